@@ -0,0 +1,398 @@
+package jobs
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	json "github.com/json-iterator/go"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// AuditConfig configures persistent audit log of job lifecycle events.
+type AuditConfig struct {
+	// Path to the audit log file.
+	Path string
+
+	// MaxSize defines maximum size of the log file in megabytes before it's being rotated. Zero disables
+	// size based rotation.
+	MaxSize int
+
+	// Interval defines how often (in seconds) log file must be rotated. Zero disables time based rotation.
+	Interval int
+
+	// Compress enables gzip compression of rotated segments.
+	Compress bool
+
+	// PayloadHash adds SHA-256 hash of the job payload to every record.
+	PayloadHash bool
+
+	// Buffer limits number of records waiting to be written, records exceeding the limit are dropped and
+	// reported as EventAuditError. Defaults to 1000.
+	Buffer int
+}
+
+// auditRecord is single line of the audit log. Every record carries the hash of the previous
+// line which makes any modification or removal of the records detectable.
+type auditRecord struct {
+	Time        time.Time `json:"time"`
+	Event       string    `json:"event"`
+	ID          string    `json:"id,omitempty"`
+	Job         string    `json:"job"`
+	Pipeline    string    `json:"pipeline,omitempty"`
+	Attempt     int       `json:"attempt"`
+	Duration    float64   `json:"duration,omitempty"`
+	Error       string    `json:"error,omitempty"`
+	PayloadHash string    `json:"payloadHash,omitempty"`
+	Prev        string    `json:"prev"`
+}
+
+// auditChunk defines how many bytes are read at once while looking for the last record of the log.
+const auditChunk = 4096
+
+// errAuditDropped reported for records which did not fit into the write buffer.
+var errAuditDropped = errors.New("audit buffer is full, record is dropped")
+
+// AuditError is passed as context of EventAuditError.
+type AuditError struct {
+	// Event is name of the event which was not recorded, empty when rotated segment can not be compressed.
+	Event string
+
+	// ID of the job (when known).
+	ID string
+
+	// Caused contains write or compression error.
+	Caused error
+}
+
+// Error returns error message.
+func (e *AuditError) Error() string {
+	return e.Caused.Error()
+}
+
+// auditor writes job events into rotating JSON lines file. Records are written in background by single writer,
+// job processing never waits for the log.
+type auditor struct {
+	cfg *AuditConfig
+	lsn func(event int, ctx interface{})
+
+	// records waiting to be written, nil while log is closed
+	mu      sync.Mutex
+	records chan *auditRecord
+	written chan interface{}
+
+	// owned by the writer
+	file    *os.File
+	size    int64
+	opened  time.Time
+	prev    string
+	rotated sync.WaitGroup
+}
+
+// newAuditor creates audit log writer, log is written only while open. Failed writes are reported to the given
+// listener as EventAuditError.
+func newAuditor(cfg *AuditConfig, lsn func(event int, ctx interface{})) *auditor {
+	return &auditor{cfg: cfg, lsn: lsn}
+}
+
+// Open opens the audit log, restores the hash chain from it's last record and starts the writer.
+func (a *auditor) Open() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.records != nil {
+		return nil
+	}
+
+	if err := a.open(); err != nil {
+		return err
+	}
+
+	size := a.cfg.Buffer
+	if size <= 0 {
+		size = 1000
+	}
+
+	a.records, a.written = make(chan *auditRecord, size), make(chan interface{})
+	go a.serve(a.records, a.written)
+
+	return nil
+}
+
+// listen writes lifecycle event into the log, only events of the jobs are recorded.
+func (a *auditor) listen(event int, ctx interface{}) {
+	name, ok := eventNames[event]
+	if !ok {
+		return
+	}
+
+	r := &auditRecord{Event: name}
+	switch e := ctx.(type) {
+	case *JobEvent:
+		r.ID, r.Pipeline, r.Attempt = e.ID, e.Pipeline, e.Attempt
+		r.Duration = e.Elapsed().Seconds()
+		a.describe(r, e.Job)
+	case *JobError:
+		r.ID, r.Pipeline, r.Attempt = e.ID, e.Pipeline, e.Attempt
+		r.Duration = e.Elapsed().Seconds()
+		r.Error = e.Error()
+		a.describe(r, e.Job)
+	default:
+		return
+	}
+
+	r.Time = time.Now()
+
+	// audit must never affect job processing, dropped records are only reported
+	a.mu.Lock()
+	if a.records == nil {
+		// events are not recorded while log is closed
+		a.mu.Unlock()
+		return
+	}
+
+	var err error
+	select {
+	case a.records <- r:
+	default:
+		err = errAuditDropped
+	}
+	a.mu.Unlock()
+
+	if err != nil {
+		a.throw(EventAuditError, &AuditError{Event: name, ID: r.ID, Caused: err})
+	}
+}
+
+// serve writes records till the channel is closed.
+func (a *auditor) serve(records chan *auditRecord, written chan interface{}) {
+	defer close(written)
+
+	for r := range records {
+		if err := a.write(r); err != nil {
+			a.throw(EventAuditError, &AuditError{Event: r.Event, ID: r.ID, Caused: err})
+		}
+	}
+}
+
+// throw handles auditor events.
+func (a *auditor) throw(event int, ctx interface{}) {
+	if a.lsn != nil {
+		a.lsn(event, ctx)
+	}
+}
+
+// describe copies job information into the record.
+func (a *auditor) describe(r *auditRecord, j *Job) {
+	if j == nil {
+		return
+	}
+
+	r.Job = j.Job
+	if a.cfg.PayloadHash {
+		sum := sha256.Sum256([]byte(j.Payload))
+		r.PayloadHash = hex.EncodeToString(sum[:])
+	}
+}
+
+// write record to the log, rotate the log when needed. Must be called by the writer.
+func (a *auditor) write(r *auditRecord) error {
+	if a.file == nil {
+		return os.ErrClosed
+	}
+
+	r.Prev = a.prev
+
+	line, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+
+	if a.mustRotate(int64(len(line) + 1)) {
+		if err := a.rotate(); err != nil {
+			return err
+		}
+	}
+
+	n, err := a.file.Write(append(line, '\n'))
+	a.size += int64(n)
+	if err != nil {
+		return err
+	}
+
+	a.prev = chainHash(line)
+	return nil
+}
+
+// Close writes buffered records, closes the log and waits for the rotated segments to be compressed.
+func (a *auditor) Close() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.records == nil {
+		return nil
+	}
+
+	close(a.records)
+	<-a.written
+	a.records = nil
+
+	a.rotated.Wait()
+
+	if a.file == nil {
+		return nil
+	}
+
+	err := a.file.Close()
+	a.file = nil
+
+	return err
+}
+
+// mustRotate returns true if log must be rotated before writing given number of bytes.
+func (a *auditor) mustRotate(size int64) bool {
+	if a.size == 0 {
+		return false
+	}
+
+	if a.cfg.MaxSize != 0 && a.size+size > int64(a.cfg.MaxSize)*1024*1024 {
+		return true
+	}
+
+	if a.cfg.Interval != 0 && time.Since(a.opened) > time.Duration(a.cfg.Interval)*time.Second {
+		return true
+	}
+
+	return false
+}
+
+// open the log file for appending and locate the hash of it's last record.
+func (a *auditor) open() error {
+	f, err := os.OpenFile(a.cfg.Path, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+
+	if info.Size() != 0 {
+		last, err := lastLine(f, info.Size())
+		if err != nil {
+			f.Close()
+			return err
+		}
+
+		a.prev = chainHash(last)
+	}
+
+	a.file, a.size, a.opened = f, info.Size(), time.Now()
+	return nil
+}
+
+// rotate moves current log into timestamped segment and starts the new log. Hash chain continues
+// into the new segment.
+func (a *auditor) rotate() error {
+	if err := a.file.Close(); err != nil {
+		return err
+	}
+
+	segment := a.cfg.Path + "." + time.Now().Format("20060102-150405.000000000")
+	if err := os.Rename(a.cfg.Path, segment); err != nil {
+		return err
+	}
+
+	if a.cfg.Compress {
+		a.rotated.Add(1)
+		go func() {
+			defer a.rotated.Done()
+			if err := compress(segment); err != nil {
+				a.throw(EventAuditError, &AuditError{Caused: err})
+			}
+		}()
+	}
+
+	prev := a.prev
+	if err := a.open(); err != nil {
+		a.file = nil
+		return err
+	}
+	a.prev = prev
+
+	return nil
+}
+
+// chainHash calculates hash of the log line.
+func chainHash(line []byte) string {
+	sum := sha256.Sum256(line)
+	return hex.EncodeToString(sum[:])
+}
+
+// lastLine reads the last non empty line of the file of given size. File is read backwards from the end.
+func lastLine(f *os.File, size int64) ([]byte, error) {
+	var tail []byte
+	for end := size; end > 0; {
+		start := end - auditChunk
+		if start < 0 {
+			start = 0
+		}
+
+		chunk := make([]byte, end-start)
+		if _, err := f.ReadAt(chunk, start); err != nil {
+			return nil, err
+		}
+
+		tail = append(chunk, tail...)
+		end = start
+
+		// trailing empty lines are skipped
+		trimmed := bytes.TrimRight(tail, " \t\r\n")
+		if i := bytes.LastIndexByte(trimmed, '\n'); i >= 0 {
+			return bytes.TrimSpace(trimmed[i+1:]), nil
+		}
+
+		if end == 0 {
+			return bytes.TrimSpace(trimmed), nil
+		}
+	}
+
+	return nil, nil
+}
+
+// compress gzips the file and removes the original.
+func compress(path string) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := os.OpenFile(path+".gz", os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+
+	zw := gzip.NewWriter(dst)
+	if _, err := io.Copy(zw, src); err != nil {
+		dst.Close()
+		return err
+	}
+
+	if err := zw.Close(); err != nil {
+		dst.Close()
+		return err
+	}
+
+	if err := dst.Close(); err != nil {
+		return err
+	}
+
+	return os.Remove(path)
+}
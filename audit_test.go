@@ -0,0 +1,207 @@
+package jobs
+
+import (
+	"bufio"
+	"compress/gzip"
+	"errors"
+	json "github.com/json-iterator/go"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func readAudit(t *testing.T, path string) (lines [][]byte, records []*auditRecord) {
+	f, err := os.Open(path)
+	assert.NoError(t, err)
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		r := &auditRecord{}
+		assert.NoError(t, json.Unmarshal(scanner.Bytes(), r))
+
+		lines = append(lines, append([]byte{}, scanner.Bytes()...))
+		records = append(records, r)
+	}
+
+	return lines, records
+}
+
+func TestAudit_Write(t *testing.T) {
+	dir, err := ioutil.TempDir("", "audit")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	a := newAuditor(&AuditConfig{Path: filepath.Join(dir, "audit.log"), PayloadHash: true}, nil)
+	assert.NoError(t, a.Open())
+
+	j := &Job{Job: "job", Payload: "body"}
+	a.listen(EventPushOK, &JobEvent{ID: "id", Job: j, Pipeline: "default"})
+	a.listen(EventJobOK, &JobEvent{ID: "id", Job: j, Pipeline: "default", Attempt: 1, elapsed: time.Second})
+	a.listen(EventJobError, &JobError{ID: "id", Job: j, Pipeline: "default", Caused: errors.New("failed")})
+	a.listen(EventPipeActive, &Pipeline{})
+	assert.NoError(t, a.Close())
+
+	lines, records := readAudit(t, filepath.Join(dir, "audit.log"))
+	assert.Len(t, records, 3)
+
+	assert.Equal(t, "push", records[0].Event)
+	assert.Equal(t, "id", records[0].ID)
+	assert.Equal(t, "job", records[0].Job)
+	assert.Equal(t, "default", records[0].Pipeline)
+	assert.Equal(t, "", records[0].Prev)
+	assert.Equal(t, "230d8358dc8e8890b4c58deeb62912ee2f20357ae92a5cc861b98e68fe31acb5", records[0].PayloadHash)
+
+	assert.Equal(t, "job.ok", records[1].Event)
+	assert.Equal(t, 1, records[1].Attempt)
+	assert.Equal(t, 1.0, records[1].Duration)
+	assert.Equal(t, chainHash(lines[0]), records[1].Prev)
+
+	assert.Equal(t, "job.error", records[2].Event)
+	assert.Equal(t, "failed", records[2].Error)
+	assert.Equal(t, chainHash(lines[1]), records[2].Prev)
+}
+
+func TestAudit_Reopen(t *testing.T) {
+	dir, err := ioutil.TempDir("", "audit")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	cfg := &AuditConfig{Path: filepath.Join(dir, "audit.log")}
+
+	a := newAuditor(cfg, nil)
+	assert.NoError(t, a.Open())
+	a.listen(EventPushOK, &JobEvent{ID: "id", Job: &Job{Job: "job"}})
+	assert.NoError(t, a.Close())
+
+	// same auditor is opened by the next serve
+	assert.NoError(t, a.Open())
+	a.listen(EventPushOK, &JobEvent{ID: "id2", Job: &Job{Job: "job"}})
+	assert.NoError(t, a.Close())
+
+	lines, records := readAudit(t, cfg.Path)
+	assert.Len(t, records, 2)
+	assert.Equal(t, chainHash(lines[0]), records[1].Prev)
+}
+
+func TestAudit_LastLine(t *testing.T) {
+	dir, err := ioutil.TempDir("", "audit")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	long := strings.Repeat("x", auditChunk*2+10)
+	for content, last := range map[string]string{
+		"first\nsecond\n":           "second",
+		"first\nsecond\n\n  \n":     "second",
+		"single":                    "single",
+		"first\n" + long + "\n":     long,
+		long + "\nlast\n":           "last",
+		"first\n" + long + "\n\n\n": long,
+	} {
+		path := filepath.Join(dir, "audit.log")
+		assert.NoError(t, ioutil.WriteFile(path, []byte(content), 0644))
+
+		f, err := os.Open(path)
+		assert.NoError(t, err)
+
+		line, err := lastLine(f, int64(len(content)))
+		assert.NoError(t, err)
+		assert.Equal(t, last, string(line))
+		f.Close()
+	}
+}
+
+func TestAudit_Rotate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "audit")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	cfg := &AuditConfig{Path: filepath.Join(dir, "audit.log"), MaxSize: 1, Compress: true, Buffer: 2000}
+
+	a := newAuditor(cfg, nil)
+	assert.NoError(t, a.Open())
+
+	j := &Job{Job: "job", Payload: strings.Repeat("a", 1024)}
+	for i := 0; i < 1100; i++ {
+		a.listen(EventPushError, &JobError{ID: "id", Job: j, Caused: errors.New(j.Payload)})
+	}
+	assert.NoError(t, a.Close())
+
+	segments, err := filepath.Glob(cfg.Path + ".*.gz")
+	assert.NoError(t, err)
+	assert.Len(t, segments, 1)
+
+	f, err := os.Open(segments[0])
+	assert.NoError(t, err)
+	defer f.Close()
+
+	zr, err := gzip.NewReader(f)
+	assert.NoError(t, err)
+
+	var last []byte
+	scanner := bufio.NewScanner(zr)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		last = append(last[:0], scanner.Bytes()...)
+	}
+	assert.NoError(t, scanner.Err())
+
+	// hash chain continues into the new segment
+	_, records := readAudit(t, cfg.Path)
+	assert.NotEmpty(t, records)
+	assert.Equal(t, chainHash(last), records[0].Prev)
+}
+
+func TestAudit_InvalidPath(t *testing.T) {
+	a := newAuditor(&AuditConfig{Path: "/missing/dir/audit.log"}, nil)
+	assert.Error(t, a.Open())
+}
+
+func TestAudit_Closed(t *testing.T) {
+	dir, err := ioutil.TempDir("", "audit")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	var failed []*AuditError
+	a := newAuditor(&AuditConfig{Path: filepath.Join(dir, "audit.log")}, func(event int, ctx interface{}) {
+		if event == EventAuditError {
+			failed = append(failed, ctx.(*AuditError))
+		}
+	})
+
+	// events before the log is opened and after it's closed are not recorded
+	a.listen(EventJobOK, &JobEvent{ID: "1", Job: &Job{Job: "job"}})
+	assert.NoError(t, a.Open())
+	assert.NoError(t, a.Close())
+	a.listen(EventJobOK, &JobEvent{ID: "2", Job: &Job{Job: "job"}})
+
+	assert.Len(t, failed, 0)
+
+	data, err := ioutil.ReadFile(filepath.Join(dir, "audit.log"))
+	assert.NoError(t, err)
+	assert.Len(t, data, 0)
+}
+
+func TestAudit_Dropped(t *testing.T) {
+	var failed []*AuditError
+	a := newAuditor(&AuditConfig{}, func(event int, ctx interface{}) {
+		if event == EventAuditError {
+			failed = append(failed, ctx.(*AuditError))
+		}
+	})
+
+	// writer is not running
+	a.records = make(chan *auditRecord, 1)
+
+	a.listen(EventJobOK, &JobEvent{ID: "1", Job: &Job{Job: "job"}})
+	a.listen(EventJobOK, &JobEvent{ID: "2", Job: &Job{Job: "job"}})
+
+	assert.Len(t, a.records, 1)
+	assert.Len(t, failed, 1)
+	assert.Equal(t, "2", failed[0].ID)
+	assert.Equal(t, errAuditDropped, failed[0].Caused)
+}
@@ -0,0 +1,252 @@
+package jobs
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// AutoscaleConfig configures automatic scaling of the worker pool based on the queue depth and job latency. Every
+// scaling step rebuilds the pool and restarts all workers, cooldowns limit how often it might happen.
+type AutoscaleConfig struct {
+	// MinWorkers defines the lowest number of workers. Defaults to 1.
+	MinWorkers int64
+
+	// MaxWorkers defines the highest number of workers.
+	MaxWorkers int64
+
+	// Interval defines how often (in seconds) pipelines stats must be checked. Defaults to 5 seconds.
+	Interval int
+
+	// Backlog defines target number of pending jobs per worker. Defaults to 10.
+	Backlog int64
+
+	// Latency defines target average queue wait in milliseconds. Zero to scale based on backlog only.
+	Latency int
+
+	// Step defines how many workers to add or remove at once. Defaults to 1.
+	Step int64
+
+	// UpCooldown defines number of seconds to wait after the start or any scaling before adding workers. Defaults
+	// to 10 seconds.
+	UpCooldown int
+
+	// DownCooldown defines number of seconds to wait after the start or any scaling before removing workers.
+	// Defaults to 60 seconds.
+	DownCooldown int
+}
+
+// InitDefaults sets missing values to their default values.
+func (c *AutoscaleConfig) InitDefaults() {
+	if c.MinWorkers == 0 {
+		c.MinWorkers = 1
+	}
+
+	if c.Interval == 0 {
+		c.Interval = 5
+	}
+
+	if c.Backlog == 0 {
+		c.Backlog = 10
+	}
+
+	if c.Step == 0 {
+		c.Step = 1
+	}
+
+	if c.UpCooldown == 0 {
+		c.UpCooldown = 10
+	}
+
+	if c.DownCooldown == 0 {
+		c.DownCooldown = 60
+	}
+}
+
+// Valid returns error if config is not valid.
+func (c *AutoscaleConfig) Valid() error {
+	if c.MinWorkers < 1 {
+		return fmt.Errorf("autoscale.minWorkers must be positive")
+	}
+
+	if c.MaxWorkers < c.MinWorkers {
+		return fmt.Errorf("autoscale.maxWorkers must be greater or equal to minWorkers")
+	}
+
+	if c.UpCooldown < 0 || c.DownCooldown < 0 {
+		return fmt.Errorf("autoscale cooldowns can not be negative")
+	}
+
+	return nil
+}
+
+// clamp fits number of workers into configured range.
+func (c *AutoscaleConfig) clamp(workers int64) int64 {
+	if workers < c.MinWorkers {
+		return c.MinWorkers
+	}
+
+	if workers > c.MaxWorkers {
+		return c.MaxWorkers
+	}
+
+	return workers
+}
+
+// ScaleEvent is passed with every scaling decision.
+type ScaleEvent struct {
+	// From is number of workers before scaling.
+	From int64
+
+	// To is number of workers after scaling.
+	To int64
+
+	// Backlog is number of pending jobs in all consumed pipelines.
+	Backlog int64
+
+	// QueueWait is average time jobs have been waiting in the queue since the last check.
+	QueueWait time.Duration
+
+	// Caused contains scaling error (EventScaleError only).
+	Caused error
+}
+
+// autoscaler periodically resizes the worker pool between configured number of workers.
+type autoscaler struct {
+	cfg *AutoscaleConfig
+	svc *Service
+
+	// current number of workers
+	workers   int64
+	lastScale time.Time
+
+	// queue wait of the jobs started since the last check
+	mu        sync.Mutex
+	waitSum   time.Duration
+	waitCount int64
+
+	stop chan interface{}
+	once sync.Once
+}
+
+// newAutoscaler creates new autoscaler with given initial number of workers.
+func newAutoscaler(svc *Service, cfg *AutoscaleConfig, workers int64) *autoscaler {
+	return &autoscaler{
+		cfg:     cfg,
+		svc:     svc,
+		workers: workers,
+		stop:    make(chan interface{}),
+	}
+}
+
+// listen collects queue wait of started jobs.
+func (a *autoscaler) listen(event int, ctx interface{}) {
+	if event != EventJobStart {
+		return
+	}
+
+	a.mu.Lock()
+	a.waitSum += ctx.(*JobEvent).QueueWait
+	a.waitCount++
+	a.mu.Unlock()
+}
+
+// serve checks pipelines with configured interval until stopped.
+func (a *autoscaler) serve() {
+	// workers have just been started, first scaling waits for the cooldown as well
+	a.lastScale = time.Now()
+
+	ticker := time.NewTicker(time.Duration(a.cfg.Interval) * time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-a.stop:
+			return
+		case <-ticker.C:
+			a.check(time.Now())
+		}
+	}
+}
+
+// Stop stops the autoscaler.
+func (a *autoscaler) Stop() {
+	a.once.Do(func() {
+		close(a.stop)
+	})
+}
+
+// check collects pipeline stats and scales the pool when needed.
+func (a *autoscaler) check(now time.Time) {
+	e := &ScaleEvent{From: a.workers, Backlog: a.backlog(), QueueWait: a.queueWait()}
+
+	e.To = a.target(e.Backlog, e.QueueWait, now)
+	if e.To == e.From {
+		return
+	}
+
+	if e.Caused = a.svc.scale(e.From, e.To, a.stop); e.Caused != nil {
+		a.svc.throw(EventScaleError, e)
+		return
+	}
+
+	a.workers, a.lastScale = e.To, now
+	a.svc.throw(EventScale, e)
+}
+
+// target calculates desired number of workers.
+func (a *autoscaler) target(backlog int64, wait time.Duration, now time.Time) int64 {
+	latency := time.Duration(a.cfg.Latency) * time.Millisecond
+
+	switch {
+	case backlog > a.workers*a.cfg.Backlog || (latency != 0 && wait > latency):
+		if now.Sub(a.lastScale) < time.Duration(a.cfg.UpCooldown)*time.Second {
+			return a.workers
+		}
+
+		return a.cfg.clamp(a.workers + a.cfg.Step)
+
+	case backlog*2 <= (a.workers-a.cfg.Step)*a.cfg.Backlog && (latency == 0 || wait*2 <= latency):
+		if now.Sub(a.lastScale) < time.Duration(a.cfg.DownCooldown)*time.Second {
+			return a.workers
+		}
+
+		return a.cfg.clamp(a.workers - a.cfg.Step)
+	}
+
+	return a.workers
+}
+
+// backlog returns number of pending jobs in all consumed pipelines.
+func (a *autoscaler) backlog() (backlog int64) {
+	for _, pipe := range a.svc.cfg.pipelines {
+		a.svc.mup.Lock()
+		consuming := a.svc.pipelines[pipe]
+		a.svc.mup.Unlock()
+
+		if !consuming {
+			continue
+		}
+
+		if stat, err := a.svc.Stat(pipe); err == nil {
+			backlog += stat.Queue
+		}
+	}
+
+	return backlog
+}
+
+// queueWait returns average queue wait since the last check and resets it.
+func (a *autoscaler) queueWait() time.Duration {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.waitCount == 0 {
+		return 0
+	}
+
+	wait := a.waitSum / time.Duration(a.waitCount)
+	a.waitSum, a.waitCount = 0, 0
+
+	return wait
+}
@@ -0,0 +1,138 @@
+package jobs
+
+import (
+	"github.com/sirupsen/logrus"
+	"github.com/spiral/roadrunner/service"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestAutoscaleConfig_Valid(t *testing.T) {
+	cfg := &AutoscaleConfig{MaxWorkers: 4}
+	cfg.InitDefaults()
+
+	assert.NoError(t, cfg.Valid())
+	assert.Equal(t, int64(1), cfg.MinWorkers)
+	assert.Equal(t, int64(10), cfg.Backlog)
+	assert.Equal(t, int64(1), cfg.Step)
+
+	assert.Equal(t, int64(1), cfg.clamp(0))
+	assert.Equal(t, int64(4), cfg.clamp(10))
+
+	assert.Error(t, (&AutoscaleConfig{MinWorkers: 2, MaxWorkers: 1}).Valid())
+	assert.Error(t, (&AutoscaleConfig{MaxWorkers: 1}).Valid())
+}
+
+func TestAutoscaler_Target(t *testing.T) {
+	cfg := &AutoscaleConfig{MaxWorkers: 3, Latency: 100}
+	cfg.InitDefaults()
+
+	a := newAutoscaler(&Service{}, cfg, 2)
+	now := time.Now()
+
+	// backlog
+	assert.Equal(t, int64(3), a.target(21, 0, now))
+	assert.Equal(t, int64(2), a.target(20, 0, now))
+
+	// latency
+	assert.Equal(t, int64(3), a.target(0, 101*time.Millisecond, now))
+	assert.Equal(t, int64(2), a.target(0, 99*time.Millisecond, now))
+
+	// idle
+	assert.Equal(t, int64(1), a.target(0, 0, now))
+	assert.Equal(t, int64(1), a.target(5, 50*time.Millisecond, now))
+
+	// cooldowns
+	a.lastScale = now
+	assert.Equal(t, int64(2), a.target(21, 0, now.Add(5*time.Second)))
+	assert.Equal(t, int64(3), a.target(21, 0, now.Add(11*time.Second)))
+	assert.Equal(t, int64(2), a.target(0, 0, now.Add(11*time.Second)))
+	assert.Equal(t, int64(1), a.target(0, 0, now.Add(61*time.Second)))
+
+	// limits
+	a.workers = 3
+	assert.Equal(t, int64(3), a.target(100, 0, now.Add(time.Hour)))
+}
+
+func TestAutoscaler_QueueWait(t *testing.T) {
+	a := newAutoscaler(&Service{}, &AutoscaleConfig{}, 1)
+
+	a.listen(EventJobStart, &JobEvent{QueueWait: time.Second})
+	a.listen(EventJobStart, &JobEvent{QueueWait: 3 * time.Second})
+	a.listen(EventJobOK, &JobEvent{QueueWait: time.Hour})
+
+	assert.Equal(t, 2*time.Second, a.queueWait())
+	assert.Equal(t, time.Duration(0), a.queueWait())
+}
+
+func TestAutoscaler_Check(t *testing.T) {
+	c := service.NewContainer(logrus.New())
+	c.Register("jobs", &Service{Brokers: map[string]Broker{"ephemeral": &testBroker{}}})
+
+	assert.NoError(t, c.Init(viperConfig(`{
+	"jobs":{
+		"pipelines":{"default":{"broker":"ephemeral"}},
+		"autoscale":{"maxWorkers": 4}
+	}
+}`)))
+
+	svc := jobs(c)
+	assert.Equal(t, int64(4), svc.cfg.Autoscale.MaxWorkers)
+
+	svc.execPool = make(chan Handler, 4)
+	for i := 0; i < 3; i++ {
+		svc.execPool <- svc.exec
+	}
+
+	events := make(chan *ScaleEvent, 10)
+	svc.AddListener(func(event int, ctx interface{}) {
+		if event == EventScale {
+			events <- ctx.(*ScaleEvent)
+		}
+	})
+
+	a := newAutoscaler(svc, svc.cfg.Autoscale, 3)
+
+	a.check(time.Now())
+	e := <-events
+	assert.Equal(t, int64(3), e.From)
+	assert.Equal(t, int64(2), e.To)
+	assert.Equal(t, int64(2), a.workers)
+	assert.Len(t, svc.execPool, 2)
+
+	// cooldown
+	a.check(time.Now())
+	assert.Len(t, events, 0)
+
+	assert.NoError(t, svc.scale(2, 4, a.stop))
+	assert.Len(t, svc.execPool, 4)
+}
+
+func TestAutoscaler_Interrupt(t *testing.T) {
+	svc := &Service{execPool: make(chan Handler, 2)}
+	svc.execPool <- svc.exec
+
+	stop := make(chan interface{})
+	close(stop)
+
+	assert.Error(t, svc.scale(2, 0, stop))
+	assert.Len(t, svc.execPool, 1)
+}
+
+func TestAutoscaler_StopTwice(t *testing.T) {
+	a := newAutoscaler(&Service{}, &AutoscaleConfig{Interval: 1}, 1)
+
+	done := make(chan interface{})
+	go func() {
+		a.serve()
+		close(done)
+	}()
+
+	a.Stop()
+	a.Stop()
+	<-done
+
+	// serving counts as the last scaling
+	assert.False(t, a.lastScale.IsZero())
+}
@@ -0,0 +1,314 @@
+package jobs
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	// BreakerClosed indicates that pipeline is consumed normally.
+	BreakerClosed = "closed"
+
+	// BreakerOpen indicates that pipeline consuming has been paused due to high failure rate.
+	BreakerOpen = "open"
+
+	// BreakerHalfOpen indicates that pipeline is consumed by limited number of probes.
+	BreakerHalfOpen = "half-open"
+)
+
+// BreakerEvent is passed with circuit breaker events.
+type BreakerEvent struct {
+	// Pipeline associated with the breaker.
+	Pipeline *Pipeline
+
+	// State of the breaker.
+	State string
+
+	// ErrorRate observed when breaker has been opened.
+	ErrorRate float64
+}
+
+// breaker pauses pipeline consuming when job error rate exceeds configured threshold. Breaker is configured
+// using `breaker` pipeline option:
+//
+//	breaker:
+//	  threshold: 50 # percent of failed jobs within window to open the breaker
+//	  requests:  10 # minimal number of jobs within window to calculate error rate
+//	  window:    60 # seconds
+//	  cooldown:  30 # seconds before consuming is probed again
+//	  probes:    1  # number of successful probe jobs to close the breaker
+//
+// Breaker never resumes pipelines paused by the operator, consuming changes are applied one by one in order of
+// breaker transitions.
+type breaker struct {
+	svc  *Service
+	pipe *Pipeline
+
+	threshold float64
+	requests  int
+	cooldown  time.Duration
+	probes    int
+
+	mu      sync.Mutex
+	state   string
+	stopped bool
+	held    bool
+	buckets []breakerBucket
+	probed  int
+	timer   *time.Timer
+
+	// consuming changes waiting to be applied
+	pending  []string
+	applying bool
+
+	// transitions to be reported once the lock is released
+	events []*BreakerEvent
+}
+
+// job outcomes within one second
+type breakerBucket struct {
+	second int64
+	total  int
+	failed int
+}
+
+// newBreaker creates new breaker for the given pipeline.
+func newBreaker(svc *Service, pipe *Pipeline) *breaker {
+	cfg := pipe.Map("breaker")
+
+	window := int(cfg.Duration("window", time.Minute).Seconds())
+	if window < 1 {
+		window = 1
+	}
+
+	return &breaker{
+		svc:       svc,
+		pipe:      pipe,
+		threshold: float64(cfg.Integer("threshold", 50)) / 100,
+		requests:  cfg.Integer("requests", 10),
+		cooldown:  cfg.Duration("cooldown", 30*time.Second),
+		probes:    cfg.Integer("probes", 1),
+		state:     BreakerClosed,
+		buckets:   make([]breakerBucket, window),
+	}
+}
+
+// State returns current breaker state.
+func (b *breaker) State() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.state
+}
+
+// listen to job outcomes of the associated pipeline.
+func (b *breaker) listen(event int, ctx interface{}) {
+	var pipeline string
+	var failed bool
+
+	switch event {
+	case EventJobOK:
+		pipeline = ctx.(*JobEvent).Pipeline
+	case EventJobError:
+		pipeline = ctx.(*JobError).Pipeline
+		failed = true
+	default:
+		return
+	}
+
+	if pipeline != b.pipe.Name() {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.unlock()
+
+	if b.stopped {
+		return
+	}
+
+	switch b.state {
+	case BreakerClosed:
+		b.record(time.Now(), failed)
+		if rate, ok := b.tripped(time.Now()); ok {
+			b.open(rate)
+		}
+
+	case BreakerHalfOpen:
+		if failed {
+			b.open(1)
+			return
+		}
+
+		b.probed++
+		if b.probed >= b.probes {
+			b.transit(BreakerClosed, 0)
+		}
+	}
+}
+
+// hold marks pipeline as paused or resumed by the operator. Resumed pipeline starts with closed breaker.
+func (b *breaker) hold(held bool) {
+	b.mu.Lock()
+	defer b.unlock()
+
+	b.held = held
+	if held || b.stopped || b.state == BreakerClosed {
+		return
+	}
+
+	if b.timer != nil {
+		b.timer.Stop()
+	}
+
+	b.transit(BreakerClosed, 0)
+}
+
+// stop disables the breaker.
+func (b *breaker) stop() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.stopped = true
+	if b.timer != nil {
+		b.timer.Stop()
+	}
+}
+
+// record job outcome.
+func (b *breaker) record(now time.Time, failed bool) {
+	sec := now.Unix()
+	bucket := &b.buckets[sec%int64(len(b.buckets))]
+	if bucket.second != sec {
+		*bucket = breakerBucket{second: sec}
+	}
+
+	bucket.total++
+	if failed {
+		bucket.failed++
+	}
+}
+
+// tripped calculates error rate within the window and returns true if it exceeds the threshold.
+func (b *breaker) tripped(now time.Time) (float64, bool) {
+	var total, failed int
+	for _, bucket := range b.buckets {
+		if now.Unix()-bucket.second < int64(len(b.buckets)) {
+			total += bucket.total
+			failed += bucket.failed
+		}
+	}
+
+	if total == 0 || total < b.requests {
+		return 0, false
+	}
+
+	rate := float64(failed) / float64(total)
+	return rate, rate >= b.threshold
+}
+
+// open the breaker and schedule the probe.
+func (b *breaker) open(rate float64) {
+	b.transit(BreakerOpen, rate)
+	b.timer = time.AfterFunc(b.cooldown, b.halfOpen)
+}
+
+// halfOpen starts probing the pipeline.
+func (b *breaker) halfOpen() {
+	b.mu.Lock()
+	defer b.unlock()
+
+	if b.stopped || b.held || b.state != BreakerOpen {
+		// paused pipeline is closed once operator resumes it, see hold
+		return
+	}
+
+	b.probed = 0
+	b.transit(BreakerHalfOpen, 0)
+}
+
+// unlock releases the lock and reports transitions made under it, listeners might request the breaker state.
+func (b *breaker) unlock() {
+	events := b.events
+	b.events = nil
+	b.mu.Unlock()
+
+	for _, e := range events {
+		switch e.State {
+		case BreakerOpen:
+			b.svc.throw(EventBreakerOpen, e)
+		case BreakerHalfOpen:
+			b.svc.throw(EventBreakerHalfOpen, e)
+		case BreakerClosed:
+			b.svc.throw(EventBreakerClose, e)
+		}
+	}
+}
+
+// transit changes breaker state and applies it to the pipeline consuming. Must be called under lock.
+func (b *breaker) transit(state string, rate float64) {
+	b.state = state
+	b.buckets = make([]breakerBucket, len(b.buckets))
+	b.events = append(b.events, &BreakerEvent{Pipeline: b.pipe, State: state, ErrorRate: rate})
+
+	// consuming changes wait for active jobs, which might be the ones we are listening to
+	b.pending = append(b.pending, state)
+	if !b.applying {
+		b.applying = true
+		go b.drain()
+	}
+}
+
+// drain applies pending consuming changes in order of breaker transitions.
+func (b *breaker) drain() {
+	for {
+		b.mu.Lock()
+		if b.stopped || len(b.pending) == 0 {
+			b.pending = nil
+			b.applying = false
+			b.mu.Unlock()
+			return
+		}
+
+		state, held := b.pending[0], b.held
+		b.pending = b.pending[1:]
+		b.mu.Unlock()
+
+		b.apply(state, held)
+	}
+}
+
+// apply given breaker state to the pipeline, paused pipelines are only stopped.
+func (b *breaker) apply(state string, held bool) {
+	if err := b.svc.Consume(b.pipe, nil, nil); err != nil || held {
+		return
+	}
+
+	switch state {
+	case BreakerHalfOpen:
+		if b.svc.execPool == nil {
+			// jobs are executed by the broker
+			b.svc.Consume(b.pipe, b.svc.pool(b.pipe), b.svc.error)
+			return
+		}
+
+		// probes are limited by the breaker and executed by the shared exec pool handlers
+		probes := make(chan Handler, b.probes)
+		for i := 0; i < b.probes; i++ {
+			probes <- b.probe
+		}
+
+		b.svc.Consume(b.pipe, probes, b.svc.error)
+
+	case BreakerClosed:
+		b.svc.Consume(b.pipe, b.svc.pool(b.pipe), b.svc.error)
+	}
+}
+
+// probe executes the job using exec pool handler.
+func (b *breaker) probe(id string, j *Job) error {
+	h := <-b.svc.execPool
+	defer func() { b.svc.execPool <- h }()
+
+	return h(id, j)
+}
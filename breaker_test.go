@@ -0,0 +1,229 @@
+package jobs
+
+import (
+	"errors"
+	"github.com/sirupsen/logrus"
+	"github.com/spiral/roadrunner/service"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestBreaker_Trip(t *testing.T) {
+	c := service.NewContainer(logrus.New())
+	c.Register("jobs", &Service{Brokers: map[string]Broker{"ephemeral": &testBroker{}}})
+
+	assert.NoError(t, c.Init(viperConfig(`{
+	"jobs":{
+		"pipelines":{"default":{"broker":"ephemeral"}}
+	}
+}`)))
+
+	svc := jobs(c)
+	svc.execPool = make(chan Handler, 1)
+	svc.execPool <- func(id string, j *Job) error { return nil }
+
+	pipe := svc.cfg.pipelines.Get("default")
+	(*pipe)["breaker"] = map[string]interface{}{"threshold": 50, "requests": 4, "cooldown": 1, "probes": 2}
+
+	b := newBreaker(svc, pipe)
+	svc.breakers[pipe] = b
+
+	ready := make(chan interface{})
+	states := make(chan string, 10)
+	svc.AddListener(func(event int, ctx interface{}) {
+		switch event {
+		case EventBrokerReady:
+			close(ready)
+		case EventBreakerOpen, EventBreakerHalfOpen, EventBreakerClose:
+			states <- ctx.(*BreakerEvent).State
+		case EventPipeActive, EventPipeStopped:
+			states <- eventNames[event]
+		}
+	})
+
+	go func() { c.Serve() }()
+	defer c.Stop()
+	<-ready
+
+	assert.NoError(t, svc.Consume(pipe, svc.execPool, svc.error))
+	assert.Equal(t, "pipe.active", <-states)
+
+	// other pipelines are ignored
+	for i := 0; i < 4; i++ {
+		b.listen(EventJobError, &JobError{Pipeline: "other", Caused: errors.New("failed")})
+	}
+
+	b.listen(EventJobOK, &JobEvent{Pipeline: "default"})
+	b.listen(EventJobError, &JobError{Pipeline: "default", Caused: errors.New("failed")})
+	b.listen(EventJobOK, &JobEvent{Pipeline: "default"})
+	assert.Equal(t, BreakerClosed, b.State())
+
+	b.listen(EventJobError, &JobError{Pipeline: "default", Caused: errors.New("failed")})
+	assert.Equal(t, BreakerOpen, <-states)
+	assert.Equal(t, "pipe.stopped", <-states)
+
+	stat, err := svc.Stat(pipe)
+	assert.NoError(t, err)
+	assert.False(t, stat.Consuming)
+	assert.Equal(t, BreakerOpen, stat.Breaker)
+
+	// probing
+	assert.Equal(t, BreakerHalfOpen, <-states)
+	assert.Equal(t, "pipe.active", <-states)
+
+	b.listen(EventJobOK, &JobEvent{Pipeline: "default"})
+	assert.Equal(t, BreakerHalfOpen, b.State())
+
+	b.listen(EventJobOK, &JobEvent{Pipeline: "default"})
+	assert.Equal(t, BreakerClosed, <-states)
+	assert.Equal(t, "pipe.stopped", <-states)
+	assert.Equal(t, "pipe.active", <-states)
+
+	stat, err = svc.Stat(pipe)
+	assert.NoError(t, err)
+	assert.True(t, stat.Consuming)
+	assert.Equal(t, BreakerClosed, stat.Breaker)
+}
+
+func TestBreaker_ProbeFailure(t *testing.T) {
+	b := newBreaker(&Service{}, &Pipeline{
+		"name":    "default",
+		"breaker": map[string]interface{}{"cooldown": 60},
+	})
+
+	b.mu.Lock()
+	b.state = BreakerHalfOpen
+	b.mu.Unlock()
+
+	b.listen(EventJobError, &JobError{Pipeline: "default", Caused: errors.New("failed")})
+	assert.Equal(t, BreakerOpen, b.State())
+
+	b.stop()
+}
+
+func TestBreaker_Window(t *testing.T) {
+	b := newBreaker(&Service{}, &Pipeline{"breaker": map[string]interface{}{"window": 2, "requests": 2}})
+
+	now := time.Now()
+	b.record(now.Add(-3*time.Second), true)
+	b.record(now.Add(-3*time.Second), true)
+	b.record(now, false)
+	b.record(now, false)
+
+	rate, tripped := b.tripped(now)
+	assert.False(t, tripped)
+	assert.Equal(t, 0.0, rate)
+
+	b.record(now, true)
+	b.record(now, true)
+
+	rate, tripped = b.tripped(now)
+	assert.True(t, tripped)
+	assert.Equal(t, 0.5, rate)
+}
+
+func TestBreaker_Paused(t *testing.T) {
+	c := service.NewContainer(logrus.New())
+	c.Register("jobs", &Service{Brokers: map[string]Broker{"ephemeral": &testBroker{}}})
+
+	assert.NoError(t, c.Init(viperConfig(`{
+	"jobs":{
+		"pipelines":{"default":{"broker":"ephemeral"}}
+	}
+}`)))
+
+	svc := jobs(c)
+	svc.execPool = make(chan Handler, 1)
+	svc.execPool <- func(id string, j *Job) error { return nil }
+
+	pipe := svc.cfg.pipelines.Get("default")
+	(*pipe)["breaker"] = map[string]interface{}{"threshold": 50, "requests": 2, "cooldown": 1}
+
+	b := newBreaker(svc, pipe)
+	svc.breakers[pipe] = b
+
+	ready := make(chan interface{})
+	states := make(chan string, 10)
+	svc.AddListener(func(event int, ctx interface{}) {
+		switch event {
+		case EventBrokerReady:
+			close(ready)
+		case EventBreakerOpen, EventBreakerHalfOpen, EventBreakerClose:
+			states <- ctx.(*BreakerEvent).State
+		case EventPipeStopped:
+			states <- eventNames[event]
+		}
+	})
+
+	go func() { c.Serve() }()
+	defer c.Stop()
+	<-ready
+
+	assert.NoError(t, svc.resume(pipe))
+
+	b.listen(EventJobError, &JobError{Pipeline: "default", Caused: errors.New("failed")})
+	b.listen(EventJobError, &JobError{Pipeline: "default", Caused: errors.New("failed")})
+	assert.Equal(t, BreakerOpen, <-states)
+	assert.Equal(t, "pipe.stopped", <-states)
+
+	// operator pauses the pipeline while breaker is open
+	assert.NoError(t, svc.pause(pipe))
+
+	select {
+	case state := <-states:
+		t.Fatalf("unexpected transition to `%s`", state)
+	case <-time.After(1500 * time.Millisecond):
+	}
+
+	stat, err := svc.Stat(pipe)
+	assert.NoError(t, err)
+	assert.False(t, stat.Consuming)
+	assert.Equal(t, BreakerOpen, stat.Breaker)
+
+	assert.NoError(t, svc.resume(pipe))
+	assert.Equal(t, BreakerClosed, <-states)
+
+	for i := 0; i < 50; i++ {
+		if stat, err = svc.Stat(pipe); err == nil && stat.Consuming {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	assert.True(t, stat.Consuming)
+	assert.Equal(t, BreakerClosed, stat.Breaker)
+}
+
+func TestBreaker_ListenerState(t *testing.T) {
+	svc := &Service{}
+	pipe := &Pipeline{"name": "default", "breaker": map[string]interface{}{"requests": 1, "cooldown": 3600}}
+
+	b := newBreaker(svc, pipe)
+	defer b.stop()
+
+	states := make(chan string, 1)
+	svc.AddListener(func(event int, ctx interface{}) {
+		if event == EventBreakerOpen {
+			states <- b.State()
+		}
+	})
+
+	b.listen(EventJobError, &JobError{Pipeline: "default", Caused: errors.New("failed")})
+	assert.Equal(t, BreakerOpen, <-states)
+}
+
+func TestBreaker_Probe(t *testing.T) {
+	calls := make(chan string, 1)
+	svc := &Service{execPool: make(chan Handler, 1)}
+	svc.execPool <- func(id string, j *Job) error {
+		calls <- id
+		return nil
+	}
+
+	b := newBreaker(svc, &Pipeline{"name": "default"})
+
+	assert.NoError(t, b.probe("id", &Job{}))
+	assert.Equal(t, "id", <-calls)
+	assert.Len(t, svc.execPool, 1)
+}
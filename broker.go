@@ -1,5 +1,11 @@
 package jobs
 
+import (
+	"errors"
+	"strings"
+	"time"
+)
+
 // Broker manages set of pipelines and provides ability to push jobs into them.
 type Broker interface {
 	// Register broker pipeline.
@@ -22,6 +28,152 @@ type EventProvider interface {
 	Listen(lsn func(event int, ctx interface{}))
 }
 
+// HealthChecker defines the ability to report broker connectivity.
+type HealthChecker interface {
+	// Health must return error if broker is not able to serve it's pipelines.
+	Health() error
+}
+
+// Router pushes jobs into pipelines by their name. Jobs are pushed directly into the pipeline broker, failover
+// pipelines and outbox of the pipeline are not used.
+type Router interface {
+	// Route pushes job into the pipeline with given name and returns job id.
+	Route(pipeline string, j *Job) (string, error)
+
+	// Healthy must return error if broker of the pipeline with given name is not able to accept jobs.
+	Healthy(pipeline string) error
+
+	// Withdraw removes pending job routed into the pipeline with given name, must return error when job can not be
+	// removed.
+	Withdraw(pipeline, id string) error
+}
+
+// Forwarder defines virtual brokers which forward jobs into other pipelines instead of storing them.
+type Forwarder interface {
+	// UseRouter attaches router to push jobs into target pipelines, called before pipelines are registered.
+	UseRouter(r Router)
+}
+
+// Executor defines brokers which execute jobs on their own instead of passing them to the exec pool handlers.
+// Pipelines of such brokers are consumed even when workers are not configured.
+type Executor interface {
+	// Executes must return true if jobs of the pipeline are executed by the broker.
+	Executes(pipe *Pipeline) bool
+}
+
+// RetryPolicy can be implemented by job errors to override retry options of the job.
+type RetryPolicy interface {
+	// Retry returns false if job must not be retried anymore, not zero delay replaces retry delay of the job.
+	Retry() (retry bool, delay time.Duration)
+}
+
+// ErrRelease can be returned by the job handler to put the job back into the pipeline without spending it's attempt,
+// released job is not reported as failed. Brokers which count deliveries on their own might still count it.
+var ErrRelease = errors.New("job has been released")
+
+// NextRetry returns true if failed job can be retried and delay of it's next attempt.
+func NextRetry(j *Job, attempt int, err error) (bool, time.Duration) {
+	if j.Options == nil {
+		return false, 0
+	}
+
+	retry, delay := j.Options.CanRetry(attempt), j.Options.RetryDuration()
+	if p, ok := err.(RetryPolicy); ok {
+		canRetry, d := p.Retry()
+		if !canRetry {
+			return false, 0
+		}
+
+		if d != 0 {
+			delay = d
+		}
+	}
+
+	return retry, delay
+}
+
+// Inspector defines the ability to look at pending jobs without consuming them. Brokers which only expose messages
+// by receiving them (SQS) return a sample of pending jobs and increase receive count of the inspected messages,
+// repeated inspection might move pending jobs into the dead letter queue of the queue redrive policy.
+type Inspector interface {
+	// List returns pending jobs of the pipeline, starting from given offset.
+	List(pipe *Pipeline, offset, limit int) ([]*JobInfo, error)
+
+	// Peek returns pending job by it's id.
+	Peek(pipe *Pipeline, id string) (*JobInfo, error)
+}
+
+// Remover defines the ability to remove pending job by it's id.
+type Remover interface {
+	// Remove deletes pending job of the pipeline, must return error if job is not pending anymore.
+	Remove(pipe *Pipeline, id string) error
+}
+
+// Purger defines the ability to remove pending jobs from the pipeline.
+type Purger interface {
+	// Purge removes pending jobs from the pipeline and returns number of removed jobs, -1 when broker
+	// does not report it.
+	Purge(pipe *Pipeline, opts PurgeOptions) (int, error)
+}
+
+// PurgeOptions defines which jobs must be purged in addition to the ready ones.
+type PurgeOptions struct {
+	// Delayed enables purging of delayed jobs.
+	Delayed bool `json:"delayed"`
+
+	// Failed enables purging of failed (buried) jobs.
+	Failed bool `json:"failed"`
+}
+
+// Requeuer defines the ability to bring failed jobs back to the pipeline.
+type Requeuer interface {
+	// Requeue moves failed jobs back to the pipeline with reset attempt counter and returns number of
+	// requeued jobs.
+	Requeue(pipe *Pipeline, opts RequeueOptions) (int, error)
+}
+
+// FailedInspector defines the ability to look at failed jobs retained by the broker and bring them back.
+type FailedInspector interface {
+	// ListFailed returns failed jobs of the pipeline in order of their failure, starting from given offset.
+	ListFailed(pipe *Pipeline, offset, limit int) ([]*FailedJob, error)
+
+	// RetryFailed pushes failed job back to the pipeline with reset attempt counter.
+	RetryFailed(pipe *Pipeline, id string) error
+
+	// DeleteFailed removes failed job without retrying it.
+	DeleteFailed(pipe *Pipeline, id string) error
+}
+
+// RequeueOptions limits the set of failed jobs to be requeued.
+type RequeueOptions struct {
+	// Jobs contains job name patterns to requeue, all failed jobs are requeued when empty.
+	Jobs []string `json:"jobs"`
+
+	// Max defines maximum number of jobs to requeue, 0 - no limit.
+	Max int `json:"max"`
+}
+
+// Match must return true if job with given name must be requeued.
+func (o RequeueOptions) Match(job string) bool {
+	if len(o.Jobs) == 0 {
+		return true
+	}
+
+	job = normalizeName(job)
+	for _, pattern := range o.Jobs {
+		if strings.HasPrefix(job, normalizePattern(pattern)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Exceeded must return true when given number of requeued jobs reached the limit.
+func (o RequeueOptions) Exceeded(n int) bool {
+	return o.Max > 0 && n >= o.Max
+}
+
 // Stat contains information about pipeline.
 type Stat struct {
 	// Pipeline name.
@@ -44,4 +196,47 @@ type Stat struct {
 
 	// Delayed defines number of jobs which are being processed.
 	Delayed int64
+
+	// OldestAge defines how long the oldest pending job has been waiting in the queue. Zero when the queue is empty
+	// or broker is unable to tell the age.
+	OldestAge time.Duration
+
+	// Pushed defines number of jobs pushed into the pipeline since service start.
+	Pushed int64
+
+	// Processed defines number of successfully processed jobs since service start.
+	Processed int64
+
+	// Errors defines number of failed job executions since service start.
+	Errors int64
+
+	// Retried defines number of failed jobs scheduled for the next attempt since service start.
+	Retried int64
+
+	// PushRate defines average number of pushed jobs per second within the last minute.
+	PushRate float64
+
+	// ProcessRate defines average number of executed jobs per second within the last minute.
+	ProcessRate float64
+
+	// AvgDuration defines average execution time of recent jobs.
+	AvgDuration time.Duration
+
+	// P95Duration defines 95th percentile of recent jobs execution time.
+	P95Duration time.Duration
+
+	// Breaker defines state of the pipeline circuit breaker (if any).
+	Breaker string
+
+	// Capacity defines maximum number of pending jobs, 0 - unlimited.
+	Capacity int64
+
+	// Dropped defines number of jobs dropped due to the queue overflow.
+	Dropped int64
+
+	// Failed defines number of failed jobs retained by the broker.
+	Failed int64
+
+	// Outbox defines number of jobs journaled in the local outbox waiting to be pushed into the pipeline.
+	Outbox int64
 }
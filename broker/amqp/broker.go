@@ -79,7 +79,7 @@ func (b *Broker) Serve() (err error) {
 	for _, q := range b.queues {
 		qq := q
 		if qq.execPool != nil {
-			go qq.serve(b.publish, b.consume)
+			qq.start(b.publish, b.consume)
 		}
 	}
 
@@ -129,7 +129,7 @@ func (b *Broker) Consume(pipe *jobs.Pipeline, execPool chan jobs.Handler, errHan
 
 	if b.publish != nil && q.execPool != nil {
 		if q.execPool != nil {
-			go q.serve(b.publish, b.consume)
+			q.start(b.publish, b.consume)
 		}
 	}
 
@@ -159,7 +159,8 @@ func (b *Broker) Push(pipe *jobs.Pipeline, j *jobs.Job) (string, error) {
 	return id.String(), nil
 }
 
-// Stat must fetch statistics about given pipeline or return error.
+// Stat must fetch statistics about given pipeline or return error. AMQP does not expose age of the queued
+// messages without consuming them, OldestAge is always zero.
 func (b *Broker) Stat(pipe *jobs.Pipeline) (stat *jobs.Stat, err error) {
 	if err := b.isServing(); err != nil {
 		return nil, err
@@ -183,6 +184,91 @@ func (b *Broker) Stat(pipe *jobs.Pipeline) (stat *jobs.Stat, err error) {
 	}, nil
 }
 
+// Health returns error if any of broker connections is dead or reconnecting.
+func (b *Broker) Health() error {
+	if err := b.isServing(); err != nil {
+		return err
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if err := b.publish.healthy(); err != nil {
+		return err
+	}
+
+	return b.consume.healthy()
+}
+
+// List returns pending jobs of the pipeline. Messages are fetched without acknowledgement and returned back
+// to the queue once the scan ends, listed messages are marked as redelivered and can not be consumed during the
+// scan. At most `inspectLimit` messages (1000 by default) are scanned.
+func (b *Broker) List(pipe *jobs.Pipeline, offset, limit int) ([]*jobs.JobInfo, error) {
+	if err := b.isServing(); err != nil {
+		return nil, err
+	}
+
+	q := b.queue(pipe)
+	if q == nil {
+		return nil, fmt.Errorf("undefined queue `%s`", pipe.Name())
+	}
+
+	return q.list(b.publish, offset, limit)
+}
+
+// Peek returns pending job by it's id, method scans the queue until the job is found. Only first `inspectLimit`
+// messages (1000 by default) are scanned, scanned messages can not be consumed until the scan ends.
+func (b *Broker) Peek(pipe *jobs.Pipeline, id string) (*jobs.JobInfo, error) {
+	if err := b.isServing(); err != nil {
+		return nil, err
+	}
+
+	q := b.queue(pipe)
+	if q == nil {
+		return nil, fmt.Errorf("undefined queue `%s`", pipe.Name())
+	}
+
+	return q.peek(b.publish, id)
+}
+
+// Purge removes pending jobs from the queue. Delayed jobs are purged from the delay queues declared since
+// the broker start. AMQP does not keep failed jobs, purging them is not supported.
+func (b *Broker) Purge(pipe *jobs.Pipeline, opts jobs.PurgeOptions) (int, error) {
+	if err := b.isServing(); err != nil {
+		return 0, err
+	}
+
+	q := b.queue(pipe)
+	if q == nil {
+		return 0, fmt.Errorf("undefined queue `%s`", pipe.Name())
+	}
+
+	if opts.Failed {
+		return 0, fmt.Errorf("unable to purge failed jobs of `%s`, amqp broker does not keep failed jobs", pipe.Name())
+	}
+
+	return q.purge(b.publish, opts.Delayed)
+}
+
+// Requeue moves dead-lettered jobs from the queue defined by pipeline `dlq` option back to the pipeline.
+func (b *Broker) Requeue(pipe *jobs.Pipeline, opts jobs.RequeueOptions) (int, error) {
+	if err := b.isServing(); err != nil {
+		return 0, err
+	}
+
+	q := b.queue(pipe)
+	if q == nil {
+		return 0, fmt.Errorf("undefined queue `%s`", pipe.Name())
+	}
+
+	dlq := pipe.String("dlq", "")
+	if dlq == "" {
+		return 0, fmt.Errorf("missing `dlq` parameter on amqp pipeline `%s`", pipe.Name())
+	}
+
+	return q.requeue(b.publish, dlq, opts)
+}
+
 // check if broker is serving
 func (b *Broker) isServing() error {
 	b.mu.Lock()
@@ -100,6 +100,16 @@ func (cp *chanPool) waitConnected() chan interface{} {
 	return cp.connected
 }
 
+// healthy returns error if connection is being reconnected.
+func (cp *chanPool) healthy() error {
+	select {
+	case <-cp.waitConnected():
+		return nil
+	default:
+		return fmt.Errorf("connection is dead or reconnecting")
+	}
+}
+
 // watch manages connection state and reconnects if needed
 func (cp *chanPool) watch() {
 	for {
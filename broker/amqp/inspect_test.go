@@ -0,0 +1,159 @@
+package amqp
+
+import (
+	"github.com/spiral/jobs/v2"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestBroker_List(t *testing.T) {
+	b := &Broker{}
+	_, err := b.Init(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b.Register(pipe)
+
+	ready := make(chan interface{})
+	b.Listen(func(event int, ctx interface{}) {
+		if event == jobs.EventBrokerReady {
+			close(ready)
+		}
+	})
+
+	go func() { assert.NoError(t, b.Serve()) }()
+	defer b.Stop()
+
+	<-ready
+
+	jid, perr := b.Push(pipe, &jobs.Job{Job: "test", Payload: "body", Options: &jobs.Options{}})
+	assert.NotEqual(t, "", jid)
+	assert.NoError(t, perr)
+
+	list, err := b.List(pipe, 0, 10)
+	assert.NoError(t, err)
+	assert.Len(t, list, 1)
+	assert.Equal(t, jid, list[0].ID)
+	assert.Equal(t, "body", list[0].Job.Payload)
+	assert.Equal(t, jobs.JobReady, list[0].State)
+
+	info, err := b.Peek(pipe, jid)
+	assert.NoError(t, err)
+	assert.Equal(t, "test", info.Job.Job)
+
+	// inspected job stays in the queue
+	exec := make(chan jobs.Handler, 1)
+	assert.NoError(t, b.Consume(pipe, exec, func(id string, j *jobs.Job, err error) {}))
+
+	waitJob := make(chan interface{})
+	exec <- func(id string, j *jobs.Job) error {
+		assert.Equal(t, jid, id)
+		close(waitJob)
+		return nil
+	}
+
+	<-waitJob
+}
+
+func TestBroker_Purge(t *testing.T) {
+	b := &Broker{}
+	_, err := b.Init(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b.Register(pipe)
+
+	ready := make(chan interface{})
+	b.Listen(func(event int, ctx interface{}) {
+		if event == jobs.EventBrokerReady {
+			close(ready)
+		}
+	})
+
+	go func() { assert.NoError(t, b.Serve()) }()
+	defer b.Stop()
+
+	<-ready
+
+	for i := 0; i < 3; i++ {
+		_, err := b.Push(pipe, &jobs.Job{Job: "test", Payload: "body", Options: &jobs.Options{}})
+		assert.NoError(t, err)
+	}
+
+	_, err = b.Purge(pipe, jobs.PurgeOptions{Failed: true})
+	assert.Error(t, err)
+
+	n, err := b.Purge(pipe, jobs.PurgeOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, n)
+
+	stat, err := b.Stat(pipe)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), stat.Queue)
+}
+
+func TestBroker_Requeue(t *testing.T) {
+	b := &Broker{}
+	_, err := b.Init(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	source := &jobs.Pipeline{
+		"broker":   "amqp",
+		"name":     "source",
+		"queue":    "rr-source",
+		"exchange": "rr-exchange",
+		"dlq":      "rr-dead",
+	}
+
+	dead := &jobs.Pipeline{
+		"broker":   "amqp",
+		"name":     "dead",
+		"queue":    "rr-dead",
+		"exchange": "rr-exchange",
+	}
+
+	b.Register(source)
+	b.Register(dead)
+
+	ready := make(chan interface{})
+	b.Listen(func(event int, ctx interface{}) {
+		if event == jobs.EventBrokerReady {
+			close(ready)
+		}
+	})
+
+	go func() { assert.NoError(t, b.Serve()) }()
+	defer b.Stop()
+
+	<-ready
+
+	for _, name := range []string{"test.failed", "other", "test.failed"} {
+		_, err := b.Push(dead, &jobs.Job{Job: name, Payload: "body", Options: &jobs.Options{}})
+		assert.NoError(t, err)
+	}
+
+	n, err := b.Requeue(source, jobs.RequeueOptions{Jobs: []string{"test"}, Max: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, n)
+
+	n, err = b.Requeue(source, jobs.RequeueOptions{Jobs: []string{"test"}})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, n)
+
+	list, err := b.List(source, 0, 10)
+	assert.NoError(t, err)
+	assert.Len(t, list, 2)
+	assert.Equal(t, 0, list[0].Attempt)
+
+	_, err = b.Requeue(dead, jobs.RequeueOptions{})
+	assert.Error(t, err)
+
+	n, err = b.Purge(dead, jobs.PurgeOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, n)
+
+	_, err = b.Purge(source, jobs.PurgeOptions{})
+	assert.NoError(t, err)
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"github.com/spiral/jobs/v2"
 	"github.com/streadway/amqp"
+	"time"
 )
 
 // pack job metadata into headers
@@ -54,3 +55,12 @@ func unpack(d amqp.Delivery) (id string, attempt int, j *jobs.Job, err error) {
 
 	return d.Headers["rr-id"].(string), int(d.Headers["rr-attempt"].(int64)), j, nil
 }
+
+// queued returns time when message became available in the queue, zero if unknown.
+func queued(d amqp.Delivery) time.Time {
+	if ts, ok := d.Headers["rr-queued"].(int64); ok {
+		return time.Unix(0, ts)
+	}
+
+	return time.Time{}
+}
@@ -50,6 +50,17 @@ type queue struct {
 	muc sync.Mutex
 	cc  *channel
 
+	// stop channel
+	wait chan interface{}
+
+	// serializes queue inspections, limits number of messages fetched by one inspection
+	mui       sync.Mutex
+	scanLimit int
+
+	// declared delay queues
+	mud     sync.Mutex
+	delayed map[string]bool
+
 	// queue events
 	lsn func(event int, ctx interface{})
 
@@ -84,18 +95,30 @@ func newQueue(pipe *jobs.Pipeline, lsn func(event int, ctx interface{})) (*queue
 		consumer: pipe.String("consumer", fmt.Sprintf("rr-jobs:%s-%v", pipe.Name(), os.Getpid())),
 		pipe:     pipe,
 		lsn:      lsn,
+		delayed:  make(map[string]bool),
+		scanLimit: pipe.Integer("inspectLimit", 1000),
 	}, nil
 }
 
-// serve consumes queue
-func (q *queue) serve(publish, consume *chanPool) {
+// start consuming in background, queue is marked as consumed before the method returns so it can be stopped
+// right away.
+func (q *queue) start(publish, consume *chanPool) {
+	q.wait = make(chan interface{})
 	atomic.StoreInt32(&q.active, 1)
 
+	go q.serve(publish, consume, q.wait)
+}
+
+// serve consumes queue till given channel is closed.
+func (q *queue) serve(publish, consume *chanPool, wait chan interface{}) {
 	for {
 		<-consume.waitConnected()
-		if atomic.LoadInt32(&q.active) == 0 {
+
+		select {
+		case <-wait:
 			// stopped
 			return
+		default:
 		}
 
 		delivery, cc, err := q.consume(consume)
@@ -160,12 +183,24 @@ func (q *queue) do(cp *chanPool, h jobs.Handler, d amqp.Delivery) error {
 		q.report(err)
 		return d.Nack(false, false)
 	}
+
+	j.Delivery = &jobs.Delivery{Pipeline: q.pipe.Name(), Attempt: attempt, Queued: queued(d)}
 	err = h(id, j)
 
 	if err == nil {
 		return d.Ack(false)
 	}
 
+	if err == jobs.ErrRelease {
+		// republish with the same attempt number
+		if err = q.publish(cp, id, attempt, j, 0); err != nil {
+			q.report(err)
+			return d.Nack(false, true)
+		}
+
+		return d.Ack(false)
+	}
+
 	// failed
 	q.errHandler(id, j, err)
 
@@ -179,6 +214,8 @@ func (q *queue) do(cp *chanPool, h jobs.Handler, d amqp.Delivery) error {
 		return d.Nack(false, true)
 	}
 
+	q.lsn(jobs.EventJobRelease, &jobs.JobEvent{ID: id, Job: j, Pipeline: q.pipe.Name(), Attempt: attempt + 1})
+
 	return d.Ack(false)
 }
 
@@ -188,6 +225,7 @@ func (q *queue) stop() {
 	}
 
 	atomic.StoreInt32(&q.active, 0)
+	close(q.wait)
 
 	q.muc.Lock()
 	if q.cc != nil {
@@ -209,6 +247,8 @@ func (q *queue) publish(cp *chanPool, id string, attempt int, j *jobs.Job, delay
 	}
 
 	qKey := q.key
+	headers := pack(id, attempt, j)
+	headers["rr-queued"] = time.Now().Add(delay).UnixNano()
 
 	if delay != 0 {
 		delayMs := int64(delay.Seconds() * 1000)
@@ -225,6 +265,10 @@ func (q *queue) publish(cp *chanPool, id string, attempt int, j *jobs.Job, delay
 		if err != nil {
 			return err
 		}
+
+		q.mud.Lock()
+		q.delayed[qName] = true
+		q.mud.Unlock()
 	}
 
 	err = c.ch.Publish(
@@ -236,7 +280,7 @@ func (q *queue) publish(cp *chanPool, id string, attempt int, j *jobs.Job, delay
 			ContentType:  "application/octet-stream",
 			Body:         j.Body(),
 			DeliveryMode: amqp.Persistent,
-			Headers:      pack(id, attempt, j),
+			Headers:      headers,
 		},
 	)
 
@@ -294,6 +338,181 @@ func (q *queue) inspect(cp *chanPool) (*amqp.Queue, error) {
 	return &queue, err
 }
 
+// list fetches pending messages without acknowledging them and returns them back to the queue.
+func (q *queue) list(cp *chanPool, offset, limit int) ([]*jobs.JobInfo, error) {
+	list := make([]*jobs.JobInfo, 0)
+
+	err := q.scan(cp, func(n int, info *jobs.JobInfo) bool {
+		if n >= offset {
+			list = append(list, info)
+		}
+
+		return limit <= 0 || len(list) < limit
+	})
+
+	return list, err
+}
+
+// peek scans the queue for the message with given id.
+func (q *queue) peek(cp *chanPool, id string) (*jobs.JobInfo, error) {
+	var found *jobs.JobInfo
+
+	err := q.scan(cp, func(n int, info *jobs.JobInfo) bool {
+		if info.ID == id {
+			found = info
+		}
+
+		return found == nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	if found == nil {
+		return nil, fmt.Errorf("undefined job `%s`", id)
+	}
+
+	return found, nil
+}
+
+// scan gets queue messages one by one until given function returns false, queue is empty or the scan limit is
+// reached. Fetched messages stay unacked until the scan ends and can not be consumed meanwhile, then they are
+// returned back to the queue at once, which may change their order when other consumers ack or requeue messages
+// during the scan.
+func (q *queue) scan(cp *chanPool, f func(n int, info *jobs.JobInfo) bool) error {
+	q.mui.Lock()
+	defer q.mui.Unlock()
+
+	c, err := cp.channel("inspect:" + q.name)
+	if err != nil {
+		return err
+	}
+
+	var last uint64
+	for n := 0; q.scanLimit <= 0 || n < q.scanLimit; n++ {
+		d, ok, err := c.ch.Get(q.name, false)
+		if err != nil {
+			return cp.closeChan(c, err)
+		}
+
+		if !ok {
+			break
+		}
+
+		last = d.DeliveryTag
+
+		id, attempt, j, err := unpack(d)
+		if err != nil {
+			// foreign message
+			continue
+		}
+
+		if !f(n, &jobs.JobInfo{ID: id, Job: j, State: jobs.JobReady, Attempt: attempt}) {
+			break
+		}
+	}
+
+	if last != 0 {
+		if err := c.ch.Nack(last, true, true); err != nil {
+			return cp.closeChan(c, err)
+		}
+	}
+
+	// keep channel open
+	return nil
+}
+
+// purge removes all messages from the queue and, when requested, from the delay queues declared by this queue.
+func (q *queue) purge(cp *chanPool, delayed bool) (int, error) {
+	c, err := cp.channel(q.name)
+	if err != nil {
+		return 0, err
+	}
+
+	n, err := c.ch.QueuePurge(q.name, false)
+	if err != nil {
+		return 0, cp.closeChan(c, err)
+	}
+
+	if !delayed {
+		return n, nil
+	}
+
+	q.mud.Lock()
+	defer q.mud.Unlock()
+
+	for name := range q.delayed {
+		c, err := cp.channel(q.name)
+		if err != nil {
+			return n, err
+		}
+
+		dn, err := c.ch.QueuePurge(name, false)
+		if err != nil {
+			// delay queue has expired
+			cp.closeChan(c, err)
+			delete(q.delayed, name)
+			continue
+		}
+
+		n += dn
+	}
+
+	return n, nil
+}
+
+// requeue moves messages from the dead letter queue back to the queue as new jobs with reset attempt
+// counter. Messages which do not match the options are returned to the dead letter queue.
+func (q *queue) requeue(cp *chanPool, dlq string, opts jobs.RequeueOptions) (n int, err error) {
+	q.mui.Lock()
+	defer q.mui.Unlock()
+
+	c, err := cp.channel("requeue:" + q.name)
+	if err != nil {
+		return 0, err
+	}
+
+	var last uint64
+	for !opts.Exceeded(n) {
+		d, ok, err := c.ch.Get(dlq, false)
+		if err != nil {
+			return n, cp.closeChan(c, err)
+		}
+
+		if !ok {
+			break
+		}
+
+		id, _, j, err := unpack(d)
+		if err != nil || !opts.Match(j.Job) {
+			// foreign or filtered message
+			last = d.DeliveryTag
+			continue
+		}
+
+		if err := q.publish(cp, id, 0, j, 0); err != nil {
+			c.ch.Nack(d.DeliveryTag, true, true)
+			return n, err
+		}
+
+		if err := d.Ack(false); err != nil {
+			return n, cp.closeChan(c, err)
+		}
+
+		n++
+	}
+
+	if last != 0 {
+		if err := c.ch.Nack(last, true, true); err != nil {
+			return n, cp.closeChan(c, err)
+		}
+	}
+
+	// keep channel open
+	return n, nil
+}
+
 // throw handles service, server and pool events.
 func (q *queue) report(err error) {
 	if err != nil {
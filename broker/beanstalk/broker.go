@@ -3,6 +3,7 @@ package beanstalk
 import (
 	"fmt"
 	"github.com/spiral/jobs/v2"
+	"strconv"
 	"sync"
 )
 
@@ -61,7 +62,7 @@ func (b *Broker) Serve() (err error) {
 	for _, t := range b.tubes {
 		tt := t
 		if tt.execPool != nil {
-			go tt.serve(b.cfg)
+			tt.start(b.cfg)
 		}
 	}
 
@@ -112,7 +113,7 @@ func (b *Broker) Consume(pipe *jobs.Pipeline, execPool chan jobs.Handler, errHan
 	if b.conn != nil {
 		tt := t
 		if tt.execPool != nil {
-			go tt.serve(connFactory(b.cfg))
+			tt.start(connFactory(b.cfg))
 		}
 	}
 
@@ -152,6 +153,111 @@ func (b *Broker) Stat(pipe *jobs.Pipeline) (stat *jobs.Stat, err error) {
 	return t.stat(b.conn)
 }
 
+// Health returns error if broker connection is dead or reconnecting.
+func (b *Broker) Health() error {
+	if err := b.isServing(); err != nil {
+		return err
+	}
+
+	return b.conn.healthy()
+}
+
+// List returns next ready, delayed and buried jobs of the tube, beanstalk does not allow to list all
+// the jobs.
+func (b *Broker) List(pipe *jobs.Pipeline, offset, limit int) ([]*jobs.JobInfo, error) {
+	if err := b.isServing(); err != nil {
+		return nil, err
+	}
+
+	t := b.tube(pipe)
+	if t == nil {
+		return nil, fmt.Errorf("undefined tube `%s`", pipe.Name())
+	}
+
+	list, err := t.list(b.conn)
+	if err != nil {
+		return nil, err
+	}
+
+	if offset >= len(list) {
+		return []*jobs.JobInfo{}, nil
+	}
+
+	list = list[offset:]
+	if limit > 0 && limit < len(list) {
+		list = list[:limit]
+	}
+
+	return list, nil
+}
+
+// Peek returns job by it's id.
+func (b *Broker) Peek(pipe *jobs.Pipeline, id string) (*jobs.JobInfo, error) {
+	if err := b.isServing(); err != nil {
+		return nil, err
+	}
+
+	t := b.tube(pipe)
+	if t == nil {
+		return nil, fmt.Errorf("undefined tube `%s`", pipe.Name())
+	}
+
+	bid, err := strconv.ParseUint(id, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("undefined job `%s`", id)
+	}
+
+	return t.peek(b.conn, bid)
+}
+
+// Remove deletes job by it's id.
+func (b *Broker) Remove(pipe *jobs.Pipeline, id string) error {
+	if err := b.isServing(); err != nil {
+		return err
+	}
+
+	t := b.tube(pipe)
+	if t == nil {
+		return fmt.Errorf("undefined tube `%s`", pipe.Name())
+	}
+
+	bid, err := strconv.ParseUint(id, 10, 64)
+	if err != nil {
+		return fmt.Errorf("undefined job `%s`", id)
+	}
+
+	return t.remove(b.conn, bid)
+}
+
+// Purge deletes pending jobs of the tube, failed jobs are the buried ones.
+func (b *Broker) Purge(pipe *jobs.Pipeline, opts jobs.PurgeOptions) (int, error) {
+	if err := b.isServing(); err != nil {
+		return 0, err
+	}
+
+	t := b.tube(pipe)
+	if t == nil {
+		return 0, fmt.Errorf("undefined tube `%s`", pipe.Name())
+	}
+
+	return t.purge(b.conn, opts.Delayed, opts.Failed)
+}
+
+// Requeue moves buried jobs back to the tube keeping their priority, requeued jobs start from the first attempt.
+// Requeue stops at the first buried job which does not match given job names.
+func (b *Broker) Requeue(pipe *jobs.Pipeline, opts jobs.RequeueOptions) (int, error) {
+	if err := b.isServing(); err != nil {
+		return 0, err
+	}
+
+	t := b.tube(pipe)
+	if t == nil {
+		return 0, fmt.Errorf("undefined tube `%s`", pipe.Name())
+	}
+
+	return t.requeue(b.conn, opts)
+}
+
 // check if broker is serving
 func (b *Broker) isServing() error {
 	b.mu.Lock()
@@ -6,6 +6,7 @@ import (
 	"github.com/cenkalti/backoff/v4"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -19,13 +20,14 @@ type connFactory interface {
 // conn protects allocation for one connection between
 // threads and provides reconnecting capabilities.
 type conn struct {
-	tout  time.Duration
-	conn  *beanstalk.Conn
-	alive bool
-	free  chan interface{}
-	dead  chan interface{}
-	stop  chan interface{}
-	lock  *sync.Cond
+	tout   time.Duration
+	conn   *beanstalk.Conn
+	alive  bool
+	redial int32
+	free   chan interface{}
+	dead   chan interface{}
+	stop   chan interface{}
+	lock   *sync.Cond
 }
 
 // creates new beanstalk connection and reconnect watcher.
@@ -100,6 +102,7 @@ func (cn *conn) acquire(mandatory bool) (*beanstalk.Conn, error) {
 func (cn *conn) release(err error) error {
 	if isConnError(err) {
 		// reconnect is required
+		atomic.StoreInt32(&cn.redial, 1)
 		cn.dead <- err
 	} else {
 		cn.free <- nil
@@ -108,6 +111,15 @@ func (cn *conn) release(err error) error {
 	return err
 }
 
+// healthy returns error if connection is being redialed.
+func (cn *conn) healthy() error {
+	if atomic.LoadInt32(&cn.redial) == 1 {
+		return fmt.Errorf("connection is dead or reconnecting")
+	}
+
+	return nil
+}
+
 // watch and reconnect if dead
 func (cn *conn) watch(network, addr string) {
 	cn.free <- nil
@@ -134,6 +146,7 @@ func (cn *conn) watch(network, addr string) {
 				fmt.Println("------beanstalk successfully redialed------")
 
 				cn.conn = conn
+				atomic.StoreInt32(&cn.redial, 0)
 				cn.free <- nil
 				return nil
 			}
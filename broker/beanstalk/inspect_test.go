@@ -0,0 +1,187 @@
+package beanstalk
+
+import (
+	"fmt"
+	"github.com/spiral/jobs/v2"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestBroker_List(t *testing.T) {
+	b := &Broker{}
+	_, err := b.Init(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b.Register(pipe)
+
+	ready := make(chan interface{})
+	b.Listen(func(event int, ctx interface{}) {
+		if event == jobs.EventBrokerReady {
+			close(ready)
+		}
+	})
+
+	go func() { assert.NoError(t, b.Serve()) }()
+	defer b.Stop()
+
+	<-ready
+
+	jid, perr := b.Push(pipe, &jobs.Job{Job: "test", Payload: "body", Options: &jobs.Options{}})
+	assert.NotEqual(t, "", jid)
+	assert.NoError(t, perr)
+
+	list, err := b.List(pipe, 0, 10)
+	assert.NoError(t, err)
+	assert.Len(t, list, 1)
+	assert.Equal(t, jid, list[0].ID)
+	assert.Equal(t, "body", list[0].Job.Payload)
+	assert.Equal(t, jobs.JobReady, list[0].State)
+
+	info, err := b.Peek(pipe, jid)
+	assert.NoError(t, err)
+	assert.Equal(t, "test", info.Job.Job)
+
+	// inspected job stays in the queue
+	exec := make(chan jobs.Handler, 1)
+	assert.NoError(t, b.Consume(pipe, exec, func(id string, j *jobs.Job, err error) {}))
+
+	waitJob := make(chan interface{})
+	exec <- func(id string, j *jobs.Job) error {
+		assert.Equal(t, jid, id)
+		close(waitJob)
+		return nil
+	}
+
+	<-waitJob
+}
+
+func TestBroker_Remove(t *testing.T) {
+	b := &Broker{}
+	_, err := b.Init(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b.Register(pipe)
+
+	ready := make(chan interface{})
+	b.Listen(func(event int, ctx interface{}) {
+		if event == jobs.EventBrokerReady {
+			close(ready)
+		}
+	})
+
+	go func() { assert.NoError(t, b.Serve()) }()
+	defer b.Stop()
+
+	<-ready
+
+	jid, perr := b.Push(pipe, &jobs.Job{Job: "test", Payload: "body", Options: &jobs.Options{Delay: 60}})
+	assert.NoError(t, perr)
+
+	info, err := b.Peek(pipe, jid)
+	assert.NoError(t, err)
+	assert.Equal(t, jobs.JobDelayed, info.State)
+	assert.True(t, info.Available.After(time.Now().Add(50*time.Second)))
+
+	assert.NoError(t, b.Remove(pipe, jid))
+	assert.Error(t, b.Remove(pipe, jid))
+
+	_, err = b.Peek(pipe, jid)
+	assert.Error(t, err)
+}
+
+func TestBroker_Purge(t *testing.T) {
+	b := &Broker{}
+	_, err := b.Init(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b.Register(pipe)
+
+	ready := make(chan interface{})
+	b.Listen(func(event int, ctx interface{}) {
+		if event == jobs.EventBrokerReady {
+			close(ready)
+		}
+	})
+
+	go func() { assert.NoError(t, b.Serve()) }()
+	defer b.Stop()
+
+	<-ready
+
+	for i := 0; i < 3; i++ {
+		_, err := b.Push(pipe, &jobs.Job{Job: "test", Payload: "body", Options: &jobs.Options{}})
+		assert.NoError(t, err)
+	}
+
+	n, err := b.Purge(pipe, jobs.PurgeOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, n)
+
+	stat, err := b.Stat(pipe)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), stat.Queue)
+}
+
+func TestBroker_Requeue(t *testing.T) {
+	b := &Broker{}
+	_, err := b.Init(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b.Register(pipe)
+
+	ready := make(chan interface{})
+	b.Listen(func(event int, ctx interface{}) {
+		if event == jobs.EventBrokerReady {
+			close(ready)
+		}
+	})
+
+	errHandled := make(chan interface{}, 2)
+	errHandler := func(id string, j *jobs.Job, err error) {
+		errHandled <- nil
+	}
+
+	exec := make(chan jobs.Handler, 1)
+	exec <- func(id string, j *jobs.Job) error {
+		return fmt.Errorf("job failed")
+	}
+
+	assert.NoError(t, b.Consume(pipe, exec, errHandler))
+
+	go func() { assert.NoError(t, b.Serve()) }()
+	defer b.Stop()
+
+	<-ready
+
+	for _, name := range []string{"test.failed", "other"} {
+		_, err := b.Push(pipe, &jobs.Job{Job: name, Payload: "body", Options: &jobs.Options{}})
+		assert.NoError(t, err)
+	}
+
+	<-errHandled
+	<-errHandled
+
+	assert.NoError(t, b.Consume(pipe, nil, nil))
+	time.Sleep(100 * time.Millisecond)
+
+	n, err := b.Requeue(pipe, jobs.RequeueOptions{Jobs: []string{"test"}})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, n)
+
+	list, err := b.List(pipe, 0, 10)
+	assert.NoError(t, err)
+	assert.Len(t, list, 2)
+	assert.Equal(t, jobs.JobReady, list[0].State)
+	assert.Equal(t, "test.failed", list[0].Job.Job)
+	assert.Equal(t, 0, list[0].Attempt)
+	assert.Equal(t, jobs.JobBuried, list[1].State)
+
+	n, err = b.Purge(pipe, jobs.PurgeOptions{Failed: true})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, n)
+}
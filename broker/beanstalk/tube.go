@@ -57,8 +57,17 @@ func newTube(pipe *jobs.Pipeline, lsn func(event int, ctx interface{})) (*tube,
 	}, nil
 }
 
-// run consumers
-func (t *tube) serve(connector connFactory) {
+// start consuming in background, tube is marked as consumed before the method returns so it can be stopped
+// right away.
+func (t *tube) start(connector connFactory) {
+	t.wait = make(chan interface{})
+	atomic.StoreInt32(&t.active, 1)
+
+	go t.serve(connector, t.wait)
+}
+
+// run consumers till given channel is closed
+func (t *tube) serve(connector connFactory, wait chan interface{}) {
 	// tube specific consume connection
 	cn, err := connector.newConn()
 	if err != nil {
@@ -67,11 +76,8 @@ func (t *tube) serve(connector connFactory) {
 	}
 	defer cn.Close()
 
-	t.wait = make(chan interface{})
-	atomic.StoreInt32(&t.active, 1)
-
 	for {
-		e, err := t.consume(cn)
+		e, err := t.consume(cn, wait)
 		if err != nil {
 			if isConnError(err) {
 				t.report(err)
@@ -94,12 +100,12 @@ func (t *tube) serve(connector connFactory) {
 }
 
 // fetch consume
-func (t *tube) consume(cn *conn) (*entry, error) {
+func (t *tube) consume(cn *conn, wait chan interface{}) (*entry, error) {
 	t.muw.Lock()
 	defer t.muw.Unlock()
 
 	select {
-	case <-t.wait:
+	case <-wait:
 		return nil, nil
 	default:
 		conn, err := cn.acquire(false)
@@ -128,6 +134,15 @@ func (t *tube) do(cn *conn, h jobs.Handler, e *entry) error {
 		return err
 	}
 
+	// attempt is unknown (-1) when job stats can not be fetched, such job is buried on failure
+	attempt, queued := t.delivery(cn, e)
+	j.Delivery = &jobs.Delivery{Pipeline: t.pipe.Name(), Queued: queued}
+	if attempt < 0 {
+		j.Delivery.AttemptUnknown = true
+	} else {
+		j.Delivery.Attempt = attempt
+	}
+
 	err = h(e.String(), j)
 
 	// mandatory acquisition
@@ -141,19 +156,52 @@ func (t *tube) do(cn *conn, h jobs.Handler, e *entry) error {
 		return cn.release(conn.Delete(e.id))
 	}
 
-	stat, statErr := conn.StatsJob(e.id)
-	if statErr != nil {
-		return cn.release(statErr)
+	if err == jobs.ErrRelease {
+		// reserve count is still increased by the next delivery
+		return cn.release(conn.Release(e.id, 0, 0))
 	}
 
 	t.errHandler(e.String(), j, err)
 
-	reserves, ok := strconv.Atoi(stat["reserves"])
-	if ok != nil || !j.Options.CanRetry(reserves-1) {
+	if attempt < 0 || !j.Options.CanRetry(attempt) {
 		return cn.release(conn.Bury(e.id, 0))
 	}
 
-	return cn.release(conn.Release(e.id, 0, j.Options.RetryDuration()))
+	if err := cn.release(conn.Release(e.id, 0, j.Options.RetryDuration())); err != nil {
+		return err
+	}
+
+	t.lsn(jobs.EventJobRelease, &jobs.JobEvent{ID: e.String(), Job: j, Pipeline: t.pipe.Name(), Attempt: attempt + 1})
+
+	return nil
+}
+
+// delivery fetches job attempt number and time when job was queued (only known for the first attempt).
+func (t *tube) delivery(cn *conn, e *entry) (attempt int, queued time.Time) {
+	conn, err := cn.acquire(true)
+	if err != nil {
+		return -1, queued
+	}
+
+	stat, err := conn.StatsJob(e.id)
+	if cn.release(err) != nil {
+		return -1, queued
+	}
+
+	reserves, err := strconv.Atoi(stat["reserves"])
+	if err != nil {
+		return -1, queued
+	}
+
+	if reserves == 1 {
+		age, ageErr := strconv.Atoi(stat["age"])
+		delay, delayErr := strconv.Atoi(stat["delay"])
+		if ageErr == nil && delayErr == nil {
+			queued = time.Now().Add(time.Duration(delay-age) * time.Second)
+		}
+	}
+
+	return reserves - 1, queued
 }
 
 // stop tube consuming
@@ -239,6 +287,12 @@ func (t *tube) doStat(cn *conn) (stat *jobs.Stat, err error) {
 		stat.Delayed = int64(v)
 	}
 
+	if stat.Queue != 0 {
+		if stat.OldestAge, err = t.oldest(conn); err != nil && isConnError(err) {
+			return nil, cn.release(err)
+		}
+	}
+
 	return stat, cn.release(nil)
 }
 
@@ -248,3 +302,265 @@ func (t *tube) report(err error) {
 		t.lsn(jobs.EventPipeError, &jobs.PipelineError{Pipeline: t.pipe, Caused: err})
 	}
 }
+
+// oldest returns age of the next ready job using acquired connection.
+func (t *tube) oldest(conn *beanstalk.Conn) (time.Duration, error) {
+	t.mut.Lock()
+	defer t.mut.Unlock()
+
+	t.tube.Conn = conn
+	id, _, err := t.tube.PeekReady()
+	if err != nil {
+		return 0, err
+	}
+
+	values, err := conn.StatsJob(id)
+	if err != nil {
+		return 0, err
+	}
+
+	age, err := strconv.Atoi(values["age"])
+	if err != nil {
+		return 0, nil
+	}
+
+	return time.Duration(age) * time.Second, nil
+}
+
+// list returns next ready, delayed and buried jobs of the tube. Beanstalk does not allow to walk
+// the tube, only the first job of every state can be inspected.
+func (t *tube) list(cn *conn) ([]*jobs.JobInfo, error) {
+	conn, err := cn.acquire(false)
+	if err != nil {
+		return nil, err
+	}
+
+	t.mut.Lock()
+	defer t.mut.Unlock()
+
+	t.tube.Conn = conn
+
+	var list []*jobs.JobInfo
+	for _, peek := range []func() (uint64, []byte, error){t.tube.PeekReady, t.tube.PeekDelayed, t.tube.PeekBuried} {
+		id, data, err := peek()
+		if err != nil {
+			if isConnError(err) {
+				return nil, cn.release(err)
+			}
+
+			// no jobs in given state
+			continue
+		}
+
+		info, err := t.info(conn, id, data)
+		if err != nil {
+			if isConnError(err) {
+				return nil, cn.release(err)
+			}
+
+			continue
+		}
+
+		list = append(list, info)
+	}
+
+	return list, cn.release(nil)
+}
+
+// peek job by it's id.
+func (t *tube) peek(cn *conn, id uint64) (*jobs.JobInfo, error) {
+	conn, err := cn.acquire(false)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := conn.Peek(id)
+	if err != nil {
+		return nil, cn.release(err)
+	}
+
+	info, err := t.info(conn, id, data)
+	if err != nil {
+		return nil, cn.release(err)
+	}
+
+	return info, cn.release(nil)
+}
+
+// info describes job using it's stats.
+func (t *tube) info(conn *beanstalk.Conn, id uint64, data []byte) (*jobs.JobInfo, error) {
+	j, err := unpack(data)
+	if err != nil {
+		return nil, err
+	}
+
+	stat, err := conn.StatsJob(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if stat["tube"] != t.tube.Name {
+		return nil, fmt.Errorf("undefined job `%v`", id)
+	}
+
+	info := &jobs.JobInfo{ID: strconv.FormatUint(id, 10), Job: j, State: stat["state"]}
+	if reserves, err := strconv.Atoi(stat["reserves"]); err == nil {
+		info.Attempt = reserves
+		if stat["state"] == jobs.JobReserved && reserves > 0 {
+			info.Attempt = reserves - 1
+		}
+	}
+
+	if stat["state"] == jobs.JobDelayed {
+		if left, err := strconv.Atoi(stat["time-left"]); err == nil {
+			info.Available = time.Now().Add(time.Duration(left) * time.Second)
+		}
+	}
+
+	return info, nil
+}
+
+// remove job of the tube by it's id.
+func (t *tube) remove(cn *conn, id uint64) error {
+	conn, err := cn.acquire(false)
+	if err != nil {
+		return err
+	}
+
+	stat, err := conn.StatsJob(id)
+	if err != nil {
+		return cn.release(err)
+	}
+
+	if stat["tube"] != t.tube.Name {
+		return cn.release(fmt.Errorf("undefined job `%v`", id))
+	}
+
+	return cn.release(conn.Delete(id))
+}
+
+// purge deletes ready jobs of the tube and, when requested, delayed and buried jobs.
+func (t *tube) purge(cn *conn, delayed, buried bool) (n int, err error) {
+	conn, err := cn.acquire(false)
+	if err != nil {
+		return 0, err
+	}
+
+	t.mut.Lock()
+	defer t.mut.Unlock()
+
+	t.tube.Conn = conn
+
+	peeks := []func() (uint64, []byte, error){t.tube.PeekReady}
+	if delayed {
+		peeks = append(peeks, t.tube.PeekDelayed)
+	}
+
+	if buried {
+		peeks = append(peeks, t.tube.PeekBuried)
+	}
+
+	for _, peek := range peeks {
+		var failed uint64
+		for {
+			id, _, err := peek()
+			if err != nil {
+				if isConnError(err) {
+					return n, cn.release(err)
+				}
+
+				// no more jobs in given state
+				break
+			}
+
+			if err := conn.Delete(id); err != nil {
+				if isConnError(err) {
+					return n, cn.release(err)
+				}
+
+				if id == failed {
+					// peek keeps returning the job which can not be deleted
+					cn.release(nil)
+					return n, fmt.Errorf("unable to delete job `%v`: %s", id, err)
+				}
+
+				// job has been reserved or deleted by another client, next peek returns another job
+				failed = id
+				continue
+			}
+
+			n++
+		}
+	}
+
+	return n, cn.release(nil)
+}
+
+// requeue puts buried jobs back to the tube as new jobs (to reset the attempt counter) and deletes the
+// original ones. Beanstalk only allows to peek the first buried job, requeue stops at the first buried job
+// which does not match given job names.
+func (t *tube) requeue(cn *conn, opts jobs.RequeueOptions) (n int, err error) {
+	conn, err := cn.acquire(false)
+	if err != nil {
+		return 0, err
+	}
+
+	t.mut.Lock()
+	defer t.mut.Unlock()
+
+	t.tube.Conn = conn
+
+	for !opts.Exceeded(n) {
+		id, data, err := t.tube.PeekBuried()
+		if err != nil {
+			if isConnError(err) {
+				return n, cn.release(err)
+			}
+
+			// no more buried jobs
+			break
+		}
+
+		j, err := unpack(data)
+		if err != nil || !opts.Match(j.Job) {
+			break
+		}
+
+		stat, err := conn.StatsJob(id)
+		if err != nil {
+			if isConnError(err) {
+				return n, cn.release(err)
+			}
+
+			// job has been kicked or deleted by another client
+			continue
+		}
+
+		pri, err := strconv.ParseUint(stat["pri"], 10, 32)
+		if err != nil {
+			return n, cn.release(err)
+		}
+
+		copyID, err := t.tube.Put(data, uint32(pri), 0, j.Options.TimeoutDuration())
+		if err != nil {
+			return n, cn.release(err)
+		}
+
+		if err := conn.Delete(id); err != nil {
+			if isConnError(err) {
+				return n, cn.release(err)
+			}
+
+			// original job has been kicked or deleted by another client, remove the copy to avoid duplicates
+			if err := conn.Delete(copyID); err != nil {
+				return n, cn.release(err)
+			}
+
+			continue
+		}
+
+		n++
+	}
+
+	return n, cn.release(nil)
+}
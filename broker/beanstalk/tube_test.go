@@ -13,6 +13,6 @@ func TestTube_CantServe(t *testing.T) {
 		},
 	}
 
-	tube.serve(&Config{Addr: "broken"})
+	tube.serve(&Config{Addr: "broken"}, make(chan interface{}))
 	assert.Error(t, gctx.(error))
 }
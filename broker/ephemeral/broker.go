@@ -7,13 +7,20 @@ import (
 	"sync"
 )
 
-// Broker run queue using local goroutines.
+// Broker run queues in memory. Number of pending jobs can be limited by `maxSize` pipeline option, `overflow`
+// option defines what happens to pushes into the full queue: `block` (default), `reject` or `drop-oldest`. Blocked
+// pushes fail when the queue is stopped or after `pushTimeout` seconds (no timeout by default).
+// Pending and delayed jobs can be saved into the file, set by `snapshot` pipeline option, on broker stop and
+// every `snapshotInterval` seconds (10 by default), to be restored on start. Delayed jobs of all pipelines are
+// released by single scheduler shared by the broker. Last `maxFailed` (100 by default) jobs which exhausted all
+// of their attempts are retained in memory to be inspected, retried or deleted.
 type Broker struct {
 	lsn     func(event int, ctx interface{})
 	mu      sync.Mutex
 	wait    chan error
 	stopped chan interface{}
 	queues  map[*jobs.Pipeline]*queue
+	sched   *scheduler
 }
 
 // Listen attaches server event watcher.
@@ -24,6 +31,7 @@ func (b *Broker) Listen(lsn func(event int, ctx interface{})) {
 // Init configures broker.
 func (b *Broker) Init() (bool, error) {
 	b.queues = make(map[*jobs.Pipeline]*queue)
+	b.sched = newScheduler()
 
 	return true, nil
 }
@@ -37,7 +45,18 @@ func (b *Broker) Register(pipe *jobs.Pipeline) error {
 		return fmt.Errorf("queue `%s` has already been registered", pipe.Name())
 	}
 
-	b.queues[pipe] = newQueue(pipe.Integer("maxThreads", 0))
+	q, err := newQueue(pipe, b.sched, b.throw)
+	if err != nil {
+		return err
+	}
+
+	if q.snapshotPath != "" {
+		if err := q.restore(); err != nil {
+			return fmt.Errorf("unable to restore snapshot of queue `%s`: %s", pipe.Name(), err)
+		}
+	}
+
+	b.queues[pipe] = q
 
 	return nil
 }
@@ -49,13 +68,19 @@ func (b *Broker) Serve() error {
 	for _, q := range b.queues {
 		qq := q
 		if qq.execPool != nil {
-			go qq.serve()
+			qq.start()
 		}
 	}
 	b.wait = make(chan error)
 	b.stopped = make(chan interface{})
 	defer close(b.stopped)
 
+	for _, q := range b.queues {
+		if q.snapshotPath != "" && q.snapshotInterval != 0 {
+			go q.snapshots(q.snapshotInterval, b.stopped)
+		}
+	}
+
 	b.mu.Unlock()
 
 	b.throw(jobs.EventBrokerReady, b)
@@ -75,6 +100,11 @@ func (b *Broker) Stop() {
 	// stop all consuming
 	for _, q := range b.queues {
 		q.stop()
+		q.interrupt()
+
+		if q.snapshotPath != "" {
+			q.report(q.snapshot())
+		}
 	}
 
 	close(b.wait)
@@ -99,7 +129,7 @@ func (b *Broker) Consume(pipe *jobs.Pipeline, execPool chan jobs.Handler, errHan
 
 	if b.wait != nil {
 		if q.execPool != nil {
-			go q.serve()
+			q.start()
 		}
 	}
 
@@ -122,7 +152,9 @@ func (b *Broker) Push(pipe *jobs.Pipeline, j *jobs.Job) (string, error) {
 		return "", err
 	}
 
-	q.push(id.String(), j, 0, j.Options.DelayDuration())
+	if err := q.push(id.String(), j, j.Options.DelayDuration()); err != nil {
+		return "", err
+	}
 
 	return id.String(), nil
 }
@@ -141,6 +173,145 @@ func (b *Broker) Stat(pipe *jobs.Pipeline) (stat *jobs.Stat, err error) {
 	return q.stat(), nil
 }
 
+// Health returns error if broker is not serving.
+func (b *Broker) Health() error {
+	return b.isServing()
+}
+
+// List returns pending jobs of the pipeline in order of their availability.
+func (b *Broker) List(pipe *jobs.Pipeline, offset, limit int) ([]*jobs.JobInfo, error) {
+	if err := b.isServing(); err != nil {
+		return nil, err
+	}
+
+	q := b.queue(pipe)
+	if q == nil {
+		return nil, fmt.Errorf("undefined queue `%s`", pipe.Name())
+	}
+
+	return q.list(offset, limit), nil
+}
+
+// Peek returns pending job by it's id.
+func (b *Broker) Peek(pipe *jobs.Pipeline, id string) (*jobs.JobInfo, error) {
+	if err := b.isServing(); err != nil {
+		return nil, err
+	}
+
+	q := b.queue(pipe)
+	if q == nil {
+		return nil, fmt.Errorf("undefined queue `%s`", pipe.Name())
+	}
+
+	info := q.peek(id)
+	if info == nil {
+		return nil, fmt.Errorf("undefined job `%s`", id)
+	}
+
+	return info, nil
+}
+
+// Remove deletes pending job by it's id.
+func (b *Broker) Remove(pipe *jobs.Pipeline, id string) error {
+	if err := b.isServing(); err != nil {
+		return err
+	}
+
+	q := b.queue(pipe)
+	if q == nil {
+		return fmt.Errorf("undefined queue `%s`", pipe.Name())
+	}
+
+	if !q.removeID(id) {
+		return fmt.Errorf("undefined job `%s`", id)
+	}
+
+	return nil
+}
+
+// Purge removes pending jobs from the pipeline, failed jobs are the retained ones.
+func (b *Broker) Purge(pipe *jobs.Pipeline, opts jobs.PurgeOptions) (int, error) {
+	if err := b.isServing(); err != nil {
+		return 0, err
+	}
+
+	q := b.queue(pipe)
+	if q == nil {
+		return 0, fmt.Errorf("undefined queue `%s`", pipe.Name())
+	}
+
+	n := q.purge(opts.Delayed)
+	if opts.Failed {
+		n += q.purgeFailed()
+	}
+
+	return n, nil
+}
+
+// Requeue pushes retained failed jobs back to the pipeline, requeued jobs start from the first attempt.
+func (b *Broker) Requeue(pipe *jobs.Pipeline, opts jobs.RequeueOptions) (int, error) {
+	if err := b.isServing(); err != nil {
+		return 0, err
+	}
+
+	q := b.queue(pipe)
+	if q == nil {
+		return 0, fmt.Errorf("undefined queue `%s`", pipe.Name())
+	}
+
+	return q.requeue(opts), nil
+}
+
+// ListFailed returns retained failed jobs of the pipeline in order of their failure.
+func (b *Broker) ListFailed(pipe *jobs.Pipeline, offset, limit int) ([]*jobs.FailedJob, error) {
+	if err := b.isServing(); err != nil {
+		return nil, err
+	}
+
+	q := b.queue(pipe)
+	if q == nil {
+		return nil, fmt.Errorf("undefined queue `%s`", pipe.Name())
+	}
+
+	return q.listFailed(offset, limit), nil
+}
+
+// RetryFailed pushes retained failed job back to the pipeline starting from the first attempt.
+func (b *Broker) RetryFailed(pipe *jobs.Pipeline, id string) error {
+	if err := b.isServing(); err != nil {
+		return err
+	}
+
+	q := b.queue(pipe)
+	if q == nil {
+		return fmt.Errorf("undefined queue `%s`", pipe.Name())
+	}
+
+	if !q.retryFailed(id) {
+		return fmt.Errorf("undefined failed job `%s`", id)
+	}
+
+	return nil
+}
+
+// DeleteFailed forgets retained failed job.
+func (b *Broker) DeleteFailed(pipe *jobs.Pipeline, id string) error {
+	if err := b.isServing(); err != nil {
+		return err
+	}
+
+	q := b.queue(pipe)
+	if q == nil {
+		return fmt.Errorf("undefined queue `%s`", pipe.Name())
+	}
+
+	if !q.deleteFailed(id) {
+		return fmt.Errorf("undefined failed job `%s`", id)
+	}
+
+	return nil
+}
+
 // check if broker is serving
 func (b *Broker) isServing() error {
 	b.mu.Lock()
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"github.com/spiral/jobs/v2"
 	"github.com/stretchr/testify/assert"
+	"sync/atomic"
 	"testing"
 	"time"
 )
@@ -251,3 +252,111 @@ func TestBroker_Consume_Errored_Attempts(t *testing.T) {
 	<-errHandled
 	assert.Equal(t, 3, attempts)
 }
+
+func TestBroker_Consume_Delivery_Release(t *testing.T) {
+	b := &Broker{}
+	_, err := b.Init()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = b.Register(pipe)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ready := make(chan interface{})
+	released := make(chan *jobs.JobEvent, 1)
+	b.Listen(func(event int, ctx interface{}) {
+		switch event {
+		case jobs.EventBrokerReady:
+			close(ready)
+		case jobs.EventJobRelease:
+			released <- ctx.(*jobs.JobEvent)
+		}
+	})
+
+	exec := make(chan jobs.Handler, 1)
+	assert.NoError(t, b.Consume(pipe, exec, func(id string, j *jobs.Job, err error) {}))
+
+	go func() { assert.NoError(t, b.Serve()) }()
+	defer b.Stop()
+
+	<-ready
+
+	jid, perr := b.Push(pipe, &jobs.Job{
+		Job:     "test",
+		Payload: "body",
+		Options: &jobs.Options{Attempts: 2},
+	})
+
+	assert.NotEqual(t, "", jid)
+	assert.NoError(t, perr)
+
+	attempts := make(chan *jobs.Delivery, 2)
+	exec <- func(id string, j *jobs.Job) error {
+		attempts <- j.Delivery
+		return fmt.Errorf("job failed")
+	}
+
+	d := <-attempts
+	assert.Equal(t, "default", d.Pipeline)
+	assert.Equal(t, 0, d.Attempt)
+	assert.False(t, d.Queued.IsZero())
+
+	e := <-released
+	assert.Equal(t, jid, e.ID)
+	assert.Equal(t, "default", e.Pipeline)
+	assert.Equal(t, 1, e.Attempt)
+
+	d = <-attempts
+	assert.Equal(t, 1, d.Attempt)
+}
+
+func TestBroker_Consume_Released(t *testing.T) {
+	b := &Broker{}
+	_, err := b.Init()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = b.Register(pipe)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ready := make(chan interface{})
+	b.Listen(func(event int, ctx interface{}) {
+		if event == jobs.EventBrokerReady {
+			close(ready)
+		}
+	})
+
+	errors := make(chan error, 1)
+	exec := make(chan jobs.Handler, 1)
+	assert.NoError(t, b.Consume(pipe, exec, func(id string, j *jobs.Job, err error) { errors <- err }))
+
+	go func() { assert.NoError(t, b.Serve()) }()
+	defer b.Stop()
+
+	<-ready
+
+	_, perr := b.Push(pipe, &jobs.Job{
+		Job:     "test",
+		Payload: "body",
+		Options: &jobs.Options{Attempts: 1},
+	})
+	assert.NoError(t, perr)
+
+	var delivered int32
+	attempts := make(chan *jobs.Delivery, 2)
+	exec <- func(id string, j *jobs.Job) error {
+		attempts <- j.Delivery
+		if atomic.AddInt32(&delivered, 1) == 1 {
+			return jobs.ErrRelease
+		}
+
+		return nil
+	}
+
+	// released job keeps it's only attempt
+	assert.Equal(t, 0, (<-attempts).Attempt)
+	assert.Equal(t, 0, (<-attempts).Attempt)
+	assert.Len(t, errors, 0)
+}
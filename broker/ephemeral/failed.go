@@ -0,0 +1,139 @@
+package ephemeral
+
+import (
+	"container/list"
+	"github.com/spiral/jobs/v2"
+	"time"
+)
+
+// failure is entry which has exhausted all of it's attempts.
+type failure struct {
+	entry *entry
+	err   string
+	at    time.Time
+}
+
+// info describes failed entry.
+func (f *failure) info() *jobs.FailedJob {
+	return &jobs.FailedJob{
+		ID:       f.entry.id,
+		Job:      f.entry.job,
+		Attempts: f.entry.attempt + 1,
+		Error:    f.err,
+		Failed:   f.at,
+	}
+}
+
+// bury retains failed entry, the oldest failed entry is forgotten once limit is reached.
+func (q *queue) bury(e *entry, err error) {
+	if q.maxFailed <= 0 {
+		return
+	}
+
+	q.mup.Lock()
+	defer q.mup.Unlock()
+
+	q.failed.PushBack(&failure{entry: e, err: err.Error(), at: time.Now()})
+	if q.failed.Len() > q.maxFailed {
+		q.failed.Remove(q.failed.Front())
+	}
+}
+
+// listFailed returns failed entries in order of their failure.
+func (q *queue) listFailed(offset, limit int) []*jobs.FailedJob {
+	q.mup.Lock()
+	defer q.mup.Unlock()
+
+	list := make([]*jobs.FailedJob, 0)
+	for el, i := q.failed.Front(), 0; el != nil; el, i = el.Next(), i+1 {
+		if i < offset {
+			continue
+		}
+
+		if limit > 0 && len(list) >= limit {
+			break
+		}
+
+		list = append(list, el.Value.(*failure).info())
+	}
+
+	return list
+}
+
+// retryFailed pushes failed entry back to the queue starting from the first attempt.
+func (q *queue) retryFailed(id string) bool {
+	q.mup.Lock()
+	defer q.mup.Unlock()
+
+	el := q.findFailed(id)
+	if el == nil {
+		return false
+	}
+
+	q.retry(el)
+
+	return true
+}
+
+// deleteFailed forgets failed entry.
+func (q *queue) deleteFailed(id string) bool {
+	q.mup.Lock()
+	defer q.mup.Unlock()
+
+	el := q.findFailed(id)
+	if el == nil {
+		return false
+	}
+
+	q.failed.Remove(el)
+
+	return true
+}
+
+// requeue pushes matching failed entries back to the queue.
+func (q *queue) requeue(opts jobs.RequeueOptions) int {
+	q.mup.Lock()
+	defer q.mup.Unlock()
+
+	n := 0
+	for el := q.failed.Front(); el != nil && !opts.Exceeded(n); {
+		next := el.Next()
+
+		if opts.Match(el.Value.(*failure).entry.job.Job) {
+			q.retry(el)
+			n++
+		}
+
+		el = next
+	}
+
+	return n
+}
+
+// purgeFailed forgets all failed entries.
+func (q *queue) purgeFailed() int {
+	q.mup.Lock()
+	defer q.mup.Unlock()
+
+	n := q.failed.Len()
+	q.failed.Init()
+
+	return n
+}
+
+// retry removes failed entry and pushes it back to the queue, must be called under mup lock.
+func (q *queue) retry(el *list.Element) {
+	e := q.failed.Remove(el).(*failure).entry
+	q.add(e.id, e.job, 0, 0)
+}
+
+// findFailed returns failed entry by it's id, must be called under mup lock.
+func (q *queue) findFailed(id string) *list.Element {
+	for el := q.failed.Front(); el != nil; el = el.Next() {
+		if el.Value.(*failure).entry.id == id {
+			return el
+		}
+	}
+
+	return nil
+}
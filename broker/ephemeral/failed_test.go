@@ -0,0 +1,159 @@
+package ephemeral
+
+import (
+	"fmt"
+	"github.com/spiral/jobs/v2"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+// serveFailing starts broker consuming pipeline with handler failing the first executions.
+func serveFailing(t *testing.T, p *jobs.Pipeline, failures int) (*Broker, chan string) {
+	b := &Broker{}
+	_, err := b.Init()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = b.Register(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ready := make(chan interface{})
+	b.Listen(func(event int, ctx interface{}) {
+		if event == jobs.EventBrokerReady {
+			close(ready)
+		}
+	})
+
+	done := make(chan string, 10)
+	exec := make(chan jobs.Handler, 1)
+	exec <- func(id string, j *jobs.Job) error {
+		if failures > 0 {
+			failures--
+			done <- ""
+			return fmt.Errorf("job failed")
+		}
+
+		done <- id
+		return nil
+	}
+
+	assert.NoError(t, b.Consume(p, exec, func(id string, j *jobs.Job, err error) {}))
+
+	go func() { assert.NoError(t, b.Serve()) }()
+	<-ready
+
+	return b, done
+}
+
+func TestBroker_Failed_Retry(t *testing.T) {
+	b, done := serveFailing(t, pipe, 2)
+	defer b.Stop()
+
+	jid, err := b.Push(pipe, &jobs.Job{Job: "test", Payload: "body", Options: &jobs.Options{Attempts: 2}})
+	assert.NoError(t, err)
+
+	<-done
+	<-done
+	time.Sleep(10 * time.Millisecond)
+
+	stat, err := b.Stat(pipe)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), stat.Queue)
+	assert.Equal(t, int64(1), stat.Failed)
+
+	list, err := b.ListFailed(pipe, 0, 0)
+	assert.NoError(t, err)
+	assert.Len(t, list, 1)
+	assert.Equal(t, jid, list[0].ID)
+	assert.Equal(t, "body", list[0].Job.Payload)
+	assert.Equal(t, 2, list[0].Attempts)
+	assert.Equal(t, "job failed", list[0].Error)
+
+	assert.Error(t, b.RetryFailed(pipe, "missing"))
+	assert.NoError(t, b.RetryFailed(pipe, jid))
+	assert.Equal(t, jid, <-done)
+
+	stat, err = b.Stat(pipe)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), stat.Failed)
+}
+
+func TestBroker_Failed_Delete(t *testing.T) {
+	b, done := serveFailing(t, pipe, 3)
+	defer b.Stop()
+
+	var ids []string
+	for i := 0; i < 3; i++ {
+		jid, err := b.Push(pipe, &jobs.Job{Job: "test", Payload: "body", Options: &jobs.Options{}})
+		assert.NoError(t, err)
+		ids = append(ids, jid)
+
+		<-done
+	}
+	time.Sleep(10 * time.Millisecond)
+
+	list, err := b.ListFailed(pipe, 1, 1)
+	assert.NoError(t, err)
+	assert.Len(t, list, 1)
+	assert.Equal(t, ids[1], list[0].ID)
+
+	assert.NoError(t, b.DeleteFailed(pipe, ids[1]))
+	assert.Error(t, b.DeleteFailed(pipe, ids[1]))
+
+	n, err := b.Purge(pipe, jobs.PurgeOptions{Failed: true})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, n)
+
+	list, err = b.ListFailed(pipe, 0, 0)
+	assert.NoError(t, err)
+	assert.Len(t, list, 0)
+}
+
+func TestBroker_Failed_Requeue(t *testing.T) {
+	b, done := serveFailing(t, pipe, 2)
+	defer b.Stop()
+
+	for _, job := range []string{"spiral.jobs.local", "spiral.jobs.other"} {
+		_, err := b.Push(pipe, &jobs.Job{Job: job, Payload: "body", Options: &jobs.Options{}})
+		assert.NoError(t, err)
+
+		<-done
+	}
+	time.Sleep(10 * time.Millisecond)
+
+	n, err := b.Requeue(pipe, jobs.RequeueOptions{Jobs: []string{"spiral.jobs.other"}})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, n)
+	assert.NotEqual(t, "", <-done)
+
+	stat, err := b.Stat(pipe)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), stat.Failed)
+}
+
+func TestBroker_Failed_Limit(t *testing.T) {
+	p := &jobs.Pipeline{"broker": "ephemeral", "name": "default", "maxFailed": 2}
+
+	b, done := serveFailing(t, p, 3)
+	defer b.Stop()
+
+	var ids []string
+	for i := 0; i < 3; i++ {
+		jid, err := b.Push(p, &jobs.Job{Job: "test", Payload: "body", Options: &jobs.Options{}})
+		assert.NoError(t, err)
+		ids = append(ids, jid)
+
+		<-done
+	}
+	time.Sleep(10 * time.Millisecond)
+
+	list, err := b.ListFailed(p, 0, 0)
+	assert.NoError(t, err)
+	assert.Len(t, list, 2)
+	assert.Equal(t, ids[1], list[0].ID)
+	assert.Equal(t, ids[2], list[1].ID)
+}
@@ -0,0 +1,117 @@
+package ephemeral
+
+import (
+	"github.com/spiral/jobs/v2"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestBroker_List(t *testing.T) {
+	b := &Broker{}
+	_, err := b.Init()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = b.Register(pipe)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ready := make(chan interface{})
+	b.Listen(func(event int, ctx interface{}) {
+		if event == jobs.EventBrokerReady {
+			close(ready)
+		}
+	})
+
+	go func() { assert.NoError(t, b.Serve()) }()
+	defer b.Stop()
+
+	<-ready
+
+	delayed, err := b.Push(pipe, &jobs.Job{Job: "test", Payload: "delayed", Options: &jobs.Options{Delay: 10}})
+	assert.NoError(t, err)
+
+	var ids []string
+	for _, payload := range []string{"a", "b", "c"} {
+		id, err := b.Push(pipe, &jobs.Job{Job: "test", Payload: payload, Options: &jobs.Options{}})
+		assert.NoError(t, err)
+		ids = append(ids, id)
+
+		time.Sleep(time.Millisecond)
+	}
+
+	list, err := b.List(pipe, 0, 0)
+	assert.NoError(t, err)
+	assert.Len(t, list, 4)
+	assert.Equal(t, ids[0], list[0].ID)
+	assert.Equal(t, jobs.JobReady, list[0].State)
+	assert.Equal(t, "a", list[0].Job.Payload)
+	assert.Equal(t, delayed, list[3].ID)
+	assert.Equal(t, jobs.JobDelayed, list[3].State)
+	assert.True(t, list[3].Available.After(time.Now().Add(9*time.Second)))
+	assert.True(t, list[0].Available.IsZero())
+
+	list, err = b.List(pipe, 1, 2)
+	assert.NoError(t, err)
+	assert.Len(t, list, 2)
+	assert.Equal(t, ids[1], list[0].ID)
+	assert.Equal(t, ids[2], list[1].ID)
+
+	list, err = b.List(pipe, 10, 2)
+	assert.NoError(t, err)
+	assert.Len(t, list, 0)
+
+	info, err := b.Peek(pipe, ids[1])
+	assert.NoError(t, err)
+	assert.Equal(t, "b", info.Job.Payload)
+
+	_, err = b.Peek(pipe, "missing")
+	assert.Error(t, err)
+
+	// consumed jobs are no longer pending
+	exec := make(chan jobs.Handler, 1)
+	done := make(chan interface{}, 3)
+	exec <- func(id string, j *jobs.Job) error {
+		done <- nil
+		return nil
+	}
+
+	assert.NoError(t, b.Consume(pipe, exec, func(id string, j *jobs.Job, err error) {}))
+	<-done
+	<-done
+	<-done
+
+	list, err = b.List(pipe, 0, 0)
+	assert.NoError(t, err)
+	assert.Len(t, list, 1)
+	assert.Equal(t, delayed, list[0].ID)
+
+	assert.NoError(t, b.Remove(pipe, delayed))
+	assert.Error(t, b.Remove(pipe, delayed))
+
+	stat, err := b.Stat(pipe)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), stat.Delayed)
+}
+
+func TestBroker_ListNotRunning(t *testing.T) {
+	b := &Broker{}
+	_, err := b.Init()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = b.Register(pipe)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = b.List(pipe, 0, 10)
+	assert.Error(t, err)
+
+	_, err = b.Peek(pipe, "id")
+	assert.Error(t, err)
+
+	assert.Error(t, b.Remove(pipe, "id"))
+}
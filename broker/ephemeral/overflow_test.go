@@ -0,0 +1,164 @@
+package ephemeral
+
+import (
+	"github.com/spiral/jobs/v2"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+// serveBounded starts broker with bounded pipeline.
+func serveBounded(t *testing.T, overflow string) (*Broker, *jobs.Pipeline) {
+	p := &jobs.Pipeline{"broker": "ephemeral", "name": "default", "maxSize": 2, "overflow": overflow}
+
+	b := &Broker{}
+	_, err := b.Init()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = b.Register(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ready := make(chan interface{})
+	b.Listen(func(event int, ctx interface{}) {
+		if event == jobs.EventBrokerReady {
+			close(ready)
+		}
+	})
+
+	go func() { assert.NoError(t, b.Serve()) }()
+	<-ready
+
+	return b, p
+}
+
+func TestBroker_Overflow_Reject(t *testing.T) {
+	b, p := serveBounded(t, OverflowReject)
+	defer b.Stop()
+
+	for i := 0; i < 2; i++ {
+		_, err := b.Push(p, &jobs.Job{Job: "test", Payload: "body", Options: &jobs.Options{}})
+		assert.NoError(t, err)
+	}
+
+	_, err := b.Push(p, &jobs.Job{Job: "test", Payload: "body", Options: &jobs.Options{}})
+	assert.Error(t, err)
+
+	stat, err := b.Stat(p)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), stat.Queue)
+	assert.Equal(t, int64(2), stat.Capacity)
+	assert.Equal(t, int64(0), stat.Dropped)
+}
+
+func TestBroker_Overflow_DropOldest(t *testing.T) {
+	b, p := serveBounded(t, OverflowDropOldest)
+	defer b.Stop()
+
+	var ids []string
+	for _, payload := range []string{"first", "second", "third"} {
+		id, err := b.Push(p, &jobs.Job{Job: "test", Payload: payload, Options: &jobs.Options{}})
+		assert.NoError(t, err)
+		ids = append(ids, id)
+	}
+
+	stat, err := b.Stat(p)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), stat.Queue)
+	assert.Equal(t, int64(1), stat.Dropped)
+
+	list, err := b.List(p, 0, 0)
+	assert.NoError(t, err)
+	assert.Len(t, list, 2)
+	assert.Equal(t, ids[1], list[0].ID)
+	assert.Equal(t, ids[2], list[1].ID)
+}
+
+func TestBroker_Overflow_Block(t *testing.T) {
+	b, p := serveBounded(t, OverflowBlock)
+	defer b.Stop()
+
+	for i := 0; i < 2; i++ {
+		_, err := b.Push(p, &jobs.Job{Job: "test", Payload: "body", Options: &jobs.Options{}})
+		assert.NoError(t, err)
+	}
+
+	pushed := make(chan interface{})
+	go func() {
+		_, err := b.Push(p, &jobs.Job{Job: "test", Payload: "body", Options: &jobs.Options{}})
+		assert.NoError(t, err)
+		close(pushed)
+	}()
+
+	select {
+	case <-pushed:
+		t.Fatal("push into full queue has not been blocked")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	exec := make(chan jobs.Handler, 1)
+	exec <- func(id string, j *jobs.Job) error { return nil }
+	assert.NoError(t, b.Consume(p, exec, func(id string, j *jobs.Job, err error) {}))
+
+	select {
+	case <-pushed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("push has not been released")
+	}
+}
+
+func TestBroker_Overflow_Block_Stop(t *testing.T) {
+	b, p := serveBounded(t, OverflowBlock)
+
+	for i := 0; i < 2; i++ {
+		_, err := b.Push(p, &jobs.Job{Job: "test", Payload: "body", Options: &jobs.Options{}})
+		assert.NoError(t, err)
+	}
+
+	pushed := make(chan error)
+	go func() {
+		_, err := b.Push(p, &jobs.Job{Job: "test", Payload: "body", Options: &jobs.Options{}})
+		pushed <- err
+	}()
+
+	time.Sleep(50 * time.Millisecond)
+	b.Stop()
+
+	select {
+	case err := <-pushed:
+		assert.Error(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("push has not been released on stop")
+	}
+}
+
+func TestBroker_Overflow_Block_Timeout(t *testing.T) {
+	b, p := serveBounded(t, OverflowBlock)
+	defer b.Stop()
+
+	b.queues[p].pushTimeout = 100 * time.Millisecond
+
+	for i := 0; i < 2; i++ {
+		_, err := b.Push(p, &jobs.Job{Job: "test", Payload: "body", Options: &jobs.Options{}})
+		assert.NoError(t, err)
+	}
+
+	start := time.Now()
+	_, err := b.Push(p, &jobs.Job{Job: "test", Payload: "body", Options: &jobs.Options{}})
+	assert.Error(t, err)
+	assert.True(t, time.Since(start) >= 100*time.Millisecond)
+
+	stat, err := b.Stat(p)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), stat.Queue)
+}
+
+func TestBroker_Overflow_Undefined(t *testing.T) {
+	b := &Broker{}
+	_, err := b.Init()
+	assert.NoError(t, err)
+	assert.Error(t, b.Register(&jobs.Pipeline{"broker": "ephemeral", "name": "default", "overflow": "unknown"}))
+}
@@ -0,0 +1,92 @@
+package ephemeral
+
+import (
+	"github.com/spiral/jobs/v2"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestBroker_Purge(t *testing.T) {
+	b := &Broker{}
+	_, err := b.Init()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = b.Register(pipe)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ready := make(chan interface{})
+	b.Listen(func(event int, ctx interface{}) {
+		if event == jobs.EventBrokerReady {
+			close(ready)
+		}
+	})
+
+	go func() { assert.NoError(t, b.Serve()) }()
+	defer b.Stop()
+
+	<-ready
+
+	for i := 0; i < 3; i++ {
+		_, err := b.Push(pipe, &jobs.Job{Job: "test", Payload: "body", Options: &jobs.Options{}})
+		assert.NoError(t, err)
+	}
+
+	delayed, err := b.Push(pipe, &jobs.Job{Job: "test", Payload: "delayed", Options: &jobs.Options{Delay: 10}})
+	assert.NoError(t, err)
+
+	n, err := b.Purge(pipe, jobs.PurgeOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, n)
+
+	// purged entries are released in background
+	time.Sleep(10 * time.Millisecond)
+
+	stat, err := b.Stat(pipe)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), stat.Queue)
+	assert.Equal(t, int64(1), stat.Delayed)
+
+	list, err := b.List(pipe, 0, 0)
+	assert.NoError(t, err)
+	assert.Len(t, list, 1)
+	assert.Equal(t, delayed, list[0].ID)
+
+	n, err = b.Purge(pipe, jobs.PurgeOptions{Delayed: true})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, n)
+
+	time.Sleep(10 * time.Millisecond)
+
+	stat, err = b.Stat(pipe)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), stat.Delayed)
+
+	// purged jobs are never consumed
+	exec := make(chan jobs.Handler, 1)
+	exec <- func(id string, j *jobs.Job) error {
+		t.Error("purged job has been consumed")
+		return nil
+	}
+
+	assert.NoError(t, b.Consume(pipe, exec, func(id string, j *jobs.Job, err error) {}))
+	time.Sleep(10 * time.Millisecond)
+}
+
+func TestBroker_PurgeNotRunning(t *testing.T) {
+	b := &Broker{}
+	_, err := b.Init()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = b.Register(pipe)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = b.Purge(pipe, jobs.PurgeOptions{})
+	assert.Error(t, err)
+}
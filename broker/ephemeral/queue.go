@@ -1,19 +1,57 @@
 package ephemeral
 
 import (
+	"container/list"
+	"fmt"
 	"github.com/spiral/jobs/v2"
+	"sort"
 	"sync"
 	"sync/atomic"
 	"time"
 )
 
+const (
+	// OverflowBlock makes push wait till queue has free space.
+	OverflowBlock = "block"
+
+	// OverflowReject makes push fail when queue is full.
+	OverflowReject = "reject"
+
+	// OverflowDropOldest removes the oldest pending job to free space for the pushed one.
+	OverflowDropOldest = "drop-oldest"
+)
+
 type queue struct {
 	on    int32
+	pipe  *jobs.Pipeline
 	state *jobs.Stat
+	sched *scheduler
 
-	// job pipeline
+	// concurrency limit
 	concurPool chan interface{}
-	jobs       chan *entry
+
+	// maximum number of pending entries, overflow policy and how long blocked push can wait for free space
+	maxSize     int
+	overflow    string
+	pushTimeout time.Duration
+
+	// entries waiting to be consumed and being executed, interrupts counts stops which fail blocked pushes
+	mup        sync.Mutex
+	space      *sync.Cond
+	interrupts int
+	pending    map[*entry]bool
+	ready      *list.List
+	active     map[*entry]bool
+	notify     chan interface{}
+
+	// entries which exhausted all of their attempts
+	maxFailed int
+	failed    *list.List
+
+	// snapshot file and interval
+	snapshotPath     string
+	snapshotInterval time.Duration
+	mus              sync.Mutex
 
 	// on operations
 	muw sync.Mutex
@@ -22,6 +60,9 @@ type queue struct {
 	// stop channel
 	wait chan interface{}
 
+	// queue events
+	lsn func(event int, ctx interface{})
+
 	// exec handlers
 	execPool   chan jobs.Handler
 	errHandler jobs.ErrorHandler
@@ -31,11 +72,57 @@ type entry struct {
 	id      string
 	job     *jobs.Job
 	attempt int
+	queued  time.Time
+
+	// position in ready list, nil while entry is delayed
+	elem *list.Element
+
+	// queue entry belongs to and it's position in scheduler heap, -1 when entry is not scheduled
+	queue *queue
+	index int
+}
+
+// info describes pending entry.
+func (e *entry) info(now time.Time) *jobs.JobInfo {
+	info := &jobs.JobInfo{ID: e.id, Job: e.job, State: jobs.JobReady, Attempt: e.attempt}
+	if e.queued.After(now) {
+		info.State, info.Available = jobs.JobDelayed, e.queued
+	}
+
+	return info
 }
 
 // create new queue
-func newQueue(maxConcur int) *queue {
-	q := &queue{state: &jobs.Stat{}, jobs: make(chan *entry)}
+func newQueue(pipe *jobs.Pipeline, sched *scheduler, lsn func(event int, ctx interface{})) (*queue, error) {
+	q := &queue{
+		pipe:        pipe,
+		state:       &jobs.Stat{},
+		sched:       sched,
+		maxSize:     pipe.Integer("maxSize", 0),
+		overflow:    pipe.String("overflow", OverflowBlock),
+		pushTimeout: pipe.Duration("pushTimeout", 0),
+		pending:     make(map[*entry]bool),
+		ready:       list.New(),
+		active:      make(map[*entry]bool),
+		notify:      make(chan interface{}, 1),
+
+		maxFailed: pipe.Integer("maxFailed", 100),
+		failed:    list.New(),
+		lsn:       lsn,
+
+		snapshotPath:     pipe.String("snapshot", ""),
+		snapshotInterval: pipe.Duration("snapshotInterval", 10*time.Second),
+	}
+
+	q.space = sync.NewCond(&q.mup)
+
+	switch q.overflow {
+	case OverflowBlock, OverflowReject, OverflowDropOldest:
+	default:
+		return nil, fmt.Errorf("undefined overflow policy `%s` on ephemeral pipeline `%s`", q.overflow, pipe.Name())
+	}
+
+	maxConcur := pipe.Integer("maxThreads", 0)
 
 	if maxConcur != 0 {
 		q.concurPool = make(chan interface{}, maxConcur)
@@ -44,16 +131,22 @@ func newQueue(maxConcur int) *queue {
 		}
 	}
 
-	return q
+	return q, nil
 }
 
-// serve consumers
-func (q *queue) serve() {
+// start consuming in background, queue is marked as consumed before the method returns so it can be stopped
+// right away.
+func (q *queue) start() {
 	q.wait = make(chan interface{})
 	atomic.StoreInt32(&q.on, 1)
 
+	go q.serve(q.wait)
+}
+
+// serve consumers till given channel is closed.
+func (q *queue) serve(wait chan interface{}) {
 	for {
-		e := q.consume()
+		e := q.consume(wait)
 		if e == nil {
 			q.wg.Wait()
 			return
@@ -82,40 +175,76 @@ func (q *queue) serve() {
 }
 
 // allocate one job entry
-func (q *queue) consume() *entry {
+func (q *queue) consume(wait chan interface{}) *entry {
 	q.muw.Lock()
 	defer q.muw.Unlock()
 
-	select {
-	case <-q.wait:
-		return nil
-	case e := <-q.jobs:
-		q.wg.Add(1)
+	for {
+		select {
+		case <-wait:
+			return nil
+		default:
+		}
+
+		q.mup.Lock()
+		if front := q.ready.Front(); front != nil {
+			e := q.ready.Remove(front).(*entry)
+			e.elem = nil
+
+			delete(q.pending, e)
+			q.active[e] = true
+			q.space.Signal()
+			q.mup.Unlock()
 
-		return e
+			q.wg.Add(1)
+
+			return e
+		}
+		q.mup.Unlock()
+
+		select {
+		case <-wait:
+			return nil
+		case <-q.notify:
+		}
 	}
 }
 
 // do singe job
 func (q *queue) do(h jobs.Handler, e *entry) {
+	e.job.Delivery = &jobs.Delivery{Pipeline: q.pipe.Name(), Attempt: e.attempt, Queued: e.queued}
 	err := h(e.id, e.job)
 
+	q.mup.Lock()
+	delete(q.active, e)
+	q.mup.Unlock()
+
 	if err == nil {
 		atomic.AddInt64(&q.state.Queue, ^int64(0))
 		return
 	}
 
+	if err == jobs.ErrRelease {
+		atomic.AddInt64(&q.state.Queue, ^int64(0))
+		q.enqueue(e.id, e.job, e.attempt, 0)
+		return
+	}
+
 	q.errHandler(e.id, e.job, err)
 
-	if !e.job.Options.CanRetry(e.attempt) {
+	retry, delay := jobs.NextRetry(e.job, e.attempt, err)
+	if !retry {
 		atomic.AddInt64(&q.state.Queue, ^int64(0))
+		q.bury(e, err)
 		return
 	}
 
-	q.push(e.id, e.job, e.attempt+1, e.job.Options.RetryDuration())
+	atomic.AddInt64(&q.state.Queue, ^int64(0))
+	q.enqueue(e.id, e.job, e.attempt+1, delay)
+	q.lsn(jobs.EventJobRelease, &jobs.JobEvent{ID: e.id, Job: e.job, Pipeline: q.pipe.Name(), Attempt: e.attempt + 1})
 }
 
-// stop the queue consuming
+// stop the queue consuming, pushes waiting for free space fail.
 func (q *queue) stop() {
 	if atomic.LoadInt32(&q.on) == 0 {
 		return
@@ -128,27 +257,247 @@ func (q *queue) stop() {
 	q.muw.Unlock()
 
 	atomic.StoreInt32(&q.on, 0)
+	q.interrupt()
 }
 
-// add job to the queue
-func (q *queue) push(id string, j *jobs.Job, attempt int, delay time.Duration) {
-	if delay == 0 {
-		atomic.AddInt64(&q.state.Queue, 1)
-		go func() {
-			q.jobs <- &entry{id: id, job: j, attempt: attempt}
-		}()
+// interrupt fails all pushes waiting for free space.
+func (q *queue) interrupt() {
+	q.mup.Lock()
+	defer q.mup.Unlock()
+
+	q.interrupts++
+	q.space.Broadcast()
+}
+
+// push new job into the queue, full queue is handled according to the overflow policy. Blocked push fails when
+// queue is stopped or push timeout is reached.
+func (q *queue) push(id string, j *jobs.Job, delay time.Duration) error {
+	q.mup.Lock()
+	defer q.mup.Unlock()
+
+	var deadline time.Time
+	for q.maxSize > 0 && len(q.pending) >= q.maxSize {
+		switch q.overflow {
+		case OverflowReject:
+			return fmt.Errorf("queue `%s` is full", q.pipe.Name())
+		case OverflowDropOldest:
+			q.remove(q.oldestEntry())
+			atomic.AddInt64(&q.state.Dropped, 1)
+		default:
+			if q.pushTimeout != 0 && deadline.IsZero() {
+				deadline = time.Now().Add(q.pushTimeout)
+
+				timer := time.AfterFunc(q.pushTimeout, func() {
+					q.mup.Lock()
+					defer q.mup.Unlock()
+
+					q.space.Broadcast()
+				})
+				defer timer.Stop()
+			}
+
+			if !deadline.IsZero() && !time.Now().Before(deadline) {
+				return fmt.Errorf("queue `%s` is full, push timed out", q.pipe.Name())
+			}
+
+			interrupts := q.interrupts
+			q.space.Wait()
+
+			if q.interrupts != interrupts {
+				return fmt.Errorf("queue `%s` has been stopped while waiting for free space", q.pipe.Name())
+			}
+		}
+	}
+
+	q.add(id, j, 0, delay)
 
+	return nil
+}
+
+// enqueue adds job to the queue regardless of the queue size, used for retried and restored jobs.
+func (q *queue) enqueue(id string, j *jobs.Job, attempt int, delay time.Duration) {
+	q.mup.Lock()
+	defer q.mup.Unlock()
+
+	q.add(id, j, attempt, delay)
+}
+
+// add creates new pending entry available after given delay, must be called under mup lock.
+func (q *queue) add(id string, j *jobs.Job, attempt int, delay time.Duration) {
+	e := &entry{
+		id:      id,
+		job:     j,
+		attempt: attempt,
+		queued:  time.Now().Add(delay),
+		queue:   q,
+		index:   -1,
+	}
+
+	q.pending[e] = true
+
+	if delay == 0 {
+		q.release(e)
 		return
 	}
 
 	atomic.AddInt64(&q.state.Delayed, 1)
-	go func() {
-		time.Sleep(delay)
+	q.sched.schedule(e)
+}
+
+// wake releases due delayed entry, called by the scheduler.
+func (q *queue) wake(e *entry) {
+	q.mup.Lock()
+	defer q.mup.Unlock()
+
+	if !q.pending[e] {
+		// removed while being released
+		return
+	}
+
+	atomic.AddInt64(&q.state.Delayed, ^int64(0))
+	q.release(e)
+}
+
+// release makes entry available to consumers, must be called under mup lock.
+func (q *queue) release(e *entry) {
+	e.elem = q.ready.PushBack(e)
+	atomic.AddInt64(&q.state.Queue, 1)
+
+	select {
+	case q.notify <- nil:
+	default:
+	}
+}
+
+// remove pending entry, must be called under mup lock.
+func (q *queue) remove(e *entry) {
+	if e == nil || !q.pending[e] {
+		return
+	}
+
+	delete(q.pending, e)
+
+	if e.elem != nil {
+		q.ready.Remove(e.elem)
+		e.elem = nil
+		atomic.AddInt64(&q.state.Queue, ^int64(0))
+	} else {
+		q.sched.cancel(e)
 		atomic.AddInt64(&q.state.Delayed, ^int64(0))
-		atomic.AddInt64(&q.state.Queue, 1)
+	}
+
+	q.space.Signal()
+}
+
+// oldestEntry returns the first ready entry or the delayed entry due first, must be called under mup lock.
+func (q *queue) oldestEntry() *entry {
+	if front := q.ready.Front(); front != nil {
+		return front.Value.(*entry)
+	}
+
+	var oldest *entry
+	for e := range q.pending {
+		if oldest == nil || e.queued.Before(oldest.queued) {
+			oldest = e
+		}
+	}
+
+	return oldest
+}
+
+// oldest returns age of the oldest ready entry.
+func (q *queue) oldest() time.Duration {
+	q.mup.Lock()
+	defer q.mup.Unlock()
+
+	front := q.ready.Front()
+	if front == nil {
+		return 0
+	}
+
+	return time.Since(front.Value.(*entry).queued)
+}
+
+// list pending entries in order of their availability.
+func (q *queue) list(offset, limit int) []*jobs.JobInfo {
+	q.mup.Lock()
+	entries := make([]*entry, 0, len(q.pending))
+	for e := range q.pending {
+		entries = append(entries, e)
+	}
+	q.mup.Unlock()
+
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].queued.Equal(entries[j].queued) {
+			return entries[i].id < entries[j].id
+		}
+
+		return entries[i].queued.Before(entries[j].queued)
+	})
+
+	if offset >= len(entries) {
+		return []*jobs.JobInfo{}
+	}
+
+	entries = entries[offset:]
+	if limit > 0 && limit < len(entries) {
+		entries = entries[:limit]
+	}
+
+	now := time.Now()
+	list := make([]*jobs.JobInfo, 0, len(entries))
+	for _, e := range entries {
+		list = append(list, e.info(now))
+	}
+
+	return list
+}
+
+// peek pending entry by it's id.
+func (q *queue) peek(id string) *jobs.JobInfo {
+	q.mup.Lock()
+	defer q.mup.Unlock()
+
+	for e := range q.pending {
+		if e.id == id {
+			return e.info(time.Now())
+		}
+	}
+
+	return nil
+}
+
+// removeID removes pending entry by it's id, returns false if entry is not pending.
+func (q *queue) removeID(id string) bool {
+	q.mup.Lock()
+	defer q.mup.Unlock()
+
+	for e := range q.pending {
+		if e.id == id {
+			q.remove(e)
+			return true
+		}
+	}
 
-		q.jobs <- &entry{id: id, job: j, attempt: attempt}
-	}()
+	return false
+}
+
+// purge removes pending entries, delayed entries are only removed when requested.
+func (q *queue) purge(delayed bool) int {
+	q.mup.Lock()
+	defer q.mup.Unlock()
+
+	now, n := time.Now(), 0
+	for e := range q.pending {
+		if !delayed && e.queued.After(now) {
+			continue
+		}
+
+		q.remove(e)
+		n++
+	}
+
+	return n
 }
 
 func (q *queue) stat() *jobs.Stat {
@@ -157,5 +506,17 @@ func (q *queue) stat() *jobs.Stat {
 		Queue:        atomic.LoadInt64(&q.state.Queue),
 		Active:       atomic.LoadInt64(&q.state.Active),
 		Delayed:      atomic.LoadInt64(&q.state.Delayed),
+		OldestAge:    q.oldest(),
+		Capacity:     int64(q.maxSize),
+		Dropped:      atomic.LoadInt64(&q.state.Dropped),
+		Failed:       q.failedCount(),
 	}
 }
+
+// failedCount returns number of retained failed entries.
+func (q *queue) failedCount() int64 {
+	q.mup.Lock()
+	defer q.mup.Unlock()
+
+	return int64(q.failed.Len())
+}
@@ -0,0 +1,152 @@
+package ephemeral
+
+import (
+	"github.com/spiral/jobs/v2"
+	"github.com/stretchr/testify/assert"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func newSchedulerQueue(t testing.TB) *queue {
+	q, err := newQueue(&jobs.Pipeline{"broker": "ephemeral", "name": "default"}, newScheduler(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return q
+}
+
+func TestScheduler_Order(t *testing.T) {
+	q := newSchedulerQueue(t)
+
+	q.enqueue("third", &jobs.Job{}, 0, 30*time.Millisecond)
+	q.enqueue("first", &jobs.Job{}, 0, 10*time.Millisecond)
+	q.enqueue("second", &jobs.Job{}, 0, 20*time.Millisecond)
+	assert.Equal(t, 3, q.sched.len())
+	assert.Equal(t, int64(3), q.stat().Delayed)
+
+	time.Sleep(100 * time.Millisecond)
+
+	assert.Equal(t, 0, q.sched.len())
+	assert.Equal(t, int64(0), q.stat().Delayed)
+	assert.Equal(t, int64(3), q.stat().Queue)
+
+	var ids []string
+	for e := q.ready.Front(); e != nil; e = e.Next() {
+		ids = append(ids, e.Value.(*entry).id)
+	}
+
+	assert.Equal(t, []string{"first", "second", "third"}, ids)
+}
+
+func TestScheduler_Cancel(t *testing.T) {
+	q := newSchedulerQueue(t)
+
+	q.enqueue("delayed", &jobs.Job{}, 0, 20*time.Millisecond)
+	q.enqueue("later", &jobs.Job{}, 0, time.Hour)
+	assert.Equal(t, 2, q.sched.len())
+
+	list := q.list(0, 0)
+	assert.Len(t, list, 2)
+	assert.Equal(t, jobs.JobDelayed, list[0].State)
+
+	assert.Equal(t, 2, q.purge(true))
+	assert.Equal(t, 0, q.sched.len())
+
+	time.Sleep(50 * time.Millisecond)
+
+	assert.Equal(t, int64(0), q.stat().Delayed)
+	assert.Equal(t, int64(0), q.stat().Queue)
+	assert.Equal(t, 0, q.ready.Len())
+}
+
+// memory returns amount of heap and stack memory in use.
+func memory() uint64 {
+	runtime.GC()
+
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+
+	return m.HeapAlloc + m.StackInuse
+}
+
+// sleeper parks every delayed job in it's own goroutine, the way delayed jobs used to be handled.
+type sleeper struct {
+	stop chan interface{}
+	done chan interface{}
+}
+
+func (s *sleeper) schedule(delay time.Duration) {
+	go func() {
+		timer := time.NewTimer(delay)
+		defer timer.Stop()
+
+		select {
+		case <-timer.C:
+			s.done <- nil
+		case <-s.stop:
+		}
+	}()
+}
+
+func BenchmarkScheduler_Memory(b *testing.B) {
+	q := newSchedulerQueue(b)
+	before := memory()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		q.enqueue("id", &jobs.Job{}, 0, time.Hour+time.Duration(i))
+	}
+	b.StopTimer()
+
+	b.ReportMetric(float64(memory()-before)/float64(b.N), "B/job")
+	q.purge(true)
+}
+
+func BenchmarkGoroutine_Memory(b *testing.B) {
+	s := &sleeper{stop: make(chan interface{})}
+	before := memory()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		s.schedule(time.Hour + time.Duration(i))
+	}
+	b.StopTimer()
+
+	b.ReportMetric(float64(memory()-before)/float64(b.N), "B/job")
+	close(s.stop)
+}
+
+func BenchmarkScheduler_Latency(b *testing.B) {
+	q := newSchedulerQueue(b)
+
+	var late time.Duration
+	for i := 0; i < b.N; i++ {
+		q.enqueue("id", &jobs.Job{}, 0, time.Millisecond)
+		due := time.Now().Add(time.Millisecond)
+
+		<-q.notify
+		late += time.Since(due)
+
+		q.purge(false)
+	}
+
+	b.ReportMetric(float64(late.Nanoseconds())/float64(b.N), "ns/late")
+}
+
+func BenchmarkGoroutine_Latency(b *testing.B) {
+	s := &sleeper{stop: make(chan interface{}), done: make(chan interface{})}
+	defer close(s.stop)
+
+	var late time.Duration
+	for i := 0; i < b.N; i++ {
+		s.schedule(time.Millisecond)
+		due := time.Now().Add(time.Millisecond)
+
+		<-s.done
+		late += time.Since(due)
+	}
+
+	b.ReportMetric(float64(late.Nanoseconds())/float64(b.N), "ns/late")
+}
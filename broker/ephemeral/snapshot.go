@@ -0,0 +1,128 @@
+package ephemeral
+
+import (
+	"fmt"
+	json "github.com/json-iterator/go"
+	"github.com/spiral/jobs/v2"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// record is snapshot of single pending entry.
+type record struct {
+	ID      string    `json:"id"`
+	Job     *jobs.Job `json:"job"`
+	Attempt int       `json:"attempt"`
+
+	// Delay remaining till entry is available, in milliseconds.
+	Delay int64 `json:"delay"`
+}
+
+// snapshot writes pending, delayed and currently executed entries into the snapshot file. Executed entries are
+// stored as they were before the delivery and will be executed again once restored.
+func (q *queue) snapshot() error {
+	q.mus.Lock()
+	defer q.mus.Unlock()
+
+	now := time.Now()
+
+	q.mup.Lock()
+	records := make([]*record, 0, len(q.pending)+len(q.active))
+	for _, m := range []map[*entry]bool{q.active, q.pending} {
+		for e := range m {
+			r := &record{ID: e.id, Job: e.job, Attempt: e.attempt}
+			if e.queued.After(now) {
+				r.Delay = int64(e.queued.Sub(now) / time.Millisecond)
+			}
+
+			records = append(records, r)
+		}
+	}
+	q.mup.Unlock()
+
+	data, err := json.Marshal(records)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(q.snapshotPath), filepath.Base(q.snapshotPath)+".*")
+	if err != nil {
+		return err
+	}
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	return os.Rename(tmp.Name(), q.snapshotPath)
+}
+
+// restore pushes entries from the snapshot file into the queue, missing file is ignored. Records without job are
+// skipped and reported as pipeline errors.
+func (q *queue) restore() error {
+	data, err := ioutil.ReadFile(q.snapshotPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+
+		return err
+	}
+
+	var records []*record
+	if err := json.Unmarshal(data, &records); err != nil {
+		return err
+	}
+
+	for _, r := range records {
+		if r == nil || r.Job == nil {
+			q.report(fmt.Errorf("skipped snapshot record of queue `%s` without job", q.pipe.Name()))
+			continue
+		}
+
+		if r.Job.Options == nil {
+			r.Job.Options = &jobs.Options{}
+		}
+
+		q.enqueue(r.ID, r.Job, r.Attempt, time.Duration(r.Delay)*time.Millisecond)
+	}
+
+	return nil
+}
+
+// snapshots writes snapshot file every interval until stopped.
+func (q *queue) snapshots(interval time.Duration, stop chan interface{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			q.report(q.snapshot())
+		}
+	}
+}
+
+// report queue specific error
+func (q *queue) report(err error) {
+	if err != nil {
+		q.lsn(jobs.EventPipeError, &jobs.PipelineError{Pipeline: q.pipe, Caused: err})
+	}
+}
@@ -0,0 +1,154 @@
+package ephemeral
+
+import (
+	"github.com/spiral/jobs/v2"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// serveSnapshot starts broker with snapshot pipeline.
+func serveSnapshot(t *testing.T, p *jobs.Pipeline) *Broker {
+	b := &Broker{}
+	_, err := b.Init()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = b.Register(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ready := make(chan interface{})
+	b.Listen(func(event int, ctx interface{}) {
+		if event == jobs.EventBrokerReady {
+			close(ready)
+		}
+	})
+
+	go func() { assert.NoError(t, b.Serve()) }()
+	<-ready
+
+	return b
+}
+
+func TestBroker_Snapshot_Restore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ephemeral")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	p := &jobs.Pipeline{"broker": "ephemeral", "name": "default", "snapshot": filepath.Join(dir, "default.json")}
+
+	b := serveSnapshot(t, p)
+
+	jid, err := b.Push(p, &jobs.Job{Job: "test", Payload: "body", Options: &jobs.Options{Attempts: 3}})
+	assert.NoError(t, err)
+
+	_, err = b.Push(p, &jobs.Job{Job: "test", Payload: "delayed", Options: &jobs.Options{Delay: 60}})
+	assert.NoError(t, err)
+
+	b.Stop()
+
+	b = serveSnapshot(t, p)
+	defer b.Stop()
+
+	stat, err := b.Stat(p)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), stat.Queue)
+	assert.Equal(t, int64(1), stat.Delayed)
+
+	list, err := b.List(p, 0, 0)
+	assert.NoError(t, err)
+	assert.Len(t, list, 2)
+	assert.Equal(t, jid, list[0].ID)
+	assert.Equal(t, jobs.JobReady, list[0].State)
+	assert.Equal(t, "delayed", list[1].Job.Payload)
+	assert.Equal(t, jobs.JobDelayed, list[1].State)
+
+	exec := make(chan jobs.Handler, 1)
+	assert.NoError(t, b.Consume(p, exec, func(id string, j *jobs.Job, err error) {}))
+
+	waitJob := make(chan interface{})
+	exec <- func(id string, j *jobs.Job) error {
+		assert.Equal(t, jid, id)
+		assert.Equal(t, "body", j.Payload)
+		assert.Equal(t, 3, j.Options.Attempts)
+		close(waitJob)
+		return nil
+	}
+
+	<-waitJob
+}
+
+func TestBroker_Snapshot_Interval(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ephemeral")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	p := &jobs.Pipeline{
+		"broker":           "ephemeral",
+		"name":             "default",
+		"snapshot":         filepath.Join(dir, "default.json"),
+		"snapshotInterval": 1,
+	}
+
+	b := serveSnapshot(t, p)
+	defer b.Stop()
+
+	_, err = b.Push(p, &jobs.Job{Job: "test", Payload: "body", Options: &jobs.Options{}})
+	assert.NoError(t, err)
+
+	time.Sleep(1500 * time.Millisecond)
+
+	q, err := newQueue(p, b.sched, b.throw)
+	assert.NoError(t, err)
+	assert.NoError(t, q.restore())
+	assert.Len(t, q.list(0, 0), 1)
+}
+
+func TestBroker_Snapshot_Broken(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ephemeral")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "default.json")
+	assert.NoError(t, ioutil.WriteFile(path, []byte("{broken"), 0644))
+
+	b := &Broker{}
+	_, err = b.Init()
+	assert.NoError(t, err)
+	assert.Error(t, b.Register(&jobs.Pipeline{"broker": "ephemeral", "name": "default", "snapshot": path}))
+}
+
+func TestBroker_Snapshot_NullJob(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ephemeral")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "default.json")
+	assert.NoError(t, ioutil.WriteFile(path, []byte(`[{"id":"broken","job":null},null,{"id":"valid","job":{"job":"test"}}]`), 0644))
+
+	b := &Broker{}
+	_, err = b.Init()
+	assert.NoError(t, err)
+
+	var failed []error
+	b.Listen(func(event int, ctx interface{}) {
+		if event == jobs.EventPipeError {
+			failed = append(failed, ctx.(*jobs.PipelineError).Caused)
+		}
+	})
+
+	p := &jobs.Pipeline{"broker": "ephemeral", "name": "default", "snapshot": path}
+	assert.NoError(t, b.Register(p))
+	assert.Len(t, failed, 2)
+
+	list := b.queues[p].list(0, 0)
+	assert.Len(t, list, 1)
+	assert.Equal(t, "valid", list[0].ID)
+	assert.NotNil(t, list[0].Job.Options)
+}
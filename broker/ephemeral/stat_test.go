@@ -4,6 +4,7 @@ import (
 	"github.com/spiral/jobs/v2"
 	"github.com/stretchr/testify/assert"
 	"testing"
+	"time"
 )
 
 func TestBroker_Stat(t *testing.T) {
@@ -36,10 +37,13 @@ func TestBroker_Stat(t *testing.T) {
 	assert.NotEqual(t, "", jid)
 	assert.NoError(t, perr)
 
+	time.Sleep(10 * time.Millisecond)
+
 	stat, err := b.Stat(pipe)
 	assert.NoError(t, err)
 	assert.Equal(t, int64(1), stat.Queue)
 	assert.Equal(t, int64(0), stat.Active)
+	assert.True(t, stat.OldestAge >= 10*time.Millisecond)
 
 	assert.NoError(t, b.Consume(pipe, exec, func(id string, j *jobs.Job, err error) {}))
 
@@ -61,4 +65,5 @@ func TestBroker_Stat(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, int64(0), stat.Queue)
 	assert.Equal(t, int64(0), stat.Active)
+	assert.Equal(t, time.Duration(0), stat.OldestAge)
 }
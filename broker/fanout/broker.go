@@ -0,0 +1,249 @@
+package fanout
+
+import (
+	"fmt"
+	"github.com/spiral/jobs/v2"
+	"strings"
+	"sync"
+)
+
+const (
+	// ModeAll only pushes the job when all targets are healthy and fails the push when any of the targets fails,
+	// copies already pushed into other targets are withdrawn.
+	ModeAll = "all"
+
+	// ModeBestEffort fails the push only when none of the targets accepted the job.
+	ModeBestEffort = "best-effort"
+)
+
+// Broker is virtual broker which mirrors every pushed job into the list of target pipelines. Jobs are consumed from
+// target pipelines, fanout pipelines do not hold any jobs. Jobs are pushed directly into target pipelines, their
+// failover pipelines and outbox are not used.
+type Broker struct {
+	router  jobs.Router
+	lsn     func(event int, ctx interface{})
+	mu      sync.Mutex
+	wait    chan error
+	stopped chan interface{}
+	targets map[*jobs.Pipeline][]string
+}
+
+// Listen attaches server event watcher.
+func (b *Broker) Listen(lsn func(event int, ctx interface{})) {
+	b.lsn = lsn
+}
+
+// UseRouter attaches router to push jobs into target pipelines.
+func (b *Broker) UseRouter(r jobs.Router) {
+	b.router = r
+}
+
+// Init configures broker.
+func (b *Broker) Init() (bool, error) {
+	b.targets = make(map[*jobs.Pipeline][]string)
+
+	return true, nil
+}
+
+// Register broker pipeline.
+func (b *Broker) Register(pipe *jobs.Pipeline) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if _, ok := b.targets[pipe]; ok {
+		return fmt.Errorf("fanout `%s` has already been registered", pipe.Name())
+	}
+
+	targets := pipe.Strings("targets")
+	if len(targets) == 0 {
+		return fmt.Errorf("missing `targets` parameter on fanout pipeline `%s`", pipe.Name())
+	}
+
+	for _, t := range targets {
+		if t == pipe.Name() {
+			return fmt.Errorf("fanout pipeline `%s` can not target itself", pipe.Name())
+		}
+	}
+
+	switch mode := pipe.String("mode", ModeAll); mode {
+	case ModeAll, ModeBestEffort:
+	default:
+		return fmt.Errorf("invalid `mode` parameter `%s` on fanout pipeline `%s`", mode, pipe.Name())
+	}
+
+	b.targets[pipe] = targets
+
+	return nil
+}
+
+// Serve broker pipelines.
+func (b *Broker) Serve() error {
+	b.mu.Lock()
+	b.wait = make(chan error)
+	b.stopped = make(chan interface{})
+	defer close(b.stopped)
+	b.mu.Unlock()
+
+	b.throw(jobs.EventBrokerReady, b)
+
+	return <-b.wait
+}
+
+// Stop all pipelines.
+func (b *Broker) Stop() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.wait == nil {
+		return
+	}
+
+	close(b.wait)
+	<-b.stopped
+}
+
+// Consume does nothing as jobs are consumed from target pipelines.
+func (b *Broker) Consume(pipe *jobs.Pipeline, execPool chan jobs.Handler, errHandler jobs.ErrorHandler) error {
+	if b.pipeTargets(pipe) == nil {
+		return fmt.Errorf("undefined fanout `%s`", pipe.Name())
+	}
+
+	return nil
+}
+
+// Push job into every target pipeline and return composite job id. In `all` mode the job is not pushed when any
+// of the targets is unhealthy, when push fails copies already pushed into other targets are withdrawn. Copies can
+// only be withdrawn from brokers able to remove pending jobs, copies left in targets are listed in the push error.
+func (b *Broker) Push(pipe *jobs.Pipeline, j *jobs.Job) (string, error) {
+	if err := b.isServing(); err != nil {
+		return "", err
+	}
+
+	targets := b.pipeTargets(pipe)
+	if targets == nil {
+		return "", fmt.Errorf("undefined fanout `%s`", pipe.Name())
+	}
+
+	if b.router == nil {
+		return "", fmt.Errorf("fanout `%s` is not attached to the router", pipe.Name())
+	}
+
+	all := pipe.String("mode", ModeAll) == ModeAll
+	if all {
+		if err := b.checkHealth(targets); err != nil {
+			return "", err
+		}
+	}
+
+	results := make([]Result, 0, len(targets))
+	failed := 0
+	for _, target := range targets {
+		id, err := b.router.Route(target, copyJob(j, target))
+		results = append(results, Result{Pipeline: target, ID: id, Error: err})
+
+		if err != nil {
+			failed++
+			if all {
+				b.withdraw(results)
+				return "", &Error{Results: results}
+			}
+		}
+	}
+
+	if failed == 0 {
+		return ID(results), nil
+	}
+
+	err := &Error{Results: results}
+	if failed == len(targets) {
+		return "", err
+	}
+
+	// partially delivered job is reported without failing the push
+	b.throw(jobs.EventPipeError, &jobs.PipelineError{Pipeline: pipe, Caused: err})
+
+	return ID(results), nil
+}
+
+// Stat returns empty stat, jobs are stored in target pipelines.
+func (b *Broker) Stat(pipe *jobs.Pipeline) (stat *jobs.Stat, err error) {
+	if err := b.isServing(); err != nil {
+		return nil, err
+	}
+
+	targets := b.pipeTargets(pipe)
+	if targets == nil {
+		return nil, fmt.Errorf("undefined fanout `%s`", pipe.Name())
+	}
+
+	return &jobs.Stat{InternalName: strings.Join(targets, ",")}, nil
+}
+
+// Health returns error if broker is not serving.
+func (b *Broker) Health() error {
+	return b.isServing()
+}
+
+// check if broker is serving
+func (b *Broker) isServing() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.wait == nil {
+		return fmt.Errorf("broker is not running")
+	}
+
+	return nil
+}
+
+// checkHealth returns error listing unhealthy target pipelines.
+func (b *Broker) checkHealth(targets []string) error {
+	var results []Result
+	for _, target := range targets {
+		if err := b.router.Healthy(target); err != nil {
+			results = append(results, Result{Pipeline: target, Error: err})
+		}
+	}
+
+	if len(results) != 0 {
+		return &Error{Results: results}
+	}
+
+	return nil
+}
+
+// withdraw copies pushed into target pipelines.
+func (b *Broker) withdraw(results []Result) {
+	for i, r := range results {
+		if r.Error == nil {
+			results[i].Withdrawn = b.router.Withdraw(r.Pipeline, r.ID) == nil
+		}
+	}
+}
+
+// pipeTargets returns target pipeline names of the fanout pipeline.
+func (b *Broker) pipeTargets(pipe *jobs.Pipeline) []string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.targets[pipe]
+}
+
+// throw handles service, server and pool events.
+func (b *Broker) throw(event int, ctx interface{}) {
+	if b.lsn != nil {
+		b.lsn(event, ctx)
+	}
+}
+
+// copyJob creates job copy to be pushed into given pipeline.
+func copyJob(j *jobs.Job, pipeline string) *jobs.Job {
+	c := &jobs.Job{Job: j.Job, Payload: j.Payload, Options: &jobs.Options{}}
+	if j.Options != nil {
+		*c.Options = *j.Options
+	}
+
+	c.Options.Pipeline = pipeline
+
+	return c
+}
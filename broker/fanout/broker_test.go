@@ -0,0 +1,267 @@
+package fanout
+
+import (
+	"fmt"
+	"github.com/spiral/jobs/v2"
+	"github.com/stretchr/testify/assert"
+	"sync"
+	"testing"
+)
+
+var (
+	pipe = &jobs.Pipeline{
+		"broker":  "fanout",
+		"name":    "mirror",
+		"targets": []interface{}{"a", "b"},
+	}
+)
+
+// testRouter records routed jobs, pipelines listed in failing reject the push, pipelines listed in down are
+// unhealthy and jobs routed into pipelines listed in sticky can not be withdrawn.
+type testRouter struct {
+	mu      sync.Mutex
+	routed  map[string]*jobs.Job
+	failing map[string]bool
+	down    map[string]bool
+	sticky  map[string]bool
+}
+
+func (r *testRouter) Healthy(pipeline string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.down[pipeline] {
+		return fmt.Errorf("pipeline `%s` is down", pipeline)
+	}
+
+	return nil
+}
+
+func (r *testRouter) Withdraw(pipeline, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.sticky[pipeline] || r.routed[pipeline] == nil {
+		return fmt.Errorf("unable to withdraw `%s`", id)
+	}
+
+	delete(r.routed, pipeline)
+
+	return nil
+}
+
+func (r *testRouter) Route(pipeline string, j *jobs.Job) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.failing[pipeline] {
+		return "", fmt.Errorf("pipeline `%s` is down", pipeline)
+	}
+
+	r.routed[pipeline] = j
+
+	return "id-" + pipeline, nil
+}
+
+// newBroker creates broker attached to the test router.
+func newBroker(t *testing.T, failing ...string) (*Broker, *testRouter) {
+	r := &testRouter{
+		routed:  make(map[string]*jobs.Job),
+		failing: make(map[string]bool),
+		down:    make(map[string]bool),
+		sticky:  make(map[string]bool),
+	}
+	for _, f := range failing {
+		r.failing[f] = true
+	}
+
+	b := &Broker{}
+	b.UseRouter(r)
+	if _, err := b.Init(); err != nil {
+		t.Fatal(err)
+	}
+
+	return b, r
+}
+
+// serve broker and wait till it's ready.
+func serve(t *testing.T, b *Broker) {
+	ready := make(chan interface{})
+	b.Listen(func(event int, ctx interface{}) {
+		if event == jobs.EventBrokerReady {
+			close(ready)
+		}
+	})
+
+	go func() { assert.NoError(t, b.Serve()) }()
+	<-ready
+}
+
+func TestBroker_Init(t *testing.T) {
+	b := &Broker{}
+	ok, err := b.Init()
+	assert.True(t, ok)
+	assert.NoError(t, err)
+}
+
+func TestBroker_StopNotStarted(t *testing.T) {
+	b, _ := newBroker(t)
+	b.Stop()
+}
+
+func TestBroker_Register(t *testing.T) {
+	b, _ := newBroker(t)
+	assert.NoError(t, b.Register(pipe))
+	assert.Error(t, b.Register(pipe))
+}
+
+func TestBroker_Register_Invalid(t *testing.T) {
+	b, _ := newBroker(t)
+
+	assert.Error(t, b.Register(&jobs.Pipeline{"broker": "fanout", "name": "mirror"}))
+	assert.Error(t, b.Register(&jobs.Pipeline{"broker": "fanout", "name": "mirror", "targets": []string{"mirror"}}))
+	assert.Error(t, b.Register(&jobs.Pipeline{
+		"broker":  "fanout",
+		"name":    "mirror",
+		"targets": []string{"a"},
+		"mode":    "some",
+	}))
+}
+
+func TestBroker_Consume(t *testing.T) {
+	b, _ := newBroker(t)
+	assert.Error(t, b.Consume(pipe, nil, nil))
+
+	assert.NoError(t, b.Register(pipe))
+	assert.NoError(t, b.Consume(pipe, make(chan jobs.Handler), nil))
+	assert.NoError(t, b.Consume(pipe, nil, nil))
+}
+
+func TestBroker_Push_NotServing(t *testing.T) {
+	b, _ := newBroker(t)
+	assert.NoError(t, b.Register(pipe))
+
+	_, err := b.Push(pipe, &jobs.Job{Job: "test", Payload: "body", Options: &jobs.Options{}})
+	assert.Error(t, err)
+	assert.Error(t, b.Health())
+}
+
+func TestBroker_Push(t *testing.T) {
+	b, r := newBroker(t)
+	assert.NoError(t, b.Register(pipe))
+
+	serve(t, b)
+	defer b.Stop()
+
+	j := &jobs.Job{Job: "test", Payload: "body", Options: &jobs.Options{Pipeline: "mirror", Attempts: 3}}
+
+	id, err := b.Push(pipe, j)
+	assert.NoError(t, err)
+	assert.Equal(t, "a:id-a,b:id-b", id)
+
+	assert.Equal(t, "a", r.routed["a"].Options.Pipeline)
+	assert.Equal(t, "b", r.routed["b"].Options.Pipeline)
+	assert.Equal(t, 3, r.routed["b"].Options.Attempts)
+	assert.Equal(t, "body", r.routed["b"].Payload)
+
+	// original job is not affected
+	assert.Equal(t, "mirror", j.Options.Pipeline)
+
+	stat, err := b.Stat(pipe)
+	assert.NoError(t, err)
+	assert.Equal(t, "a,b", stat.InternalName)
+}
+
+func TestBroker_Push_All(t *testing.T) {
+	b, r := newBroker(t, "b")
+	assert.NoError(t, b.Register(pipe))
+
+	serve(t, b)
+	defer b.Stop()
+
+	_, err := b.Push(pipe, &jobs.Job{Job: "test", Payload: "body", Options: &jobs.Options{}})
+	assert.Error(t, err)
+
+	results := err.(*Error).Results
+	assert.Len(t, results, 2)
+	assert.NoError(t, results[0].Error)
+	assert.Equal(t, "id-a", results[0].ID)
+	assert.True(t, results[0].Withdrawn)
+	assert.Error(t, results[1].Error)
+	assert.Equal(t, "fanout failed for `b`: pipeline `b` is down", err.Error())
+
+	// copy pushed into the first target is withdrawn
+	assert.Len(t, r.routed, 0)
+}
+
+func TestBroker_Push_All_Unhealthy(t *testing.T) {
+	b, r := newBroker(t)
+	r.down["b"] = true
+	assert.NoError(t, b.Register(pipe))
+
+	serve(t, b)
+	defer b.Stop()
+
+	_, err := b.Push(pipe, &jobs.Job{Job: "test", Payload: "body", Options: &jobs.Options{}})
+	assert.Error(t, err)
+	assert.Equal(t, "fanout failed for `b`: pipeline `b` is down", err.Error())
+
+	// nothing is pushed while any target is unhealthy
+	assert.Len(t, r.routed, 0)
+}
+
+func TestBroker_Push_All_Kept(t *testing.T) {
+	b, r := newBroker(t, "b")
+	r.sticky["a"] = true
+	assert.NoError(t, b.Register(pipe))
+
+	serve(t, b)
+	defer b.Stop()
+
+	_, err := b.Push(pipe, &jobs.Job{Job: "test", Payload: "body", Options: &jobs.Options{}})
+	assert.Error(t, err)
+	assert.False(t, err.(*Error).Results[0].Withdrawn)
+	assert.Equal(t, "fanout failed for `b`: pipeline `b` is down, job is kept in `a`", err.Error())
+	assert.NotNil(t, r.routed["a"])
+}
+
+func TestBroker_Push_BestEffort(t *testing.T) {
+	p := &jobs.Pipeline{"broker": "fanout", "name": "mirror", "targets": []string{"a", "b"}, "mode": ModeBestEffort}
+
+	b, _ := newBroker(t, "b")
+	assert.NoError(t, b.Register(p))
+
+	ready := make(chan interface{})
+	errs := make(chan error, 1)
+	b.Listen(func(event int, ctx interface{}) {
+		switch event {
+		case jobs.EventBrokerReady:
+			close(ready)
+		case jobs.EventPipeError:
+			errs <- ctx.(*jobs.PipelineError).Caused
+		}
+	})
+
+	go func() { assert.NoError(t, b.Serve()) }()
+	defer b.Stop()
+	<-ready
+
+	id, err := b.Push(p, &jobs.Job{Job: "test", Payload: "body", Options: &jobs.Options{}})
+	assert.NoError(t, err)
+	assert.Equal(t, "a:id-a", id)
+
+	assert.Len(t, (<-errs).(*Error).Results, 2)
+}
+
+func TestBroker_Push_BestEffort_AllFailed(t *testing.T) {
+	p := &jobs.Pipeline{"broker": "fanout", "name": "mirror", "targets": []string{"a", "b"}, "mode": ModeBestEffort}
+
+	b, _ := newBroker(t, "a", "b")
+	assert.NoError(t, b.Register(p))
+
+	serve(t, b)
+	defer b.Stop()
+
+	_, err := b.Push(p, &jobs.Job{Job: "test", Payload: "body", Options: &jobs.Options{}})
+	assert.Error(t, err)
+}
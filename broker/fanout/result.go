@@ -0,0 +1,77 @@
+package fanout
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Result describes push into single target pipeline.
+type Result struct {
+	// Pipeline is target pipeline name.
+	Pipeline string
+
+	// ID of the job pushed into the pipeline, empty on failure.
+	ID string
+
+	// Error occurred during the push.
+	Error error
+
+	// Withdrawn is set when pushed job has been removed from the pipeline after another target failed.
+	Withdrawn bool
+}
+
+// Error is returned when job was not pushed into some of the target pipelines.
+type Error struct {
+	// Results of the targets, including successful ones.
+	Results []Result
+}
+
+// Error returns error message listing failed targets and targets which still hold the pushed job.
+func (e *Error) Error() string {
+	var failed, kept []string
+	for _, r := range e.Results {
+		switch {
+		case r.Error != nil:
+			failed = append(failed, fmt.Sprintf("`%s`: %s", r.Pipeline, r.Error))
+		case !r.Withdrawn:
+			kept = append(kept, fmt.Sprintf("`%s`", r.Pipeline))
+		}
+	}
+
+	if len(kept) == 0 {
+		return fmt.Sprintf("fanout failed for %s", strings.Join(failed, ", "))
+	}
+
+	return fmt.Sprintf("fanout failed for %s, job is kept in %s", strings.Join(failed, ", "), strings.Join(kept, ", "))
+}
+
+// ID returns composite id of the pushed jobs in a form of `pipeline:id,pipeline:id`, failed targets are omitted.
+func ID(results []Result) string {
+	var ids []string
+	for _, r := range results {
+		if r.Error == nil {
+			ids = append(ids, r.Pipeline+":"+r.ID)
+		}
+	}
+
+	return strings.Join(ids, ",")
+}
+
+// parseID returns job ids associated with target pipelines.
+func parseID(id string) (map[string]string, error) {
+	ids := make(map[string]string)
+	if id == "" {
+		return ids, nil
+	}
+
+	for _, part := range strings.Split(id, ",") {
+		chunks := strings.SplitN(part, ":", 2)
+		if len(chunks) != 2 || chunks[0] == "" || chunks[1] == "" {
+			return nil, fmt.Errorf("invalid fanout id `%s`", id)
+		}
+
+		ids[chunks[0]] = chunks[1]
+	}
+
+	return ids, nil
+}
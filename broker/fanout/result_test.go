@@ -0,0 +1,31 @@
+package fanout
+
+import (
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestID(t *testing.T) {
+	id := ID([]Result{
+		{Pipeline: "a", ID: "1"},
+		{Pipeline: "b", Error: fmt.Errorf("failed")},
+		{Pipeline: "c", ID: "3"},
+	})
+	assert.Equal(t, "a:1,c:3", id)
+
+	ids, err := parseID(id)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"a": "1", "c": "3"}, ids)
+
+	ids, err = parseID("")
+	assert.NoError(t, err)
+	assert.Len(t, ids, 0)
+}
+
+func Test_ParseID_Invalid(t *testing.T) {
+	for _, id := range []string{"a", "a:", ":1", "a:1,b"} {
+		_, err := parseID(id)
+		assert.Error(t, err, id)
+	}
+}
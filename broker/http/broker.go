@@ -0,0 +1,111 @@
+package http
+
+import (
+	"fmt"
+	"github.com/spiral/jobs/v2"
+	"github.com/spiral/jobs/v2/broker/ephemeral"
+	"sync"
+)
+
+// Broker keeps jobs in memory like the ephemeral broker and executes them by posting to the pipeline endpoint
+// instead of PHP workers. Number of parallel requests is limited by `maxThreads` pipeline option (10 by default),
+// all other options of ephemeral pipelines are supported. Pipelines are consumed even when workers are not
+// configured.
+type Broker struct {
+	ephemeral.Broker
+	lsn       func(event int, ctx interface{})
+	mu        sync.Mutex
+	endpoints map[*jobs.Pipeline]*endpoint
+}
+
+// Listen attaches server event watcher.
+func (b *Broker) Listen(lsn func(event int, ctx interface{})) {
+	b.lsn = lsn
+}
+
+// Init configures broker.
+func (b *Broker) Init() (bool, error) {
+	b.endpoints = make(map[*jobs.Pipeline]*endpoint)
+	b.Broker.Listen(b.forward)
+
+	return b.Broker.Init()
+}
+
+// Register broker pipeline.
+func (b *Broker) Register(pipe *jobs.Pipeline) error {
+	e, err := newEndpoint(pipe, b.throw)
+	if err != nil {
+		return err
+	}
+
+	if err := b.Broker.Register(pipe); err != nil {
+		return err
+	}
+
+	b.mu.Lock()
+	b.endpoints[pipe] = e
+	b.mu.Unlock()
+
+	return nil
+}
+
+// Consume configures pipeline to be consumed. With execPool to nil to disable consuming. Method can be called before
+// the service is started! Jobs are executed by the endpoint, exec pool handlers are never used.
+func (b *Broker) Consume(pipe *jobs.Pipeline, execPool chan jobs.Handler, errHandler jobs.ErrorHandler) error {
+	e := b.endpoint(pipe)
+	if e == nil {
+		return fmt.Errorf("undefined queue `%s`", pipe.Name())
+	}
+
+	if execPool == nil {
+		return b.Broker.Consume(pipe, nil, nil)
+	}
+
+	return b.Broker.Consume(pipe, e.handlers, errHandler)
+}
+
+// Executes returns true as jobs of all pipelines are executed by their endpoints.
+func (b *Broker) Executes(pipe *jobs.Pipeline) bool {
+	return true
+}
+
+// Stat must consume statistics about given pipeline or return error.
+func (b *Broker) Stat(pipe *jobs.Pipeline) (stat *jobs.Stat, err error) {
+	stat, err = b.Broker.Stat(pipe)
+	if err != nil {
+		return nil, err
+	}
+
+	stat.InternalName = b.endpoint(pipe).url
+
+	return stat, nil
+}
+
+// endpoint returns endpoint associated with the pipeline.
+func (b *Broker) endpoint(pipe *jobs.Pipeline) *endpoint {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	e, ok := b.endpoints[pipe]
+	if !ok {
+		return nil
+	}
+
+	return e
+}
+
+// forward events of the in-memory queues, broker readiness is reported on behalf of the http broker.
+func (b *Broker) forward(event int, ctx interface{}) {
+	if event == jobs.EventBrokerReady {
+		ctx = b
+	}
+
+	b.throw(event, ctx)
+}
+
+// throw handles service, server and pool events.
+func (b *Broker) throw(event int, ctx interface{}) {
+	if b.lsn != nil {
+		b.lsn(event, ctx)
+	}
+}
@@ -0,0 +1,90 @@
+package http
+
+import (
+	"github.com/spiral/jobs/v2"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// newBroker creates broker with pipeline posting jobs to given handler.
+func newBroker(t *testing.T, h http.HandlerFunc, options map[string]interface{}) (*Broker, *jobs.Pipeline, func()) {
+	srv := httptest.NewServer(h)
+
+	pipe := &jobs.Pipeline{"broker": "http", "name": "default", "url": srv.URL}
+	for k, v := range options {
+		(*pipe)[k] = v
+	}
+
+	b := &Broker{}
+	if _, err := b.Init(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := b.Register(pipe); err != nil {
+		t.Fatal(err)
+	}
+
+	return b, pipe, srv.Close
+}
+
+// serve broker and wait till it's ready.
+func serve(t *testing.T, b *Broker) {
+	ready := make(chan interface{})
+	b.Listen(func(event int, ctx interface{}) {
+		if event == jobs.EventBrokerReady {
+			close(ready)
+		}
+	})
+
+	go func() { assert.NoError(t, b.Serve()) }()
+	<-ready
+}
+
+func TestBroker_Init(t *testing.T) {
+	b := &Broker{}
+	ok, err := b.Init()
+	assert.True(t, ok)
+	assert.NoError(t, err)
+}
+
+func TestBroker_StopNotStarted(t *testing.T) {
+	b := &Broker{}
+	_, err := b.Init()
+	assert.NoError(t, err)
+
+	b.Stop()
+}
+
+func TestBroker_Register_Twice(t *testing.T) {
+	b, pipe, shutdown := newBroker(t, func(w http.ResponseWriter, r *http.Request) {}, nil)
+	defer shutdown()
+
+	assert.Error(t, b.Register(pipe))
+}
+
+func TestBroker_Register_MissingURL(t *testing.T) {
+	b := &Broker{}
+	_, err := b.Init()
+	assert.NoError(t, err)
+
+	assert.Error(t, b.Register(&jobs.Pipeline{"broker": "http", "name": "default"}))
+}
+
+func TestBroker_Consume_Undefined(t *testing.T) {
+	b := &Broker{}
+	_, err := b.Init()
+	assert.NoError(t, err)
+
+	assert.Error(t, b.Consume(&jobs.Pipeline{"name": "default"}, nil, nil))
+}
+
+func TestBroker_Push_NotServing(t *testing.T) {
+	b, pipe, shutdown := newBroker(t, func(w http.ResponseWriter, r *http.Request) {}, nil)
+	defer shutdown()
+
+	_, err := b.Push(pipe, &jobs.Job{Job: "test", Payload: "body", Options: &jobs.Options{}})
+	assert.Error(t, err)
+	assert.Error(t, b.Health())
+}
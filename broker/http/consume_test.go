@@ -0,0 +1,204 @@
+package http
+
+import (
+	json "github.com/json-iterator/go"
+	"github.com/spiral/jobs/v2"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestBroker_Consume_Job(t *testing.T) {
+	requests := make(chan *http.Request, 1)
+	bodies := make(chan []byte, 1)
+
+	b, pipe, shutdown := newBroker(t, func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		requests <- r
+		bodies <- body
+	}, map[string]interface{}{
+		"method":  "PUT",
+		"headers": map[string]interface{}{"Authorization": "Bearer token"},
+		"secret":  "secret",
+	})
+	defer shutdown()
+
+	exec := make(chan jobs.Handler, 1)
+	assert.NoError(t, b.Consume(pipe, exec, func(id string, j *jobs.Job, err error) {
+		t.Error(err)
+	}))
+
+	serve(t, b)
+	defer b.Stop()
+
+	jid, err := b.Push(pipe, &jobs.Job{Job: "test", Payload: "body", Options: &jobs.Options{}})
+	assert.NoError(t, err)
+
+	r, body := <-requests, <-bodies
+	assert.Equal(t, "PUT", r.Method)
+	assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+	assert.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+	assert.Equal(t, jobs.Sign("secret", body), r.Header.Get("X-Signature"))
+
+	req := &request{}
+	assert.NoError(t, json.Unmarshal(body, req))
+	assert.Equal(t, jid, req.ID)
+	assert.Equal(t, "test", req.Job)
+	assert.Equal(t, "body", req.Payload)
+	assert.Equal(t, "default", req.Pipeline)
+	assert.Equal(t, 0, req.Attempt)
+}
+
+func TestBroker_Consume_Events(t *testing.T) {
+	b, pipe, shutdown := newBroker(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Content-Type") != "" {
+			w.WriteHeader(http.StatusUnprocessableEntity)
+		}
+	}, nil)
+	defer shutdown()
+
+	exec := make(chan jobs.Handler, 1)
+	assert.NoError(t, b.Consume(pipe, exec, func(id string, j *jobs.Job, err error) {}))
+
+	ready, events := make(chan interface{}), make(chan int, 2)
+	b.Listen(func(event int, ctx interface{}) {
+		switch event {
+		case jobs.EventBrokerReady:
+			close(ready)
+		case jobs.EventJobStart, jobs.EventJobOK, jobs.EventJobError:
+			events <- event
+		}
+	})
+
+	go func() { assert.NoError(t, b.Serve()) }()
+	defer b.Stop()
+	<-ready
+
+	_, err := b.Push(pipe, &jobs.Job{Job: "test", Payload: "body", Options: &jobs.Options{}})
+	assert.NoError(t, err)
+
+	assert.Equal(t, jobs.EventJobStart, <-events)
+	assert.Equal(t, jobs.EventJobError, <-events)
+}
+
+func TestBroker_Consume_Retryable(t *testing.T) {
+	var calls int32
+	b, pipe, shutdown := newBroker(t, func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}, nil)
+	defer shutdown()
+
+	errors := make(chan error, 3)
+	exec := make(chan jobs.Handler, 1)
+	assert.NoError(t, b.Consume(pipe, exec, func(id string, j *jobs.Job, err error) {
+		errors <- err
+	}))
+
+	serve(t, b)
+	defer b.Stop()
+
+	_, err := b.Push(pipe, &jobs.Job{Job: "test", Payload: "body", Options: &jobs.Options{Attempts: 3}})
+	assert.NoError(t, err)
+
+	for i := 0; i < 3; i++ {
+		err := <-errors
+		assert.Equal(t, http.StatusServiceUnavailable, err.(*StatusError).Status)
+	}
+
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&calls))
+
+	stat, err := b.Stat(pipe)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), stat.Queue)
+	assert.Equal(t, int64(0), stat.Active)
+	assert.Equal(t, int64(0), stat.Delayed)
+}
+
+func TestBroker_Consume_Permanent(t *testing.T) {
+	var calls int32
+	b, pipe, shutdown := newBroker(t, func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusUnprocessableEntity)
+	}, nil)
+	defer shutdown()
+
+	errors := make(chan error, 3)
+	exec := make(chan jobs.Handler, 1)
+	assert.NoError(t, b.Consume(pipe, exec, func(id string, j *jobs.Job, err error) {
+		errors <- err
+	}))
+
+	serve(t, b)
+	defer b.Stop()
+
+	_, err := b.Push(pipe, &jobs.Job{Job: "test", Payload: "body", Options: &jobs.Options{Attempts: 3}})
+	assert.NoError(t, err)
+
+	err = <-errors
+	assert.False(t, err.(*StatusError).Retryable())
+
+	time.Sleep(100 * time.Millisecond)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+}
+
+func TestBroker_Consume_RetryAfter(t *testing.T) {
+	var calls int32
+	b, pipe, shutdown := newBroker(t, func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+		}
+	}, nil)
+	defer shutdown()
+
+	exec := make(chan jobs.Handler, 1)
+	assert.NoError(t, b.Consume(pipe, exec, func(id string, j *jobs.Job, err error) {}))
+
+	ready, released := make(chan interface{}), make(chan interface{})
+	b.Listen(func(event int, ctx interface{}) {
+		switch event {
+		case jobs.EventBrokerReady:
+			close(ready)
+		case jobs.EventJobRelease:
+			close(released)
+		}
+	})
+
+	go func() { assert.NoError(t, b.Serve()) }()
+	defer b.Stop()
+	<-ready
+
+	start := time.Now()
+	_, err := b.Push(pipe, &jobs.Job{Job: "test", Payload: "body", Options: &jobs.Options{Attempts: 2}})
+	assert.NoError(t, err)
+
+	<-released
+
+	stat, err := b.Stat(pipe)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), stat.Delayed)
+
+	for atomic.LoadInt32(&calls) < 2 {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	assert.True(t, time.Since(start) >= time.Second)
+}
+
+func TestRetryAfter(t *testing.T) {
+	now := time.Now()
+
+	assert.Equal(t, time.Duration(0), retryAfter("", now))
+	assert.Equal(t, time.Duration(0), retryAfter("invalid", now))
+	assert.Equal(t, time.Duration(0), retryAfter("-1", now))
+	assert.Equal(t, 5*time.Second, retryAfter("5", now))
+
+	at := now.Add(time.Minute).UTC().Format(http.TimeFormat)
+	assert.True(t, retryAfter(at, now) > 58*time.Second)
+	assert.Equal(t, time.Duration(0), retryAfter(now.Add(-time.Minute).UTC().Format(http.TimeFormat), now))
+}
@@ -0,0 +1,106 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"github.com/spiral/jobs/v2"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"time"
+)
+
+// endpoint executes pipeline jobs by posting them to the configured url.
+type endpoint struct {
+	pipe *jobs.Pipeline
+
+	// endpoint configuration
+	client  *http.Client
+	url     string
+	method  string
+	headers map[string]string
+	secret  string
+
+	// handlers limit number of parallel requests
+	handlers chan jobs.Handler
+}
+
+// create new endpoint, events of the executed jobs are passed to the listener.
+func newEndpoint(pipe *jobs.Pipeline, lsn func(event int, ctx interface{})) (*endpoint, error) {
+	if pipe.String("url", "") == "" {
+		return nil, fmt.Errorf("missing `url` parameter on http pipeline `%s`", pipe.Name())
+	}
+
+	e := &endpoint{
+		pipe:    pipe,
+		client:  &http.Client{},
+		url:     pipe.String("url", ""),
+		method:  pipe.String("method", http.MethodPost),
+		headers: make(map[string]string),
+		secret:  pipe.String("secret", ""),
+	}
+
+	for k, v := range pipe.Map("headers") {
+		e.headers[k] = fmt.Sprint(v)
+	}
+
+	maxConcur := pipe.Integer("maxThreads", 10)
+	if maxConcur <= 0 {
+		return nil, fmt.Errorf("invalid `maxThreads` parameter on http pipeline `%s`", pipe.Name())
+	}
+
+	e.handlers = make(chan jobs.Handler, maxConcur)
+	for i := 0; i < maxConcur; i++ {
+		e.handlers <- func(id string, j *jobs.Job) error {
+			return jobs.Execute(lsn, id, j, func() error { return e.post(id, j) })
+		}
+	}
+
+	return e, nil
+}
+
+// post job to the endpoint, request is limited by the job timeout.
+func (e *endpoint) post(id string, j *jobs.Job) error {
+	var attempt int
+	if j.Delivery != nil {
+		attempt = j.Delivery.Attempt
+	}
+
+	body, err := pack(id, j, e.pipe.Name(), attempt)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), j.Options.TimeoutDuration())
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, e.method, e.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range e.headers {
+		req.Header.Set(k, v)
+	}
+
+	if e.secret != "" {
+		req.Header.Set("X-Signature", jobs.Sign(e.secret, body))
+	}
+
+	r, err := e.client.Do(req)
+	if err != nil {
+		return err
+	}
+
+	// drain body to reuse the connection
+	io.Copy(ioutil.Discard, r.Body)
+	r.Body.Close()
+
+	if r.StatusCode >= 200 && r.StatusCode < 300 {
+		return nil
+	}
+
+	return &StatusError{Status: r.StatusCode, RetryAfter: retryAfter(r.Header.Get("Retry-After"), time.Now())}
+}
@@ -0,0 +1,78 @@
+package http
+
+import (
+	"fmt"
+	json "github.com/json-iterator/go"
+	"github.com/spiral/jobs/v2"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// request is job representation posted to the endpoint.
+type request struct {
+	// ID is job id.
+	ID string `json:"id"`
+
+	// Job name.
+	Job string `json:"job"`
+
+	// Payload is job payload.
+	Payload string `json:"payload"`
+
+	// Pipeline job has been consumed from.
+	Pipeline string `json:"pipeline"`
+
+	// Attempt number, starting from 0.
+	Attempt int `json:"attempt"`
+}
+
+// pack job into the request body.
+func pack(id string, j *jobs.Job, pipeline string, attempt int) ([]byte, error) {
+	return json.Marshal(&request{ID: id, Job: j.Job, Payload: j.Payload, Pipeline: pipeline, Attempt: attempt})
+}
+
+// StatusError is returned when endpoint does not accept the job.
+type StatusError struct {
+	// Status code of the response.
+	Status int
+
+	// RetryAfter defines delay requested by the endpoint using Retry-After header, 0 when not set.
+	RetryAfter time.Duration
+}
+
+// Error returns error message.
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("endpoint responded with status %v", e.Status)
+}
+
+// Retryable returns true when endpoint is overloaded or failed, all other statuses are permanent failures.
+func (e *StatusError) Retryable() bool {
+	return e.Status == http.StatusTooManyRequests || e.Status >= 500
+}
+
+// Retry returns false for permanent failures and delay requested by the endpoint.
+func (e *StatusError) Retry() (bool, time.Duration) {
+	return e.Retryable(), e.RetryAfter
+}
+
+// retryAfter parses Retry-After header value in seconds or HTTP date format.
+func retryAfter(value string, now time.Time) time.Duration {
+	if value == "" {
+		return 0
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0
+		}
+
+		return time.Duration(seconds) * time.Second
+	}
+
+	if at, err := http.ParseTime(value); err == nil && at.After(now) {
+		return at.Sub(now)
+	}
+
+	return 0
+}
@@ -0,0 +1,221 @@
+package local
+
+import (
+	"fmt"
+	"github.com/gofrs/uuid"
+	"github.com/spiral/jobs/v2"
+	"go.etcd.io/bbolt"
+	"sync"
+)
+
+// Broker runs queues using local goroutines and persists jobs in the embedded database file. Jobs which were
+// in-flight when broker stopped are delivered again on the next start. Delayed jobs of all pipelines are released
+// by single scheduler shared by the broker.
+type Broker struct {
+	cfg     *Config
+	db      *bbolt.DB
+	lsn     func(event int, ctx interface{})
+	mu      sync.Mutex
+	wait    chan error
+	stopped chan interface{}
+	queues  map[*jobs.Pipeline]*queue
+	sched   *scheduler
+}
+
+// Listen attaches server event watcher.
+func (b *Broker) Listen(lsn func(event int, ctx interface{})) {
+	b.lsn = lsn
+}
+
+// Init configures local broker.
+func (b *Broker) Init(cfg *Config) (ok bool, err error) {
+	b.cfg = cfg
+	b.queues = make(map[*jobs.Pipeline]*queue)
+	b.sched = newScheduler()
+
+	return true, nil
+}
+
+// Register broker pipeline.
+func (b *Broker) Register(pipe *jobs.Pipeline) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if _, ok := b.queues[pipe]; ok {
+		return fmt.Errorf("queue `%s` has already been registered", pipe.Name())
+	}
+
+	b.queues[pipe] = newQueue(pipe, b.sched, b.throw)
+
+	return nil
+}
+
+// Serve broker pipelines.
+func (b *Broker) Serve() (err error) {
+	b.mu.Lock()
+
+	b.db, err = b.cfg.open()
+	if err != nil {
+		b.mu.Unlock()
+		return err
+	}
+
+	var opened []*queue
+	for _, q := range b.queues {
+		if err := q.open(b.db); err != nil {
+			for _, q := range opened {
+				q.close()
+			}
+
+			b.db.Close()
+			b.mu.Unlock()
+			return err
+		}
+
+		opened = append(opened, q)
+	}
+
+	b.wait = make(chan error)
+	b.stopped = make(chan interface{})
+	defer close(b.stopped)
+
+	for _, q := range b.queues {
+		if q.execPool != nil {
+			q.start()
+		}
+	}
+
+	b.mu.Unlock()
+
+	b.throw(jobs.EventBrokerReady, b)
+
+	err = <-b.wait
+
+	// database must be released before broker is considered stopped
+	for _, q := range b.queues {
+		q.close()
+	}
+
+	if cerr := b.db.Close(); err == nil {
+		err = cerr
+	}
+
+	return err
+}
+
+// Stop all pipelines.
+func (b *Broker) Stop() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.wait == nil {
+		return
+	}
+
+	// stop all consuming
+	for _, q := range b.queues {
+		q.stop()
+	}
+
+	close(b.wait)
+	<-b.stopped
+}
+
+// Consume configures pipeline to be consumed. With execPool to nil to disable consuming. Method can be called before
+// the service is started!
+func (b *Broker) Consume(pipe *jobs.Pipeline, execPool chan jobs.Handler, errHandler jobs.ErrorHandler) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	q, ok := b.queues[pipe]
+	if !ok {
+		return fmt.Errorf("undefined queue `%s`", pipe.Name())
+	}
+
+	q.stop()
+
+	q.execPool = execPool
+	q.errHandler = errHandler
+
+	if b.wait != nil {
+		if q.execPool != nil {
+			q.start()
+		}
+	}
+
+	return nil
+}
+
+// Push job into the worker.
+func (b *Broker) Push(pipe *jobs.Pipeline, j *jobs.Job) (string, error) {
+	if err := b.isServing(); err != nil {
+		return "", err
+	}
+
+	q := b.queue(pipe)
+	if q == nil {
+		return "", fmt.Errorf("undefined queue `%s`", pipe.Name())
+	}
+
+	id, err := uuid.NewV4()
+	if err != nil {
+		return "", err
+	}
+
+	if err := q.push(id.String(), j, j.Options.DelayDuration()); err != nil {
+		return "", err
+	}
+
+	return id.String(), nil
+}
+
+// Stat must consume statistics about given pipeline or return error.
+func (b *Broker) Stat(pipe *jobs.Pipeline) (stat *jobs.Stat, err error) {
+	if err := b.isServing(); err != nil {
+		return nil, err
+	}
+
+	q := b.queue(pipe)
+	if q == nil {
+		return nil, fmt.Errorf("undefined queue `%s`", pipe.Name())
+	}
+
+	return q.stat(), nil
+}
+
+// Health returns error if broker is not serving.
+func (b *Broker) Health() error {
+	return b.isServing()
+}
+
+// check if broker is serving
+func (b *Broker) isServing() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.wait == nil {
+		return fmt.Errorf("broker is not running")
+	}
+
+	return nil
+}
+
+// queue returns queue associated with the pipeline.
+func (b *Broker) queue(pipe *jobs.Pipeline) *queue {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	q, ok := b.queues[pipe]
+	if !ok {
+		return nil
+	}
+
+	return q
+}
+
+// throw handles service, server and pool events.
+func (b *Broker) throw(event int, ctx interface{}) {
+	if b.lsn != nil {
+		b.lsn(event, ctx)
+	}
+}
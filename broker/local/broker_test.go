@@ -0,0 +1,130 @@
+package local
+
+import (
+	"github.com/spiral/jobs/v2"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var (
+	pipe = &jobs.Pipeline{
+		"broker": "local",
+		"name":   "default",
+	}
+)
+
+// newBroker creates broker storing jobs in the given database file.
+func newBroker(t *testing.T, path string) *Broker {
+	b := &Broker{}
+	if _, err := b.Init(&Config{Path: path, Timeout: 1}); err != nil {
+		t.Fatal(err)
+	}
+
+	return b
+}
+
+// tempDir creates temporary directory for the broker database.
+func tempDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "rr-local")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return dir
+}
+
+// serve broker and wait till it's ready.
+func serve(t *testing.T, b *Broker) {
+	ready := make(chan interface{})
+	b.Listen(func(event int, ctx interface{}) {
+		if event == jobs.EventBrokerReady {
+			close(ready)
+		}
+	})
+
+	go func() { assert.NoError(t, b.Serve()) }()
+	<-ready
+}
+
+func TestBroker_Init(t *testing.T) {
+	b := &Broker{}
+	ok, err := b.Init(&Config{Path: "jobs.db"})
+	assert.True(t, ok)
+	assert.NoError(t, err)
+}
+
+func TestBroker_StopNotStarted(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	b := newBroker(t, filepath.Join(dir, "jobs.db"))
+	b.Stop()
+}
+
+func TestBroker_Register(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	b := newBroker(t, filepath.Join(dir, "jobs.db"))
+	assert.NoError(t, b.Register(pipe))
+}
+
+func TestBroker_Register_Twice(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	b := newBroker(t, filepath.Join(dir, "jobs.db"))
+	assert.NoError(t, b.Register(pipe))
+	assert.Error(t, b.Register(pipe))
+}
+
+func TestBroker_Consume_Undefined(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	b := newBroker(t, filepath.Join(dir, "jobs.db"))
+	assert.Error(t, b.Consume(pipe, nil, nil))
+}
+
+func TestBroker_Serve_Error(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	b := newBroker(t, filepath.Join(dir, "missing", "jobs.db"))
+	assert.NoError(t, b.Register(pipe))
+	assert.Error(t, b.Serve())
+}
+
+func TestBroker_Push_NotServing(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	b := newBroker(t, filepath.Join(dir, "jobs.db"))
+	assert.NoError(t, b.Register(pipe))
+
+	_, err := b.Push(pipe, &jobs.Job{Job: "test", Payload: "body", Options: &jobs.Options{}})
+	assert.Error(t, err)
+	assert.Error(t, b.Health())
+}
+
+func TestBroker_Push_Undefined(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	b := newBroker(t, filepath.Join(dir, "jobs.db"))
+	assert.NoError(t, b.Register(pipe))
+
+	serve(t, b)
+	defer b.Stop()
+
+	_, err := b.Push(&jobs.Pipeline{"name": "other"}, &jobs.Job{Job: "test", Payload: "body", Options: &jobs.Options{}})
+	assert.Error(t, err)
+
+	_, err = b.Stat(&jobs.Pipeline{"name": "other"})
+	assert.Error(t, err)
+
+	assert.NoError(t, b.Health())
+}
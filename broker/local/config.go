@@ -0,0 +1,45 @@
+package local
+
+import (
+	"fmt"
+	"github.com/spiral/roadrunner/service"
+	"go.etcd.io/bbolt"
+	"time"
+)
+
+// Config defines local broker configuration.
+type Config struct {
+	// Path to the database file.
+	Path string
+
+	// Timeout to obtain the database file lock. Default 10 seconds.
+	Timeout int
+}
+
+// Hydrate config values.
+func (c *Config) Hydrate(cfg service.Config) error {
+	if err := cfg.Unmarshal(c); err != nil {
+		return err
+	}
+
+	if c.Path == "" {
+		return fmt.Errorf("local broker path is missing")
+	}
+
+	return nil
+}
+
+// TimeoutDuration returns number of seconds allowed to obtain the database file lock.
+func (c *Config) TimeoutDuration() time.Duration {
+	timeout := c.Timeout
+	if timeout == 0 {
+		timeout = 10
+	}
+
+	return time.Duration(timeout) * time.Second
+}
+
+// open the database file.
+func (c *Config) open() (*bbolt.DB, error) {
+	return bbolt.Open(c.Path, 0600, &bbolt.Options{Timeout: c.TimeoutDuration()})
+}
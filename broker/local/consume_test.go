@@ -0,0 +1,199 @@
+package local
+
+import (
+	"fmt"
+	"github.com/spiral/jobs/v2"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestBroker_Consume_Job(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	b := newBroker(t, filepath.Join(dir, "jobs.db"))
+	assert.NoError(t, b.Register(pipe))
+
+	exec := make(chan jobs.Handler, 1)
+	assert.NoError(t, b.Consume(pipe, exec, func(id string, j *jobs.Job, err error) {}))
+
+	serve(t, b)
+	defer b.Stop()
+
+	jid, perr := b.Push(pipe, &jobs.Job{Job: "test", Payload: "body", Options: &jobs.Options{}})
+	assert.NotEqual(t, "", jid)
+	assert.NoError(t, perr)
+
+	waitJob := make(chan interface{})
+	exec <- func(id string, j *jobs.Job) error {
+		assert.Equal(t, jid, id)
+		assert.Equal(t, "test", j.Job)
+		assert.Equal(t, "body", j.Payload)
+		assert.Equal(t, 0, j.Delivery.Attempt)
+		close(waitJob)
+		return nil
+	}
+
+	<-waitJob
+}
+
+func TestBroker_Consume_Delayed(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	b := newBroker(t, filepath.Join(dir, "jobs.db"))
+	assert.NoError(t, b.Register(pipe))
+
+	exec := make(chan jobs.Handler, 1)
+	assert.NoError(t, b.Consume(pipe, exec, func(id string, j *jobs.Job, err error) {}))
+
+	serve(t, b)
+	defer b.Stop()
+
+	start := time.Now()
+	jid, perr := b.Push(pipe, &jobs.Job{Job: "test", Payload: "body", Options: &jobs.Options{Delay: 1}})
+	assert.NotEqual(t, "", jid)
+	assert.NoError(t, perr)
+
+	waitJob := make(chan interface{})
+	exec <- func(id string, j *jobs.Job) error {
+		assert.Equal(t, jid, id)
+		close(waitJob)
+		return nil
+	}
+
+	<-waitJob
+	assert.True(t, time.Since(start) >= time.Second)
+}
+
+func TestBroker_Consume_Errored_Attempts(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	b := newBroker(t, filepath.Join(dir, "jobs.db"))
+	assert.NoError(t, b.Register(pipe))
+
+	var attempts int32
+	errHandled := make(chan interface{}, 3)
+	errHandler := func(id string, j *jobs.Job, err error) {
+		assert.Equal(t, "job failed", err.Error())
+		errHandled <- nil
+	}
+
+	exec := make(chan jobs.Handler, 1)
+	assert.NoError(t, b.Consume(pipe, exec, errHandler))
+
+	serve(t, b)
+	defer b.Stop()
+
+	jid, perr := b.Push(pipe, &jobs.Job{Job: "test", Payload: "body", Options: &jobs.Options{Attempts: 3}})
+	assert.NotEqual(t, "", jid)
+	assert.NoError(t, perr)
+
+	exec <- func(id string, j *jobs.Job) error {
+		assert.Equal(t, jid, id)
+		assert.Equal(t, int(atomic.AddInt32(&attempts, 1))-1, j.Delivery.Attempt)
+		return fmt.Errorf("job failed")
+	}
+
+	<-errHandled
+	<-errHandled
+	<-errHandled
+	time.Sleep(10 * time.Millisecond)
+
+	assert.Equal(t, int32(3), atomic.LoadInt32(&attempts))
+
+	stat, err := b.Stat(pipe)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), stat.Queue)
+	assert.Equal(t, int64(0), stat.Delayed)
+}
+
+// noRetry error forbids retrying the job.
+type noRetry struct{}
+
+func (noRetry) Error() string { return "job failed" }
+
+func (noRetry) Retry() (bool, time.Duration) { return false, 0 }
+
+func TestBroker_Consume_RetryPolicy(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	b := newBroker(t, filepath.Join(dir, "jobs.db"))
+	assert.NoError(t, b.Register(pipe))
+
+	errHandled := make(chan interface{}, 1)
+	exec := make(chan jobs.Handler, 1)
+	assert.NoError(t, b.Consume(pipe, exec, func(id string, j *jobs.Job, err error) { errHandled <- nil }))
+
+	serve(t, b)
+	defer b.Stop()
+
+	_, perr := b.Push(pipe, &jobs.Job{Job: "test", Payload: "body", Options: &jobs.Options{Attempts: 3}})
+	assert.NoError(t, perr)
+
+	var attempts int32
+	exec <- func(id string, j *jobs.Job) error {
+		atomic.AddInt32(&attempts, 1)
+		return noRetry{}
+	}
+
+	<-errHandled
+	time.Sleep(10 * time.Millisecond)
+
+	// error policy overrides job attempts
+	assert.Equal(t, int32(1), atomic.LoadInt32(&attempts))
+
+	stat, err := b.Stat(pipe)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), stat.Queue)
+	assert.Equal(t, int64(0), stat.Delayed)
+}
+
+func TestBroker_Consume_MaxThreads(t *testing.T) {
+	p := &jobs.Pipeline{"broker": "local", "name": "limited", "maxThreads": 1}
+
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	b := newBroker(t, filepath.Join(dir, "jobs.db"))
+	assert.NoError(t, b.Register(p))
+
+	exec := make(chan jobs.Handler, 2)
+	assert.NoError(t, b.Consume(p, exec, func(id string, j *jobs.Job, err error) {}))
+
+	serve(t, b)
+	defer b.Stop()
+
+	var active, peak int32
+	done := make(chan interface{}, 2)
+	h := func(id string, j *jobs.Job) error {
+		n := atomic.AddInt32(&active, 1)
+		if n > atomic.LoadInt32(&peak) {
+			atomic.StoreInt32(&peak, n)
+		}
+
+		time.Sleep(50 * time.Millisecond)
+		atomic.AddInt32(&active, -1)
+
+		done <- nil
+		return nil
+	}
+	exec <- h
+	exec <- h
+
+	for i := 0; i < 2; i++ {
+		_, err := b.Push(p, &jobs.Job{Job: "test", Payload: "body", Options: &jobs.Options{}})
+		assert.NoError(t, err)
+	}
+
+	<-done
+	<-done
+
+	assert.Equal(t, int32(1), atomic.LoadInt32(&peak))
+}
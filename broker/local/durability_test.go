@@ -0,0 +1,130 @@
+package local
+
+import (
+	"github.com/spiral/jobs/v2"
+	"github.com/stretchr/testify/assert"
+	"go.etcd.io/bbolt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestBroker_Durability_Restart(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "jobs.db")
+
+	b := newBroker(t, path)
+	assert.NoError(t, b.Register(pipe))
+	serve(t, b)
+
+	jid, err := b.Push(pipe, &jobs.Job{Job: "test", Payload: "body", Options: &jobs.Options{}})
+	assert.NoError(t, err)
+
+	did, err := b.Push(pipe, &jobs.Job{Job: "test", Payload: "delayed", Options: &jobs.Options{Delay: 1}})
+	assert.NoError(t, err)
+
+	b.Stop()
+
+	b = newBroker(t, path)
+	assert.NoError(t, b.Register(pipe))
+
+	exec := make(chan jobs.Handler, 1)
+	assert.NoError(t, b.Consume(pipe, exec, func(id string, j *jobs.Job, err error) {}))
+
+	serve(t, b)
+	defer b.Stop()
+
+	consumed := make(chan string, 2)
+	exec <- func(id string, j *jobs.Job) error {
+		consumed <- id
+		return nil
+	}
+
+	assert.Equal(t, jid, <-consumed)
+	assert.Equal(t, did, <-consumed)
+}
+
+func TestBroker_Durability_InFlight(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	b := newBroker(t, filepath.Join(dir, "jobs.db"))
+	assert.NoError(t, b.Register(pipe))
+
+	exec := make(chan jobs.Handler, 1)
+	assert.NoError(t, b.Consume(pipe, exec, func(id string, j *jobs.Job, err error) {}))
+
+	serve(t, b)
+	defer b.Stop()
+
+	jid, err := b.Push(pipe, &jobs.Job{Job: "test", Payload: "body", Options: &jobs.Options{Attempts: 3}})
+	assert.NoError(t, err)
+
+	// crash is emulated by taking the database snapshot while job is in-flight
+	crashed := filepath.Join(dir, "crashed.db")
+	exec <- func(id string, j *jobs.Job) error {
+		return b.db.View(func(tx *bbolt.Tx) error {
+			return tx.CopyFile(crashed, 0600)
+		})
+	}
+
+	time.Sleep(50 * time.Millisecond)
+
+	r := newBroker(t, crashed)
+	assert.NoError(t, r.Register(pipe))
+
+	rexec := make(chan jobs.Handler, 1)
+	assert.NoError(t, r.Consume(pipe, rexec, func(id string, j *jobs.Job, err error) {}))
+
+	serve(t, r)
+	defer r.Stop()
+
+	recovered := make(chan *jobs.Job)
+	rexec <- func(id string, j *jobs.Job) error {
+		assert.Equal(t, jid, id)
+		recovered <- j
+		return nil
+	}
+
+	j := <-recovered
+	assert.Equal(t, "body", j.Payload)
+	assert.Equal(t, 3, j.Options.Attempts)
+	assert.Equal(t, 0, j.Delivery.Attempt)
+}
+
+func TestBroker_Durability_Backlog(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "jobs.db")
+
+	b := newBroker(t, path)
+	assert.NoError(t, b.Register(pipe))
+	serve(t, b)
+
+	for i := 0; i < 1000; i++ {
+		_, err := b.Push(pipe, &jobs.Job{Job: "test", Payload: "body", Options: &jobs.Options{Delay: i % 2 * 60}})
+		assert.NoError(t, err)
+	}
+
+	b.Stop()
+
+	before := runtime.NumGoroutine()
+
+	// stored jobs are restored without goroutine per job
+	b = newBroker(t, path)
+	assert.NoError(t, b.Register(pipe))
+	serve(t, b)
+	defer b.Stop()
+
+	assert.True(t, runtime.NumGoroutine()-before < 10)
+
+	stat, err := b.Stat(pipe)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(500), stat.Queue)
+	assert.Equal(t, int64(500), stat.Delayed)
+}
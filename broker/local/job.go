@@ -0,0 +1,68 @@
+package local
+
+import (
+	"encoding/binary"
+	json "github.com/json-iterator/go"
+	"github.com/spiral/jobs/v2"
+	"time"
+)
+
+// record is stored job representation.
+type record struct {
+	// ID is job id.
+	ID string `json:"id"`
+
+	// Job name.
+	Job string `json:"job"`
+
+	// Payload is job payload.
+	Payload string `json:"payload"`
+
+	// Options contains job options.
+	Options *jobs.Options `json:"options"`
+
+	// Attempt number, starting from 0.
+	Attempt int `json:"attempt"`
+
+	// Queued defines when job becomes available (unix nano).
+	Queued int64 `json:"queued"`
+}
+
+// pack entry into the record.
+func pack(e *entry) ([]byte, error) {
+	return json.Marshal(&record{
+		ID:      e.id,
+		Job:     e.job.Job,
+		Payload: e.job.Payload,
+		Options: e.job.Options,
+		Attempt: e.attempt,
+		Queued:  e.queued.UnixNano(),
+	})
+}
+
+// unpack entry from the record.
+func unpack(key, data []byte) (*entry, error) {
+	r := &record{}
+	if err := json.Unmarshal(data, r); err != nil {
+		return nil, err
+	}
+
+	if r.Options == nil {
+		r.Options = &jobs.Options{}
+	}
+
+	return &entry{
+		key:     binary.BigEndian.Uint64(key),
+		id:      r.ID,
+		job:     &jobs.Job{Job: r.Job, Payload: r.Payload, Options: r.Options},
+		attempt: r.Attempt,
+		queued:  time.Unix(0, r.Queued),
+	}, nil
+}
+
+// itob returns record key.
+func itob(v uint64) []byte {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, v)
+	return b
+}
@@ -0,0 +1,370 @@
+package local
+
+import (
+	"container/list"
+	"github.com/spiral/jobs/v2"
+	"go.etcd.io/bbolt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+type queue struct {
+	on     int32
+	pipe   *jobs.Pipeline
+	bucket []byte
+	state  *jobs.Stat
+	sched  *scheduler
+
+	// database, set while broker is serving
+	db *bbolt.DB
+
+	// concurrency limit
+	concurPool chan interface{}
+
+	// entries waiting to be consumed and delayed entries, entries are only kept while database is open
+	mup     sync.Mutex
+	opened  bool
+	ready   *list.List
+	delayed map[*entry]bool
+	notify  chan interface{}
+
+	// on operations
+	muw sync.Mutex
+	wg  sync.WaitGroup
+
+	// stop channel
+	wait chan interface{}
+
+	// queue events
+	lsn func(event int, ctx interface{})
+
+	// exec handlers
+	execPool   chan jobs.Handler
+	errHandler jobs.ErrorHandler
+}
+
+type entry struct {
+	key     uint64
+	id      string
+	job     *jobs.Job
+	attempt int
+	queued  time.Time
+
+	// queue entry belongs to and it's position in scheduler heap, -1 when entry is not scheduled
+	queue *queue
+	index int
+}
+
+// create new queue
+func newQueue(pipe *jobs.Pipeline, sched *scheduler, lsn func(event int, ctx interface{})) *queue {
+	q := &queue{
+		pipe:    pipe,
+		bucket:  []byte(pipe.String("bucket", pipe.Name())),
+		state:   &jobs.Stat{},
+		sched:   sched,
+		ready:   list.New(),
+		delayed: make(map[*entry]bool),
+		notify:  make(chan interface{}, 1),
+		lsn:     lsn,
+	}
+
+	maxConcur := pipe.Integer("maxThreads", 0)
+
+	if maxConcur != 0 {
+		q.concurPool = make(chan interface{}, maxConcur)
+		for i := 0; i < maxConcur; i++ {
+			q.concurPool <- nil
+		}
+	}
+
+	return q
+}
+
+// open attaches queue to the database and schedules all stored jobs, including jobs which were in-flight when
+// the database was closed.
+func (q *queue) open(db *bbolt.DB) error {
+	var entries []*entry
+	err := db.Update(func(tx *bbolt.Tx) error {
+		b, err := tx.CreateBucketIfNotExists(q.bucket)
+		if err != nil {
+			return err
+		}
+
+		return b.ForEach(func(k, v []byte) error {
+			e, err := unpack(k, v)
+			if err != nil {
+				q.report(err)
+
+				// broken records are never delivered
+				return b.Delete(k)
+			}
+
+			entries = append(entries, e)
+			return nil
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	q.db = db
+
+	q.mup.Lock()
+	defer q.mup.Unlock()
+
+	q.opened = true
+	atomic.StoreInt64(&q.state.Queue, 0)
+	atomic.StoreInt64(&q.state.Delayed, 0)
+
+	for _, e := range entries {
+		q.add(e)
+	}
+
+	return nil
+}
+
+// close detaches queue from the database, pending entries are dropped from memory to be recovered once database
+// is opened again.
+func (q *queue) close() {
+	q.mup.Lock()
+	defer q.mup.Unlock()
+
+	q.opened = false
+	q.ready.Init()
+
+	for e := range q.delayed {
+		q.sched.cancel(e)
+	}
+	q.delayed = make(map[*entry]bool)
+}
+
+// start consuming in background, queue is marked as consumed before the method returns so it can be stopped
+// right away.
+func (q *queue) start() {
+	q.wait = make(chan interface{})
+	atomic.StoreInt32(&q.on, 1)
+
+	go q.serve(q.wait)
+}
+
+// serve consumers till given channel is closed.
+func (q *queue) serve(wait chan interface{}) {
+	for {
+		e := q.consume(wait)
+		if e == nil {
+			q.wg.Wait()
+			return
+		}
+
+		if q.concurPool != nil {
+			<-q.concurPool
+		}
+
+		atomic.AddInt64(&q.state.Active, 1)
+		h := <-q.execPool
+
+		go func(h jobs.Handler, e *entry) {
+			defer q.wg.Done()
+
+			q.report(q.do(h, e))
+			atomic.AddInt64(&q.state.Active, ^int64(0))
+
+			q.execPool <- h
+
+			if q.concurPool != nil {
+				q.concurPool <- nil
+			}
+		}(h, e)
+	}
+}
+
+// allocate one job entry
+func (q *queue) consume(wait chan interface{}) *entry {
+	q.muw.Lock()
+	defer q.muw.Unlock()
+
+	for {
+		select {
+		case <-wait:
+			return nil
+		default:
+		}
+
+		q.mup.Lock()
+		if front := q.ready.Front(); front != nil {
+			e := q.ready.Remove(front).(*entry)
+			q.mup.Unlock()
+
+			q.wg.Add(1)
+			return e
+		}
+		q.mup.Unlock()
+
+		select {
+		case <-wait:
+			return nil
+		case <-q.notify:
+		}
+	}
+}
+
+// do singe job
+func (q *queue) do(h jobs.Handler, e *entry) error {
+	e.job.Delivery = &jobs.Delivery{Pipeline: q.pipe.Name(), Attempt: e.attempt, Queued: e.queued}
+	err := h(e.id, e.job)
+	atomic.AddInt64(&q.state.Queue, ^int64(0))
+
+	if err == nil {
+		return q.remove(e)
+	}
+
+	if err == jobs.ErrRelease {
+		q.schedule(e)
+		return nil
+	}
+
+	q.errHandler(e.id, e.job, err)
+
+	retry, delay := jobs.NextRetry(e.job, e.attempt, err)
+	if !retry {
+		return q.remove(e)
+	}
+
+	e.attempt++
+	e.queued = time.Now().Add(delay)
+	if err := q.store(e); err != nil {
+		return err
+	}
+
+	q.schedule(e)
+	q.lsn(jobs.EventJobRelease, &jobs.JobEvent{ID: e.id, Job: e.job, Pipeline: q.pipe.Name(), Attempt: e.attempt})
+
+	return nil
+}
+
+// stop the queue consuming
+func (q *queue) stop() {
+	if atomic.LoadInt32(&q.on) == 0 {
+		return
+	}
+
+	close(q.wait)
+
+	q.muw.Lock()
+	q.wg.Wait()
+	q.muw.Unlock()
+
+	atomic.StoreInt32(&q.on, 0)
+}
+
+// push persists new job and schedules it's delivery.
+func (q *queue) push(id string, j *jobs.Job, delay time.Duration) error {
+	e := &entry{id: id, job: j, queued: time.Now().Add(delay)}
+	if err := q.store(e); err != nil {
+		return err
+	}
+
+	q.schedule(e)
+
+	return nil
+}
+
+// schedule entry delivery once it's available.
+func (q *queue) schedule(e *entry) {
+	q.mup.Lock()
+	defer q.mup.Unlock()
+
+	q.add(e)
+}
+
+// add entry to the ready list or to the scheduler when entry is delayed, entries are ignored while database is
+// closed. Must be called under mup lock.
+func (q *queue) add(e *entry) {
+	if !q.opened {
+		return
+	}
+
+	e.queue, e.index = q, -1
+
+	if !e.queued.After(time.Now()) {
+		q.release(e)
+		return
+	}
+
+	q.delayed[e] = true
+	atomic.AddInt64(&q.state.Delayed, 1)
+	q.sched.schedule(e)
+}
+
+// wake releases due delayed entry, called by the scheduler.
+func (q *queue) wake(e *entry) {
+	q.mup.Lock()
+	defer q.mup.Unlock()
+
+	if !q.delayed[e] {
+		// database has been closed while entry was released
+		return
+	}
+
+	delete(q.delayed, e)
+	atomic.AddInt64(&q.state.Delayed, ^int64(0))
+	q.release(e)
+}
+
+// release makes entry available to consumers, must be called under mup lock.
+func (q *queue) release(e *entry) {
+	q.ready.PushBack(e)
+	atomic.AddInt64(&q.state.Queue, 1)
+
+	select {
+	case q.notify <- nil:
+	default:
+	}
+}
+
+// store writes the entry record, new entries are assigned with the sequential key.
+func (q *queue) store(e *entry) error {
+	return q.db.Update(func(tx *bbolt.Tx) error {
+		b := tx.Bucket(q.bucket)
+
+		if e.key == 0 {
+			key, err := b.NextSequence()
+			if err != nil {
+				return err
+			}
+
+			e.key = key
+		}
+
+		data, err := pack(e)
+		if err != nil {
+			return err
+		}
+
+		return b.Put(itob(e.key), data)
+	})
+}
+
+// remove the entry record.
+func (q *queue) remove(e *entry) error {
+	return q.db.Update(func(tx *bbolt.Tx) error {
+		return tx.Bucket(q.bucket).Delete(itob(e.key))
+	})
+}
+
+// report queue specific error
+func (q *queue) report(err error) {
+	if err != nil {
+		q.lsn(jobs.EventPipeError, &jobs.PipelineError{Pipeline: q.pipe, Caused: err})
+	}
+}
+
+func (q *queue) stat() *jobs.Stat {
+	return &jobs.Stat{
+		InternalName: string(q.bucket),
+		Queue:        atomic.LoadInt64(&q.state.Queue),
+		Active:       atomic.LoadInt64(&q.state.Active),
+		Delayed:      atomic.LoadInt64(&q.state.Delayed),
+	}
+}
@@ -0,0 +1,125 @@
+package local
+
+import (
+	"container/heap"
+	"sync"
+	"time"
+)
+
+// scheduler releases delayed entries of all broker queues once they are due. Entries are kept in min-heap ordered
+// by their availability time, single timer is armed for the earliest entry.
+type scheduler struct {
+	mu      sync.Mutex
+	entries delayHeap
+	timer   *time.Timer
+}
+
+// newScheduler creates new scheduler.
+func newScheduler() *scheduler {
+	return &scheduler{}
+}
+
+// schedule entry to be released into it's queue once it's due.
+func (s *scheduler) schedule(e *entry) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	heap.Push(&s.entries, e)
+	if e.index == 0 {
+		s.arm()
+	}
+}
+
+// cancel scheduled entry, entries which are not scheduled are ignored.
+func (s *scheduler) cancel(e *entry) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if e.index < 0 {
+		return
+	}
+
+	head := e.index == 0
+	heap.Remove(&s.entries, e.index)
+
+	if head {
+		s.arm()
+	}
+}
+
+// len returns number of scheduled entries.
+func (s *scheduler) len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return len(s.entries)
+}
+
+// fire releases all due entries and re-arms the timer.
+func (s *scheduler) fire() {
+	s.mu.Lock()
+
+	now := time.Now()
+
+	var due []*entry
+	for len(s.entries) != 0 && !s.entries[0].queued.After(now) {
+		due = append(due, heap.Pop(&s.entries).(*entry))
+	}
+
+	s.arm()
+	s.mu.Unlock()
+
+	for _, e := range due {
+		e.queue.wake(e)
+	}
+}
+
+// arm the timer for the earliest entry, must be called under mu lock.
+func (s *scheduler) arm() {
+	if len(s.entries) == 0 {
+		if s.timer != nil {
+			s.timer.Stop()
+		}
+
+		return
+	}
+
+	delay := time.Until(s.entries[0].queued)
+	if s.timer == nil {
+		s.timer = time.AfterFunc(delay, s.fire)
+		return
+	}
+
+	s.timer.Reset(delay)
+}
+
+// delayHeap implements heap.Interface ordering entries by their availability time.
+type delayHeap []*entry
+
+func (h delayHeap) Len() int { return len(h) }
+
+func (h delayHeap) Less(i, j int) bool { return h[i].queued.Before(h[j].queued) }
+
+func (h delayHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+
+func (h *delayHeap) Push(x interface{}) {
+	e := x.(*entry)
+	e.index = len(*h)
+	*h = append(*h, e)
+}
+
+func (h *delayHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+
+	e := old[n-1]
+	old[n-1] = nil
+	e.index = -1
+	*h = old[:n-1]
+
+	return e
+}
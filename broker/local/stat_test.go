@@ -0,0 +1,59 @@
+package local
+
+import (
+	"github.com/spiral/jobs/v2"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestBroker_Stat(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	b := newBroker(t, filepath.Join(dir, "jobs.db"))
+	assert.NoError(t, b.Register(pipe))
+
+	serve(t, b)
+	defer b.Stop()
+
+	jid, perr := b.Push(pipe, &jobs.Job{Job: "test", Payload: "body", Options: &jobs.Options{}})
+	assert.NotEqual(t, "", jid)
+	assert.NoError(t, perr)
+
+	_, perr = b.Push(pipe, &jobs.Job{Job: "test", Payload: "body", Options: &jobs.Options{Delay: 10}})
+	assert.NoError(t, perr)
+
+	stat, err := b.Stat(pipe)
+	assert.NoError(t, err)
+	assert.Equal(t, "default", stat.InternalName)
+	assert.Equal(t, int64(1), stat.Queue)
+	assert.Equal(t, int64(1), stat.Delayed)
+	assert.Equal(t, int64(0), stat.Active)
+
+	exec := make(chan jobs.Handler, 1)
+	assert.NoError(t, b.Consume(pipe, exec, func(id string, j *jobs.Job, err error) {}))
+
+	waitJob := make(chan interface{})
+	exec <- func(id string, j *jobs.Job) error {
+		assert.Equal(t, jid, id)
+
+		stat, err := b.Stat(pipe)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), stat.Active)
+
+		close(waitJob)
+		return nil
+	}
+
+	<-waitJob
+	time.Sleep(10 * time.Millisecond)
+
+	stat, err = b.Stat(pipe)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), stat.Queue)
+	assert.Equal(t, int64(0), stat.Active)
+	assert.Equal(t, int64(1), stat.Delayed)
+}
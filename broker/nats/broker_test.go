@@ -0,0 +1,196 @@
+package nats
+
+import (
+	"github.com/nats-io/nats-server/v2/server"
+	"github.com/spiral/jobs/v2"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+)
+
+var (
+	pipe = &jobs.Pipeline{
+		"broker":  "nats",
+		"name":    "default",
+		"stream":  "rr-stream",
+		"ackwait": 1,
+	}
+)
+
+// natsServer is embedded JetStream server.
+type natsServer struct {
+	*server.Server
+	dir string
+}
+
+// Close stops the server and removes it's storage.
+func (s *natsServer) Close() {
+	s.Shutdown()
+	s.WaitForShutdown()
+	os.RemoveAll(s.dir)
+}
+
+// newBroker creates broker connected to embedded NATS server.
+func newBroker(t *testing.T) (*Broker, *natsServer) {
+	dir, err := ioutil.TempDir("", "rr-nats")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	srv, err := server.NewServer(&server.Options{Host: "127.0.0.1", Port: -1, JetStream: true, StoreDir: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	go srv.Start()
+	if !srv.ReadyForConnections(5 * time.Second) {
+		t.Fatal("nats server is not ready")
+	}
+
+	b := &Broker{}
+	if _, err := b.Init(&Config{Addr: srv.ClientURL()}); err != nil {
+		t.Fatal(err)
+	}
+
+	return b, &natsServer{Server: srv, dir: dir}
+}
+
+// serve broker and wait till it's ready.
+func serve(t *testing.T, b *Broker) {
+	ready := make(chan interface{})
+	b.Listen(func(event int, ctx interface{}) {
+		if event == jobs.EventBrokerReady {
+			close(ready)
+		}
+	})
+
+	go func() { assert.NoError(t, b.Serve()) }()
+	<-ready
+}
+
+func TestBroker_Init(t *testing.T) {
+	b := &Broker{}
+	ok, err := b.Init(&Config{Addr: "nats://localhost:4222"})
+	assert.True(t, ok)
+	assert.NoError(t, err)
+}
+
+func TestBroker_StopNotStarted(t *testing.T) {
+	b, srv := newBroker(t)
+	defer srv.Close()
+
+	b.Stop()
+}
+
+func TestBroker_Register(t *testing.T) {
+	b, srv := newBroker(t)
+	defer srv.Close()
+
+	assert.NoError(t, b.Register(pipe))
+}
+
+func TestBroker_RegisterInvalid(t *testing.T) {
+	b, srv := newBroker(t)
+	defer srv.Close()
+
+	assert.Error(t, b.Register(&jobs.Pipeline{
+		"broker": "nats",
+		"name":   "default",
+	}))
+}
+
+func TestBroker_Register_Twice(t *testing.T) {
+	b, srv := newBroker(t)
+	defer srv.Close()
+
+	assert.NoError(t, b.Register(pipe))
+	assert.Error(t, b.Register(pipe))
+}
+
+func TestBroker_Serve_Error(t *testing.T) {
+	b, srv := newBroker(t)
+	srv.Close()
+
+	assert.NoError(t, b.Register(pipe))
+	assert.Error(t, b.Serve())
+}
+
+func TestBroker_Serve_Declare(t *testing.T) {
+	b, srv := newBroker(t)
+	defer srv.Close()
+
+	assert.NoError(t, b.Register(pipe))
+
+	serve(t, b)
+	defer b.Stop()
+
+	info, err := b.js.ConsumerInfo("rr-stream", "rr-jobs")
+	assert.NoError(t, err)
+	assert.Equal(t, time.Second, info.Config.AckWait)
+}
+
+func TestBroker_Consume_Undefined(t *testing.T) {
+	b, srv := newBroker(t)
+	defer srv.Close()
+
+	assert.Error(t, b.Consume(pipe, nil, nil))
+}
+
+func TestBroker_Consume_Serve_Stop(t *testing.T) {
+	b, srv := newBroker(t)
+	defer srv.Close()
+
+	assert.NoError(t, b.Register(pipe))
+
+	exec := make(chan jobs.Handler)
+	assert.NoError(t, b.Consume(pipe, exec, func(id string, j *jobs.Job, err error) {}))
+
+	serve(t, b)
+	time.Sleep(100 * time.Millisecond)
+	b.Stop()
+}
+
+func TestBroker_PushToNotRunning(t *testing.T) {
+	b, srv := newBroker(t)
+	defer srv.Close()
+
+	assert.NoError(t, b.Register(pipe))
+
+	_, err := b.Push(pipe, &jobs.Job{})
+	assert.Error(t, err)
+}
+
+func TestBroker_StatNotRunning(t *testing.T) {
+	b, srv := newBroker(t)
+	defer srv.Close()
+
+	assert.NoError(t, b.Register(pipe))
+
+	_, err := b.Stat(pipe)
+	assert.Error(t, err)
+}
+
+func TestBroker_PushToNotRegistered(t *testing.T) {
+	b, srv := newBroker(t)
+	defer srv.Close()
+
+	serve(t, b)
+	defer b.Stop()
+
+	_, err := b.Push(pipe, &jobs.Job{})
+	assert.Error(t, err)
+}
+
+func TestBroker_Health(t *testing.T) {
+	b, srv := newBroker(t)
+	defer srv.Close()
+
+	assert.Error(t, b.Health())
+
+	serve(t, b)
+	defer b.Stop()
+
+	assert.NoError(t, b.Health())
+}
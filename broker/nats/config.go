@@ -0,0 +1,45 @@
+package nats
+
+import (
+	"fmt"
+	"github.com/nats-io/nats.go"
+	"github.com/spiral/roadrunner/service"
+	"time"
+)
+
+// Config defines NATS JetStream broker configuration.
+type Config struct {
+	// Addr of NATS server.
+	Addr string
+
+	// Timeout to allocate the connection. Default 10 seconds.
+	Timeout int
+}
+
+// Hydrate config values.
+func (c *Config) Hydrate(cfg service.Config) error {
+	if err := cfg.Unmarshal(c); err != nil {
+		return err
+	}
+
+	if c.Addr == "" {
+		return fmt.Errorf("nats address is missing")
+	}
+
+	return nil
+}
+
+// TimeoutDuration returns number of seconds allowed to allocate the connection.
+func (c *Config) TimeoutDuration() time.Duration {
+	timeout := c.Timeout
+	if timeout == 0 {
+		timeout = 10
+	}
+
+	return time.Duration(timeout) * time.Second
+}
+
+// newConn connects to NATS server, connection is restored automatically.
+func (c *Config) newConn() (*nats.Conn, error) {
+	return nats.Connect(c.Addr, nats.Timeout(c.TimeoutDuration()), nats.MaxReconnects(-1))
+}
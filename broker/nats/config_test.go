@@ -0,0 +1,51 @@
+package nats
+
+import (
+	json "github.com/json-iterator/go"
+	"github.com/spiral/roadrunner/service"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+type mockCfg struct{ cfg string }
+
+func (cfg *mockCfg) Get(name string) service.Config  { return nil }
+func (cfg *mockCfg) Unmarshal(out interface{}) error { return json.Unmarshal([]byte(cfg.cfg), out) }
+
+func Test_Config_Hydrate_Error(t *testing.T) {
+	cfg := &mockCfg{`{"dead`}
+	c := &Config{}
+
+	assert.Error(t, c.Hydrate(cfg))
+}
+
+func Test_Config_Hydrate_Error2(t *testing.T) {
+	cfg := &mockCfg{`{}`}
+	c := &Config{}
+
+	assert.Error(t, c.Hydrate(cfg))
+}
+
+func Test_Config_Hydrate(t *testing.T) {
+	cfg := &mockCfg{`{"addr":"nats://localhost:4222"}`}
+	c := &Config{}
+
+	assert.NoError(t, c.Hydrate(cfg))
+}
+
+func Test_Config_TimeoutDuration(t *testing.T) {
+	cfg := &mockCfg{`{"addr":"nats://localhost:4222"}`}
+	c := &Config{}
+
+	assert.NoError(t, c.Hydrate(cfg))
+	assert.Equal(t, time.Second*10, c.TimeoutDuration())
+}
+
+func Test_Config_TimeoutDurationCustom(t *testing.T) {
+	cfg := &mockCfg{`{"addr":"nats://localhost:4222","timeout":1}`}
+	c := &Config{}
+
+	assert.NoError(t, c.Hydrate(cfg))
+	assert.Equal(t, time.Second*1, c.TimeoutDuration())
+}
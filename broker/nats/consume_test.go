@@ -0,0 +1,248 @@
+package nats
+
+import (
+	"fmt"
+	"github.com/spiral/jobs/v2"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestBroker_Consume_Job(t *testing.T) {
+	b, srv := newBroker(t)
+	defer srv.Close()
+
+	assert.NoError(t, b.Register(pipe))
+
+	exec := make(chan jobs.Handler, 1)
+	assert.NoError(t, b.Consume(pipe, exec, func(id string, j *jobs.Job, err error) {}))
+
+	serve(t, b)
+	defer b.Stop()
+
+	jid, perr := b.Push(pipe, &jobs.Job{
+		Job:     "test",
+		Payload: "body",
+		Options: &jobs.Options{},
+	})
+
+	assert.NotEqual(t, "", jid)
+	assert.NoError(t, perr)
+
+	waitJob := make(chan interface{})
+	exec <- func(id string, j *jobs.Job) error {
+		assert.Equal(t, jid, id)
+		assert.Equal(t, "test", j.Job)
+		assert.Equal(t, "body", j.Payload)
+		assert.Equal(t, 0, j.Delivery.Attempt)
+		assert.Equal(t, "default", j.Delivery.Pipeline)
+		close(waitJob)
+		return nil
+	}
+
+	<-waitJob
+
+	// acknowledged messages are removed
+	time.Sleep(100 * time.Millisecond)
+	assert.NoError(t, b.Consume(pipe, nil, nil))
+
+	stat, err := b.Stat(pipe)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), stat.Queue+stat.Active)
+}
+
+func TestBroker_Consume_PushedBeforeServe(t *testing.T) {
+	b, srv := newBroker(t)
+	defer srv.Close()
+
+	assert.NoError(t, b.Register(pipe))
+
+	serve(t, b)
+	defer b.Stop()
+
+	jid, perr := b.Push(pipe, &jobs.Job{Job: "test", Payload: "body", Options: &jobs.Options{}})
+	assert.NoError(t, perr)
+
+	exec := make(chan jobs.Handler, 1)
+	assert.NoError(t, b.Consume(pipe, exec, func(id string, j *jobs.Job, err error) {}))
+
+	waitJob := make(chan interface{})
+	exec <- func(id string, j *jobs.Job) error {
+		assert.Equal(t, jid, id)
+		close(waitJob)
+		return nil
+	}
+
+	<-waitJob
+}
+
+func TestBroker_Consume_Delayed(t *testing.T) {
+	b, srv := newBroker(t)
+	defer srv.Close()
+
+	assert.NoError(t, b.Register(pipe))
+
+	exec := make(chan jobs.Handler, 1)
+	assert.NoError(t, b.Consume(pipe, exec, func(id string, j *jobs.Job, err error) {}))
+
+	serve(t, b)
+	defer b.Stop()
+
+	start := time.Now()
+	jid, perr := b.Push(pipe, &jobs.Job{
+		Job:     "test",
+		Payload: "body",
+		Options: &jobs.Options{Delay: 1},
+	})
+
+	assert.NotEqual(t, "", jid)
+	assert.NoError(t, perr)
+
+	waitJob := make(chan interface{})
+	exec <- func(id string, j *jobs.Job) error {
+		assert.Equal(t, jid, id)
+		assert.False(t, j.Delivery.Queued.Before(start.Add(time.Second)))
+		close(waitJob)
+		return nil
+	}
+
+	<-waitJob
+	assert.True(t, time.Since(start) >= time.Second)
+}
+
+func TestBroker_Consume_Errored_Attempts(t *testing.T) {
+	b, srv := newBroker(t)
+	defer srv.Close()
+
+	assert.NoError(t, b.Register(pipe))
+
+	attempts := make(chan int, 3)
+	errHandler := func(id string, j *jobs.Job, err error) {
+		assert.Equal(t, "job failed", err.Error())
+	}
+
+	exec := make(chan jobs.Handler, 1)
+	assert.NoError(t, b.Consume(pipe, exec, errHandler))
+
+	serve(t, b)
+	defer b.Stop()
+
+	jid, perr := b.Push(pipe, &jobs.Job{
+		Job:     "test",
+		Payload: "body",
+		Options: &jobs.Options{Attempts: 3},
+	})
+	assert.NoError(t, perr)
+
+	exec <- func(id string, j *jobs.Job) error {
+		assert.Equal(t, jid, id)
+		attempts <- j.Delivery.Attempt
+		return fmt.Errorf("job failed")
+	}
+
+	assert.Equal(t, 0, <-attempts)
+	assert.Equal(t, 1, <-attempts)
+	assert.Equal(t, 2, <-attempts)
+
+	// failed job is removed after the last attempt
+	time.Sleep(100 * time.Millisecond)
+	assert.NoError(t, b.Consume(pipe, nil, nil))
+
+	stat, err := b.Stat(pipe)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), stat.Queue+stat.Active)
+}
+
+func TestBroker_Consume_Delayed_Errored(t *testing.T) {
+	b, srv := newBroker(t)
+	defer srv.Close()
+
+	assert.NoError(t, b.Register(pipe))
+
+	attempts := make(chan int, 2)
+	exec := make(chan jobs.Handler, 1)
+	assert.NoError(t, b.Consume(pipe, exec, func(id string, j *jobs.Job, err error) {}))
+
+	serve(t, b)
+	defer b.Stop()
+
+	_, perr := b.Push(pipe, &jobs.Job{
+		Job:     "test",
+		Payload: "body",
+		Options: &jobs.Options{Delay: 1, Attempts: 2},
+	})
+	assert.NoError(t, perr)
+
+	exec <- func(id string, j *jobs.Job) error {
+		attempts <- j.Delivery.Attempt
+		return fmt.Errorf("job failed")
+	}
+
+	// deferral of delayed job is not an attempt
+	assert.Equal(t, 0, <-attempts)
+	assert.Equal(t, 1, <-attempts)
+}
+
+func TestBroker_Consume_InProgress(t *testing.T) {
+	b, srv := newBroker(t)
+	defer srv.Close()
+
+	assert.NoError(t, b.Register(pipe))
+
+	exec := make(chan jobs.Handler, 1)
+	assert.NoError(t, b.Consume(pipe, exec, func(id string, j *jobs.Job, err error) {}))
+
+	serve(t, b)
+	defer b.Stop()
+
+	_, perr := b.Push(pipe, &jobs.Job{Job: "test", Payload: "body", Options: &jobs.Options{}})
+	assert.NoError(t, perr)
+
+	calls := make(chan int, 2)
+	exec <- func(id string, j *jobs.Job) error {
+		calls <- j.Delivery.Attempt
+
+		// longer than ack wait
+		time.Sleep(2500 * time.Millisecond)
+		return nil
+	}
+
+	assert.Equal(t, 0, <-calls)
+	time.Sleep(time.Second)
+	assert.Len(t, calls, 0)
+}
+
+func TestBroker_Consume_Timeout(t *testing.T) {
+	b, srv := newBroker(t)
+	defer srv.Close()
+
+	slow := pipe.With("name", "slow").With("ackwait", 30)
+	assert.NoError(t, b.Register(&slow))
+
+	exec := make(chan jobs.Handler, 2)
+	assert.NoError(t, b.Consume(&slow, exec, func(id string, j *jobs.Job, err error) {}))
+
+	serve(t, b)
+	defer b.Stop()
+
+	start := time.Now()
+	_, perr := b.Push(&slow, &jobs.Job{Job: "test", Payload: "body", Options: &jobs.Options{Timeout: 1}})
+	assert.NoError(t, perr)
+
+	calls := make(chan int, 2)
+	h := func(id string, j *jobs.Job) error {
+		calls <- j.Delivery.Attempt
+
+		// longer than job timeout
+		time.Sleep(1500 * time.Millisecond)
+		return nil
+	}
+	exec <- h
+	exec <- h
+
+	assert.Equal(t, 0, <-calls)
+
+	// redelivered after job timeout rather than ack wait
+	assert.Equal(t, 1, <-calls)
+	assert.True(t, time.Since(start) < 5*time.Second)
+}
@@ -0,0 +1,85 @@
+package nats
+
+import (
+	"github.com/nats-io/nats.go"
+	"github.com/spiral/jobs/v2"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestBroker_Durability_DeadConsumer(t *testing.T) {
+	b, srv := newBroker(t)
+	defer srv.Close()
+
+	assert.NoError(t, b.Register(pipe))
+
+	serve(t, b)
+	defer b.Stop()
+
+	jid, perr := b.Push(pipe, &jobs.Job{Job: "test", Payload: "body", Options: &jobs.Options{Attempts: 3}})
+	assert.NoError(t, perr)
+
+	// another consumer dies after receiving the message
+	conn, err := nats.Connect(srv.ClientURL())
+	assert.NoError(t, err)
+
+	js, err := conn.JetStream()
+	assert.NoError(t, err)
+
+	sub, err := js.PullSubscribe("rr-stream", "rr-jobs", nats.Bind("rr-stream", "rr-jobs"))
+	assert.NoError(t, err)
+
+	messages, err := sub.Fetch(1, nats.MaxWait(time.Second))
+	assert.NoError(t, err)
+	assert.Len(t, messages, 1)
+	conn.Close()
+
+	exec := make(chan jobs.Handler, 1)
+	assert.NoError(t, b.Consume(pipe, exec, func(id string, j *jobs.Job, err error) {}))
+
+	waitJob := make(chan interface{})
+	exec <- func(id string, j *jobs.Job) error {
+		assert.Equal(t, jid, id)
+		assert.Equal(t, 1, j.Delivery.Attempt)
+		close(waitJob)
+		return nil
+	}
+
+	<-waitJob
+}
+
+func TestBroker_Durability_Restart(t *testing.T) {
+	b, srv := newBroker(t)
+	defer srv.Close()
+
+	assert.NoError(t, b.Register(pipe))
+
+	serve(t, b)
+
+	jid, perr := b.Push(pipe, &jobs.Job{Job: "test", Payload: "body", Options: &jobs.Options{}})
+	assert.NoError(t, perr)
+	b.Stop()
+
+	// jobs are kept by the server
+	b2 := &Broker{}
+	_, err := b2.Init(b.cfg)
+	assert.NoError(t, err)
+	assert.NoError(t, b2.Register(pipe))
+
+	exec := make(chan jobs.Handler, 1)
+	assert.NoError(t, b2.Consume(pipe, exec, func(id string, j *jobs.Job, err error) {}))
+
+	serve(t, b2)
+	defer b2.Stop()
+
+	waitJob := make(chan interface{})
+	exec <- func(id string, j *jobs.Job) error {
+		assert.Equal(t, jid, id)
+		assert.Equal(t, 0, j.Delivery.Attempt)
+		close(waitJob)
+		return nil
+	}
+
+	<-waitJob
+}
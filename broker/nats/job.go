@@ -0,0 +1,61 @@
+package nats
+
+import (
+	"fmt"
+	"github.com/nats-io/nats.go"
+	"github.com/spiral/jobs/v2"
+	"strconv"
+	"time"
+)
+
+// pack job into the message with job metadata in headers.
+func pack(subject, id string, j *jobs.Job, queued time.Time) *nats.Msg {
+	msg := nats.NewMsg(subject)
+	msg.Data = j.Body()
+
+	msg.Header.Set(nats.MsgIdHdr, id)
+	msg.Header.Set("rr-id", id)
+	msg.Header.Set("rr-job", j.Job)
+	msg.Header.Set("rr-maxAttempts", strconv.Itoa(j.Options.Attempts))
+	msg.Header.Set("rr-timeout", strconv.Itoa(j.Options.Timeout))
+	msg.Header.Set("rr-delay", strconv.Itoa(j.Options.Delay))
+	msg.Header.Set("rr-retryDelay", strconv.Itoa(j.Options.RetryDelay))
+	msg.Header.Set("rr-queued", strconv.FormatInt(queued.UnixNano(), 10))
+
+	return msg
+}
+
+// unpack restores job and time when job becomes available.
+func unpack(msg *nats.Msg) (id string, j *jobs.Job, queued time.Time, err error) {
+	if msg.Header.Get("rr-id") == "" {
+		return "", nil, queued, fmt.Errorf("missing header `%s`", "rr-id")
+	}
+
+	if msg.Header.Get("rr-job") == "" {
+		return "", nil, queued, fmt.Errorf("missing header `%s`", "rr-job")
+	}
+
+	j = &jobs.Job{Job: msg.Header.Get("rr-job"), Payload: string(msg.Data), Options: &jobs.Options{}}
+
+	if v, err := strconv.Atoi(msg.Header.Get("rr-maxAttempts")); err == nil {
+		j.Options.Attempts = v
+	}
+
+	if v, err := strconv.Atoi(msg.Header.Get("rr-timeout")); err == nil {
+		j.Options.Timeout = v
+	}
+
+	if v, err := strconv.Atoi(msg.Header.Get("rr-delay")); err == nil {
+		j.Options.Delay = v
+	}
+
+	if v, err := strconv.Atoi(msg.Header.Get("rr-retryDelay")); err == nil {
+		j.Options.RetryDelay = v
+	}
+
+	if v, err := strconv.ParseInt(msg.Header.Get("rr-queued"), 10, 64); err == nil {
+		queued = time.Unix(0, v)
+	}
+
+	return msg.Header.Get("rr-id"), j, queued, nil
+}
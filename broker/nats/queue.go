@@ -0,0 +1,355 @@
+package nats
+
+import (
+	"context"
+	"fmt"
+	"github.com/gofrs/uuid"
+	"github.com/nats-io/nats.go"
+	"github.com/spiral/jobs/v2"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// deferTolerance defines how early message can be delivered before it's considered deferred.
+const deferTolerance = 10 * time.Millisecond
+
+type queue struct {
+	active   int32
+	pipe     *jobs.Pipeline
+	stream   string
+	subject  string
+	consumer string
+	reserve  time.Duration
+	ackWait  time.Duration
+
+	// jobs parked with delayed redelivery by this consumer, by stream sequence
+	mud     sync.Mutex
+	delayed map[uint64]time.Time
+
+	// queue events
+	lsn func(event int, ctx interface{})
+
+	// stop channel
+	wait chan interface{}
+
+	// active operations
+	muw sync.RWMutex
+	wg  sync.WaitGroup
+
+	// exec handlers
+	execPool   chan jobs.Handler
+	errHandler jobs.ErrorHandler
+}
+
+// newQueue creates new stream wrapper.
+func newQueue(pipe *jobs.Pipeline, lsn func(event int, ctx interface{})) (*queue, error) {
+	if pipe.String("stream", "") == "" {
+		return nil, fmt.Errorf("missing `stream` parameter on nats pipeline `%s`", pipe.Name())
+	}
+
+	return &queue{
+		pipe:     pipe,
+		stream:   pipe.String("stream", ""),
+		subject:  pipe.String("subject", pipe.String("stream", "")),
+		consumer: pipe.String("consumer", "rr-jobs"),
+		reserve:  pipe.Duration("reserve", time.Second),
+		ackWait:  pipe.Duration("ackwait", 30*time.Second),
+		delayed:  make(map[uint64]time.Time),
+		lsn:      lsn,
+	}, nil
+}
+
+// declare stream and durable pull consumer.
+func (q *queue) declare(js nats.JetStreamContext) error {
+	if _, err := js.StreamInfo(q.stream); err != nil {
+		if err != nats.ErrStreamNotFound {
+			return err
+		}
+
+		_, err = js.AddStream(&nats.StreamConfig{
+			Name:      q.stream,
+			Subjects:  []string{q.subject},
+			Retention: nats.WorkQueuePolicy,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	if _, err := js.ConsumerInfo(q.stream, q.consumer); err != nil {
+		if err != nats.ErrConsumerNotFound {
+			return err
+		}
+
+		_, err = js.AddConsumer(q.stream, &nats.ConsumerConfig{
+			Durable:       q.consumer,
+			AckPolicy:     nats.AckExplicitPolicy,
+			AckWait:       q.ackWait,
+			MaxDeliver:    -1,
+			FilterSubject: q.subject,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// start consuming in background, queue is marked as consumed before the method returns so it can be stopped
+// right away.
+func (q *queue) start(js nats.JetStreamContext, tout time.Duration) {
+	q.wait = make(chan interface{})
+	atomic.StoreInt32(&q.active, 1)
+
+	go q.serve(js, tout, q.wait)
+}
+
+// serve consumers till given channel is closed.
+func (q *queue) serve(js nats.JetStreamContext, tout time.Duration, wait chan interface{}) {
+	sub, err := js.PullSubscribe(q.subject, q.consumer, nats.Bind(q.stream, q.consumer))
+	if err != nil {
+		q.report(err)
+		return
+	}
+	defer sub.Unsubscribe()
+
+	var errored bool
+	for {
+		messages, stop, err := q.consume(sub, wait)
+		if err != nil {
+			if errored {
+				// reoccurring error
+				time.Sleep(tout)
+			} else {
+				errored = true
+				q.report(err)
+			}
+
+			continue
+		}
+		errored = false
+
+		if stop {
+			return
+		}
+
+		for _, msg := range messages {
+			h := <-q.execPool
+			go func(h jobs.Handler, msg *nats.Msg) {
+				err := q.do(h, msg)
+				q.execPool <- h
+				q.wg.Done()
+				q.report(err)
+			}(h, msg)
+		}
+	}
+}
+
+// consume fetches batch of messages.
+func (q *queue) consume(sub *nats.Subscription, wait chan interface{}) ([]*nats.Msg, bool, error) {
+	q.muw.Lock()
+	defer q.muw.Unlock()
+
+	select {
+	case <-wait:
+		return nil, true, nil
+	default:
+		messages, err := sub.Fetch(q.pipe.Integer("prefetch", 1), nats.MaxWait(q.reserve))
+		if err != nil {
+			if err == nats.ErrTimeout || err == context.DeadlineExceeded {
+				// no messages
+				return nil, false, nil
+			}
+
+			return nil, false, err
+		}
+
+		q.wg.Add(len(messages))
+
+		return messages, false, nil
+	}
+}
+
+// do single message
+func (q *queue) do(h jobs.Handler, msg *nats.Msg) error {
+	meta, err := msg.Metadata()
+	if err != nil {
+		return err
+	}
+
+	q.unpark(meta.Sequence.Stream)
+
+	id, j, queued, err := unpack(msg)
+	if err != nil {
+		msg.Term()
+		return err
+	}
+
+	if wait := time.Until(queued); wait > deferTolerance {
+		// delayed job
+		return q.park(msg, meta, wait)
+	}
+
+	j.Delivery = &jobs.Delivery{Pipeline: q.pipe.Name(), Attempt: attempt(meta, queued)}
+	if j.Delivery.Attempt == 0 {
+		j.Delivery.Queued = queued
+	}
+
+	done := make(chan interface{})
+	go q.progress(msg, j.Options.TimeoutDuration(), done)
+
+	err = h(id, j)
+	close(done)
+
+	if err == nil {
+		return msg.Ack()
+	}
+
+	if err == jobs.ErrRelease {
+		// delivery count is still increased by the next delivery
+		return msg.Nak()
+	}
+
+	q.errHandler(id, j, err)
+
+	if !j.Options.CanRetry(j.Delivery.Attempt) {
+		return msg.Term()
+	}
+
+	if err := q.park(msg, meta, j.Options.RetryDuration()); err != nil {
+		return err
+	}
+
+	q.lsn(jobs.EventJobRelease, &jobs.JobEvent{ID: id, Job: j, Pipeline: q.pipe.Name(), Attempt: j.Delivery.Attempt + 1})
+
+	return nil
+}
+
+// progress extends message ack wait until job is done or job timeout is reached, message is redelivered right
+// after the job timeout. Consumer ack wait only applies to messages of the dead consumers.
+func (q *queue) progress(msg *nats.Msg, timeout time.Duration, done chan interface{}) {
+	ticker := time.NewTicker(q.ackWait / 2)
+	defer ticker.Stop()
+
+	deadline := time.NewTimer(timeout)
+	defer deadline.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case <-deadline.C:
+			q.report(msg.Nak())
+			return
+		case <-ticker.C:
+			q.report(msg.InProgress())
+		}
+	}
+}
+
+// park message till it's redelivery after given delay.
+func (q *queue) park(msg *nats.Msg, meta *nats.MsgMetadata, delay time.Duration) error {
+	if err := msg.NakWithDelay(delay); err != nil {
+		return err
+	}
+
+	q.mud.Lock()
+	q.delayed[meta.Sequence.Stream] = time.Now().Add(delay)
+	q.mud.Unlock()
+
+	return nil
+}
+
+// unpark redelivered message.
+func (q *queue) unpark(seq uint64) {
+	q.mud.Lock()
+	delete(q.delayed, seq)
+	q.mud.Unlock()
+}
+
+// parked returns number of messages waiting for their redelivery, messages which are due are not counted.
+func (q *queue) parked() int64 {
+	q.mud.Lock()
+	defer q.mud.Unlock()
+
+	now := time.Now()
+	for seq, at := range q.delayed {
+		if !at.After(now) {
+			delete(q.delayed, seq)
+		}
+	}
+
+	return int64(len(q.delayed))
+}
+
+// stop the queue consuming
+func (q *queue) stop() {
+	if atomic.LoadInt32(&q.active) == 0 {
+		return
+	}
+
+	atomic.StoreInt32(&q.active, 0)
+
+	close(q.wait)
+	q.muw.Lock()
+	q.wg.Wait()
+	q.muw.Unlock()
+}
+
+// push job into the stream.
+func (q *queue) push(js nats.JetStreamContext, j *jobs.Job) (string, error) {
+	id, err := uuid.NewV4()
+	if err != nil {
+		return "", err
+	}
+
+	msg := pack(q.subject, id.String(), j, time.Now().Add(j.Options.DelayDuration()))
+	if _, err := js.PublishMsg(msg); err != nil {
+		return "", err
+	}
+
+	return id.String(), nil
+}
+
+// stat returns consumer stats. Parked messages are acknowledgement pending for the server, they are reported as
+// delayed instead of active. Only messages parked by this consumer are known.
+func (q *queue) stat(js nats.JetStreamContext) (*jobs.Stat, error) {
+	info, err := js.ConsumerInfo(q.stream, q.consumer)
+	if err != nil {
+		return nil, err
+	}
+
+	delayed := q.parked()
+
+	active := int64(info.NumAckPending) - delayed
+	if active < 0 {
+		active = 0
+	}
+
+	return &jobs.Stat{
+		InternalName: q.stream,
+		Queue:        int64(info.NumPending),
+		Active:       active,
+		Delayed:      delayed,
+	}, nil
+}
+
+// report queue specific error
+func (q *queue) report(err error) {
+	if err != nil {
+		q.lsn(jobs.EventPipeError, &jobs.PipelineError{Pipeline: q.pipe, Caused: err})
+	}
+}
+
+// attempt returns job attempt number based on number of deliveries. Delayed jobs are deferred on the first
+// delivery when consumer is online by the time job is pushed, such deferral is not counted as an attempt.
+func attempt(meta *nats.MsgMetadata, queued time.Time) int {
+	n := int(meta.NumDelivered) - 1
+	if n > 0 && queued.Sub(meta.Timestamp) > deferTolerance {
+		n--
+	}
+
+	return n
+}
@@ -0,0 +1,86 @@
+package nats
+
+import (
+	"fmt"
+	"github.com/spiral/jobs/v2"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestBroker_Stat(t *testing.T) {
+	b, srv := newBroker(t)
+	defer srv.Close()
+
+	assert.NoError(t, b.Register(pipe))
+
+	serve(t, b)
+	defer b.Stop()
+
+	_, err := b.Push(pipe, &jobs.Job{Job: "test", Payload: "body", Options: &jobs.Options{}})
+	assert.NoError(t, err)
+
+	stat, err := b.Stat(pipe)
+	assert.NoError(t, err)
+	assert.Equal(t, "rr-stream", stat.InternalName)
+	assert.Equal(t, int64(1), stat.Queue)
+	assert.Equal(t, int64(0), stat.Active)
+
+	exec := make(chan jobs.Handler, 1)
+	assert.NoError(t, b.Consume(pipe, exec, func(id string, j *jobs.Job, err error) {}))
+
+	waitJob := make(chan interface{})
+	exec <- func(id string, j *jobs.Job) error {
+		stat, err := b.Stat(pipe)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(0), stat.Queue)
+		assert.Equal(t, int64(1), stat.Active)
+
+		close(waitJob)
+		return nil
+	}
+
+	<-waitJob
+	assert.NoError(t, b.Consume(pipe, nil, nil))
+	time.Sleep(100 * time.Millisecond)
+
+	stat, err = b.Stat(pipe)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), stat.Queue)
+	assert.Equal(t, int64(0), stat.Active)
+}
+
+func TestBroker_Stat_Delayed(t *testing.T) {
+	b, srv := newBroker(t)
+	defer srv.Close()
+
+	assert.NoError(t, b.Register(pipe))
+
+	exec := make(chan jobs.Handler, 1)
+	assert.NoError(t, b.Consume(pipe, exec, func(id string, j *jobs.Job, err error) {}))
+
+	serve(t, b)
+	defer b.Stop()
+
+	released := make(chan interface{})
+	b.Listen(func(event int, ctx interface{}) {
+		if event == jobs.EventJobRelease {
+			close(released)
+		}
+	})
+
+	_, err := b.Push(pipe, &jobs.Job{Job: "test", Payload: "body", Options: &jobs.Options{Attempts: 2, RetryDelay: 10}})
+	assert.NoError(t, err)
+
+	exec <- func(id string, j *jobs.Job) error {
+		return fmt.Errorf("job failed")
+	}
+
+	<-released
+
+	stat, err := b.Stat(pipe)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), stat.Queue)
+	assert.Equal(t, int64(0), stat.Active)
+	assert.Equal(t, int64(1), stat.Delayed)
+}
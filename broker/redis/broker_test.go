@@ -0,0 +1,169 @@
+package redis
+
+import (
+	"github.com/alicebob/miniredis/v2"
+	"github.com/spiral/jobs/v2"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+var (
+	pipe = &jobs.Pipeline{
+		"broker": "redis",
+		"name":   "default",
+		"stream": "rr-stream",
+		"claim":  1,
+	}
+)
+
+// newBroker creates broker connected to in-process redis server.
+func newBroker(t *testing.T) (*Broker, *miniredis.Miniredis) {
+	srv, err := miniredis.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b := &Broker{}
+	if _, err := b.Init(&Config{Addr: srv.Addr()}); err != nil {
+		t.Fatal(err)
+	}
+
+	return b, srv
+}
+
+// serve broker and wait till it's ready.
+func serve(t *testing.T, b *Broker) {
+	ready := make(chan interface{})
+	b.Listen(func(event int, ctx interface{}) {
+		if event == jobs.EventBrokerReady {
+			close(ready)
+		}
+	})
+
+	go func() { assert.NoError(t, b.Serve()) }()
+	<-ready
+}
+
+func TestBroker_Init(t *testing.T) {
+	b := &Broker{}
+	ok, err := b.Init(&Config{Addr: "localhost:6379"})
+	assert.True(t, ok)
+	assert.NoError(t, err)
+}
+
+func TestBroker_StopNotStarted(t *testing.T) {
+	b, srv := newBroker(t)
+	defer srv.Close()
+
+	b.Stop()
+}
+
+func TestBroker_Register(t *testing.T) {
+	b, srv := newBroker(t)
+	defer srv.Close()
+
+	assert.NoError(t, b.Register(pipe))
+}
+
+func TestBroker_RegisterInvalid(t *testing.T) {
+	b, srv := newBroker(t)
+	defer srv.Close()
+
+	assert.Error(t, b.Register(&jobs.Pipeline{
+		"broker": "redis",
+		"name":   "default",
+	}))
+}
+
+func TestBroker_Register_Twice(t *testing.T) {
+	b, srv := newBroker(t)
+	defer srv.Close()
+
+	assert.NoError(t, b.Register(pipe))
+	assert.Error(t, b.Register(pipe))
+}
+
+func TestBroker_Serve_Error(t *testing.T) {
+	b, srv := newBroker(t)
+	srv.Close()
+
+	assert.NoError(t, b.Register(pipe))
+	assert.Error(t, b.Serve())
+}
+
+func TestBroker_Serve_DeclareGroup(t *testing.T) {
+	b, srv := newBroker(t)
+	defer srv.Close()
+
+	assert.NoError(t, b.Register(pipe))
+
+	serve(t, b)
+	defer b.Stop()
+
+	assert.True(t, srv.Exists("rr-stream"))
+}
+
+func TestBroker_Consume_Undefined(t *testing.T) {
+	b, srv := newBroker(t)
+	defer srv.Close()
+
+	assert.Error(t, b.Consume(pipe, nil, nil))
+}
+
+func TestBroker_Consume_Serve_Stop(t *testing.T) {
+	b, srv := newBroker(t)
+	defer srv.Close()
+
+	assert.NoError(t, b.Register(pipe))
+
+	exec := make(chan jobs.Handler)
+	assert.NoError(t, b.Consume(pipe, exec, func(id string, j *jobs.Job, err error) {}))
+
+	serve(t, b)
+	time.Sleep(100 * time.Millisecond)
+	b.Stop()
+}
+
+func TestBroker_PushToNotRunning(t *testing.T) {
+	b, srv := newBroker(t)
+	defer srv.Close()
+
+	assert.NoError(t, b.Register(pipe))
+
+	_, err := b.Push(pipe, &jobs.Job{})
+	assert.Error(t, err)
+}
+
+func TestBroker_StatNotRunning(t *testing.T) {
+	b, srv := newBroker(t)
+	defer srv.Close()
+
+	assert.NoError(t, b.Register(pipe))
+
+	_, err := b.Stat(pipe)
+	assert.Error(t, err)
+}
+
+func TestBroker_PushToNotRegistered(t *testing.T) {
+	b, srv := newBroker(t)
+	defer srv.Close()
+
+	serve(t, b)
+	defer b.Stop()
+
+	_, err := b.Push(pipe, &jobs.Job{})
+	assert.Error(t, err)
+}
+
+func TestBroker_Health(t *testing.T) {
+	b, srv := newBroker(t)
+	defer srv.Close()
+
+	assert.Error(t, b.Health())
+
+	serve(t, b)
+	defer b.Stop()
+
+	assert.NoError(t, b.Health())
+}
@@ -0,0 +1,56 @@
+package redis
+
+import (
+	"fmt"
+	"github.com/go-redis/redis/v8"
+	"github.com/spiral/roadrunner/service"
+	"time"
+)
+
+// Config defines redis broker configuration.
+type Config struct {
+	// Addr of redis server.
+	Addr string
+
+	// Password to authenticate with.
+	Password string
+
+	// DB defines redis database to select.
+	DB int
+
+	// Timeout to allocate the connection. Default 10 seconds.
+	Timeout int
+}
+
+// Hydrate config values.
+func (c *Config) Hydrate(cfg service.Config) error {
+	if err := cfg.Unmarshal(c); err != nil {
+		return err
+	}
+
+	if c.Addr == "" {
+		return fmt.Errorf("redis address is missing")
+	}
+
+	return nil
+}
+
+// TimeoutDuration returns number of seconds allowed to allocate the connection.
+func (c *Config) TimeoutDuration() time.Duration {
+	timeout := c.Timeout
+	if timeout == 0 {
+		timeout = 10
+	}
+
+	return time.Duration(timeout) * time.Second
+}
+
+// newClient creates new redis client.
+func (c *Config) newClient() *redis.Client {
+	return redis.NewClient(&redis.Options{
+		Addr:        c.Addr,
+		Password:    c.Password,
+		DB:          c.DB,
+		DialTimeout: c.TimeoutDuration(),
+	})
+}
@@ -0,0 +1,256 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"github.com/go-redis/redis/v8"
+	"github.com/spiral/jobs/v2"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestBroker_Consume_Job(t *testing.T) {
+	b, srv := newBroker(t)
+	defer srv.Close()
+
+	assert.NoError(t, b.Register(pipe))
+
+	exec := make(chan jobs.Handler, 1)
+	assert.NoError(t, b.Consume(pipe, exec, func(id string, j *jobs.Job, err error) {}))
+
+	serve(t, b)
+	defer b.Stop()
+
+	jid, perr := b.Push(pipe, &jobs.Job{
+		Job:     "test",
+		Payload: "body",
+		Options: &jobs.Options{},
+	})
+
+	assert.NotEqual(t, "", jid)
+	assert.NoError(t, perr)
+
+	waitJob := make(chan interface{})
+	exec <- func(id string, j *jobs.Job) error {
+		assert.Equal(t, jid, id)
+		assert.Equal(t, "test", j.Job)
+		assert.Equal(t, "body", j.Payload)
+		assert.Equal(t, 0, j.Delivery.Attempt)
+		assert.Equal(t, "default", j.Delivery.Pipeline)
+		close(waitJob)
+		return nil
+	}
+
+	<-waitJob
+
+	// acknowledged entries are removed
+	time.Sleep(100 * time.Millisecond)
+	assert.NoError(t, b.Consume(pipe, nil, nil))
+
+	stat, err := b.Stat(pipe)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), stat.Queue+stat.Active+stat.Delayed)
+}
+
+func TestBroker_Consume_PushedBeforeServe(t *testing.T) {
+	b, srv := newBroker(t)
+	defer srv.Close()
+
+	assert.NoError(t, b.Register(pipe))
+
+	serve(t, b)
+	defer b.Stop()
+
+	jid, perr := b.Push(pipe, &jobs.Job{Job: "test", Payload: "body", Options: &jobs.Options{}})
+	assert.NoError(t, perr)
+
+	exec := make(chan jobs.Handler, 1)
+	assert.NoError(t, b.Consume(pipe, exec, func(id string, j *jobs.Job, err error) {}))
+
+	waitJob := make(chan interface{})
+	exec <- func(id string, j *jobs.Job) error {
+		assert.Equal(t, jid, id)
+		close(waitJob)
+		return nil
+	}
+
+	<-waitJob
+}
+
+func TestBroker_Consume_Delayed(t *testing.T) {
+	b, srv := newBroker(t)
+	defer srv.Close()
+
+	assert.NoError(t, b.Register(pipe))
+
+	exec := make(chan jobs.Handler, 1)
+	assert.NoError(t, b.Consume(pipe, exec, func(id string, j *jobs.Job, err error) {}))
+
+	serve(t, b)
+	defer b.Stop()
+
+	start := time.Now()
+	jid, perr := b.Push(pipe, &jobs.Job{
+		Job:     "test",
+		Payload: "body",
+		Options: &jobs.Options{Delay: 1},
+	})
+
+	assert.NotEqual(t, "", jid)
+	assert.NoError(t, perr)
+
+	stat, err := b.Stat(pipe)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), stat.Delayed)
+
+	waitJob := make(chan interface{})
+	exec <- func(id string, j *jobs.Job) error {
+		assert.Equal(t, jid, id)
+		assert.False(t, j.Delivery.Queued.Before(start.Add(time.Second)))
+		close(waitJob)
+		return nil
+	}
+
+	<-waitJob
+	assert.True(t, time.Since(start) >= time.Second)
+}
+
+func TestBroker_Consume_Errored_Attempts(t *testing.T) {
+	b, srv := newBroker(t)
+	defer srv.Close()
+
+	assert.NoError(t, b.Register(pipe))
+
+	attempts := make(chan int, 3)
+	errHandler := func(id string, j *jobs.Job, err error) {
+		assert.Equal(t, "job failed", err.Error())
+	}
+
+	exec := make(chan jobs.Handler, 1)
+	assert.NoError(t, b.Consume(pipe, exec, errHandler))
+
+	serve(t, b)
+	defer b.Stop()
+
+	jid, perr := b.Push(pipe, &jobs.Job{
+		Job:     "test",
+		Payload: "body",
+		Options: &jobs.Options{Attempts: 3},
+	})
+	assert.NoError(t, perr)
+
+	exec <- func(id string, j *jobs.Job) error {
+		assert.Equal(t, jid, id)
+		attempts <- j.Delivery.Attempt
+		return fmt.Errorf("job failed")
+	}
+
+	assert.Equal(t, 0, <-attempts)
+	assert.Equal(t, 1, <-attempts)
+	assert.Equal(t, 2, <-attempts)
+
+	// failed job is removed after the last attempt
+	time.Sleep(100 * time.Millisecond)
+	assert.NoError(t, b.Consume(pipe, nil, nil))
+
+	stat, err := b.Stat(pipe)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), stat.Queue+stat.Active+stat.Delayed)
+}
+
+func TestBroker_Consume_Reclaim(t *testing.T) {
+	b, srv := newBroker(t)
+	defer srv.Close()
+
+	assert.NoError(t, b.Register(pipe))
+
+	serve(t, b)
+	defer b.Stop()
+
+	jid, perr := b.Push(pipe, &jobs.Job{Job: "test", Payload: "body", Options: &jobs.Options{Timeout: 1}})
+	assert.NoError(t, perr)
+
+	// another consumer dies after reading the entry
+	c := redis.NewClient(&redis.Options{Addr: srv.Addr()})
+	defer c.Close()
+
+	_, err := c.XReadGroup(context.Background(), &redis.XReadGroupArgs{
+		Group:    "rr-jobs",
+		Consumer: "dead",
+		Streams:  []string{"rr-stream", ">"},
+		Count:    1,
+		Block:    -1,
+	}).Result()
+	assert.NoError(t, err)
+
+	stat, err := b.Stat(pipe)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), stat.Active)
+
+	exec := make(chan jobs.Handler, 1)
+	assert.NoError(t, b.Consume(pipe, exec, func(id string, j *jobs.Job, err error) {}))
+
+	waitJob := make(chan interface{})
+	exec <- func(id string, j *jobs.Job) error {
+		assert.Equal(t, jid, id)
+		assert.Equal(t, 1, j.Delivery.Attempt)
+		close(waitJob)
+		return nil
+	}
+
+	<-waitJob
+}
+
+func TestBroker_Consume_Reclaim_Paging(t *testing.T) {
+	b, srv := newBroker(t)
+	defer srv.Close()
+
+	assert.NoError(t, b.Register(pipe))
+
+	serve(t, b)
+	defer b.Stop()
+
+	// first page of pending entries is still being processed by another consumer
+	for i := 0; i < claimBatch; i++ {
+		_, err := b.Push(pipe, &jobs.Job{Job: "test", Payload: "body", Options: &jobs.Options{Timeout: 60}})
+		assert.NoError(t, err)
+	}
+
+	jid, perr := b.Push(pipe, &jobs.Job{Job: "test", Payload: "body", Options: &jobs.Options{Timeout: 1}})
+	assert.NoError(t, perr)
+
+	c := redis.NewClient(&redis.Options{Addr: srv.Addr()})
+	defer c.Close()
+
+	_, err := c.XReadGroup(context.Background(), &redis.XReadGroupArgs{
+		Group:    "rr-jobs",
+		Consumer: "dead",
+		Streams:  []string{"rr-stream", ">"},
+		Count:    claimBatch + 1,
+		Block:    -1,
+	}).Result()
+	assert.NoError(t, err)
+
+	exec := make(chan jobs.Handler, 1)
+	assert.NoError(t, b.Consume(pipe, exec, func(id string, j *jobs.Job, err error) {}))
+
+	waitJob := make(chan interface{})
+	exec <- func(id string, j *jobs.Job) error {
+		assert.Equal(t, jid, id)
+		close(waitJob)
+		return nil
+	}
+
+	select {
+	case <-waitJob:
+	case <-time.After(5 * time.Second):
+		t.Fatal("abandoned entry was not reclaimed")
+	}
+}
+
+func Test_NextID(t *testing.T) {
+	assert.Equal(t, "1526919030474-56", nextID("1526919030474-55"))
+	assert.Equal(t, "1526919030474-1", nextID("1526919030474"))
+	assert.Equal(t, "1526919030475-0", nextID("1526919030474-18446744073709551615"))
+}
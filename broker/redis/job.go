@@ -0,0 +1,52 @@
+package redis
+
+import (
+	json "github.com/json-iterator/go"
+	"github.com/spiral/jobs/v2"
+	"time"
+)
+
+// message is job envelope stored in the stream and in the delayed set.
+type message struct {
+	// ID is job id, stream entry ids are only known once job is added into the stream.
+	ID string `json:"id"`
+
+	// Job name.
+	Job string `json:"job"`
+
+	// Payload is job payload.
+	Payload string `json:"payload"`
+
+	// Options contains job options.
+	Options *jobs.Options `json:"options"`
+
+	// Queued defines when job becomes available (unix nano).
+	Queued int64 `json:"queued"`
+}
+
+// pack job into the message.
+func pack(id string, j *jobs.Job, queued time.Time) (string, error) {
+	data, err := json.MarshalToString(&message{
+		ID:      id,
+		Job:     j.Job,
+		Payload: j.Payload,
+		Options: j.Options,
+		Queued:  queued.UnixNano(),
+	})
+
+	return data, err
+}
+
+// unpack job from the message.
+func unpack(data string) (id string, j *jobs.Job, queued time.Time, err error) {
+	m := &message{}
+	if err := json.UnmarshalFromString(data, m); err != nil {
+		return "", nil, queued, err
+	}
+
+	if m.Options == nil {
+		m.Options = &jobs.Options{}
+	}
+
+	return m.ID, &jobs.Job{Job: m.Job, Payload: m.Payload, Options: m.Options}, time.Unix(0, m.Queued), nil
+}
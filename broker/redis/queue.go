@@ -0,0 +1,505 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"github.com/go-redis/redis/v8"
+	"github.com/gofrs/uuid"
+	"github.com/spiral/jobs/v2"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// claimBatch defines number of pending entries to be checked for reclaim at once.
+const claimBatch = 100
+
+// schedule moves due jobs from the delayed set into the stream.
+var schedule = redis.NewScript(`
+local jobs = redis.call('ZRANGEBYSCORE', KEYS[1], '-inf', ARGV[1], 'LIMIT', 0, ARGV[2])
+for _, data in ipairs(jobs) do
+	redis.call('XADD', KEYS[2], '*', 'data', data)
+	redis.call('ZREM', KEYS[1], data)
+end
+return #jobs
+`)
+
+type queue struct {
+	active   int32
+	pipe     *jobs.Pipeline
+	stream   string
+	group    string
+	consumer string
+	reserve  time.Duration
+
+	// pending entries reclaim
+	claim   time.Duration
+	claimed time.Time
+
+	// queue events
+	lsn func(event int, ctx interface{})
+
+	// stop channel
+	wait chan interface{}
+
+	// active operations
+	muw sync.RWMutex
+	wg  sync.WaitGroup
+
+	// exec handlers
+	execPool   chan jobs.Handler
+	errHandler jobs.ErrorHandler
+}
+
+// entry is stream entry delivered to the consumer.
+type entry struct {
+	id      string
+	data    string
+	attempt int
+	queued  time.Time
+}
+
+// newQueue creates new stream wrapper.
+func newQueue(pipe *jobs.Pipeline, lsn func(event int, ctx interface{})) (*queue, error) {
+	if pipe.String("stream", "") == "" {
+		return nil, fmt.Errorf("missing `stream` parameter on redis pipeline `%s`", pipe.Name())
+	}
+
+	return &queue{
+		pipe:     pipe,
+		stream:   pipe.String("stream", ""),
+		group:    pipe.String("group", "rr-jobs"),
+		consumer: pipe.String("consumer", fmt.Sprintf("rr-jobs:%s-%v", pipe.Name(), os.Getpid())),
+		reserve:  pipe.Duration("reserve", time.Second),
+		claim:    pipe.Duration("claim", time.Second),
+		lsn:      lsn,
+	}, nil
+}
+
+// delayed returns name of the sorted set holding delayed jobs.
+func (q *queue) delayed() string {
+	return q.stream + ":delayed"
+}
+
+// retries returns name of the hash holding retry time of failed pending entries.
+func (q *queue) retries() string {
+	return q.stream + ":retry"
+}
+
+// declare creates stream and consumer group.
+func (q *queue) declare(c *redis.Client) error {
+	err := c.XGroupCreateMkStream(context.Background(), q.stream, q.group, "0").Err()
+	if err != nil && !strings.HasPrefix(err.Error(), "BUSYGROUP") {
+		return err
+	}
+
+	return nil
+}
+
+// start consuming in background, queue is marked as consumed before the method returns so it can be stopped
+// right away.
+func (q *queue) start(c *redis.Client, tout time.Duration) {
+	q.wait = make(chan interface{})
+	atomic.StoreInt32(&q.active, 1)
+
+	go q.serve(c, tout, q.wait)
+}
+
+// serve consumers till given channel is closed.
+func (q *queue) serve(c *redis.Client, tout time.Duration, wait chan interface{}) {
+	var errored bool
+	for {
+		entries, stop, err := q.consume(c, wait)
+		if err != nil {
+			if errored {
+				// reoccurring error
+				time.Sleep(tout)
+			} else {
+				errored = true
+				q.report(err)
+			}
+
+			continue
+		}
+		errored = false
+
+		if stop {
+			return
+		}
+
+		for _, e := range entries {
+			h := <-q.execPool
+			go func(h jobs.Handler, e *entry) {
+				err := q.do(c, h, e)
+				q.execPool <- h
+				q.wg.Done()
+				q.report(err)
+			}(h, e)
+		}
+	}
+}
+
+// consume reclaims idle pending entries or reads new entries from the stream.
+func (q *queue) consume(c *redis.Client, wait chan interface{}) ([]*entry, bool, error) {
+	q.muw.Lock()
+	defer q.muw.Unlock()
+
+	select {
+	case <-wait:
+		return nil, true, nil
+	default:
+		if time.Since(q.claimed) >= q.claim {
+			q.claimed = time.Now()
+
+			entries, err := q.reclaim(c)
+			if len(entries) != 0 {
+				// claimed entries must be executed even if the rest of them can not be claimed
+				q.report(err)
+				q.wg.Add(len(entries))
+				return entries, false, nil
+			}
+
+			if err != nil {
+				return nil, false, err
+			}
+		}
+
+		streams, err := c.XReadGroup(context.Background(), &redis.XReadGroupArgs{
+			Group:    q.group,
+			Consumer: q.consumer,
+			Streams:  []string{q.stream, ">"},
+			Count:    int64(q.pipe.Integer("prefetch", 1)),
+			Block:    q.reserve,
+		}).Result()
+		if err != nil {
+			if err == redis.Nil {
+				// no new entries
+				return nil, false, nil
+			}
+
+			return nil, false, err
+		}
+
+		var entries []*entry
+		for _, s := range streams {
+			for _, msg := range s.Messages {
+				entries = append(entries, newEntry(msg, 0, time.Time{}))
+			}
+		}
+
+		q.wg.Add(len(entries))
+
+		return entries, false, nil
+	}
+}
+
+// reclaim claims failed entries which are due to retry and entries which exceeded their timeout (abandoned by
+// dead consumers). Pending entries are checked page by page till the end of the list or till the batch of entries
+// is claimed.
+func (q *queue) reclaim(c *redis.Client) ([]*entry, error) {
+	ctx := context.Background()
+
+	var (
+		entries []*entry
+		retries map[string]string
+		start   = "-"
+	)
+
+	for {
+		pending, err := c.XPendingExt(ctx, &redis.XPendingExtArgs{
+			Stream: q.stream,
+			Group:  q.group,
+			Start:  start,
+			End:    "+",
+			Count:  claimBatch,
+		}).Result()
+		if err != nil || len(pending) == 0 {
+			return entries, err
+		}
+
+		if retries == nil {
+			if retries, err = c.HGetAll(ctx, q.retries()).Result(); err != nil {
+				return entries, err
+			}
+		}
+
+		claimed, err := q.claimPending(c, pending, retries)
+		entries = append(entries, claimed...)
+		if err != nil || len(pending) < claimBatch || len(entries) >= claimBatch {
+			return entries, err
+		}
+
+		start = nextID(pending[len(pending)-1].ID)
+	}
+}
+
+// claimPending claims given pending entries which are due to retry or exceeded their timeout.
+func (q *queue) claimPending(c *redis.Client, pending []redis.XPendingExt, retries map[string]string) ([]*entry, error) {
+	ctx := context.Background()
+	now := time.Now()
+
+	var entries []*entry
+	for _, p := range pending {
+		var (
+			minIdle time.Duration
+			queued  time.Time
+		)
+
+		if at, ok := retries[p.ID]; ok {
+			ms, _ := strconv.ParseInt(at, 10, 64)
+			if queued = time.Unix(0, ms*int64(time.Millisecond)); queued.After(now) {
+				continue
+			}
+
+			// only one consumer is allowed to retry the entry
+			if n, err := c.HDel(ctx, q.retries(), p.ID).Result(); err != nil || n == 0 {
+				continue
+			}
+		} else {
+			timeout, err := q.timeout(c, p.ID)
+			if err != nil || p.Idle < timeout {
+				continue
+			}
+
+			minIdle = timeout
+		}
+
+		claimed, err := c.XClaim(ctx, &redis.XClaimArgs{
+			Stream:   q.stream,
+			Group:    q.group,
+			Consumer: q.consumer,
+			MinIdle:  minIdle,
+			Messages: []string{p.ID},
+		}).Result()
+		if err != nil {
+			return entries, err
+		}
+
+		for _, msg := range claimed {
+			entries = append(entries, newEntry(msg, int(p.RetryCount), queued))
+		}
+	}
+
+	return entries, nil
+}
+
+// nextID returns the smallest stream entry id greater than given one.
+func nextID(id string) string {
+	ms, seq := id, "0"
+	if i := strings.IndexByte(id, '-'); i != -1 {
+		ms, seq = id[:i], id[i+1:]
+	}
+
+	n, err := strconv.ParseUint(seq, 10, 64)
+	if err != nil || n == math.MaxUint64 {
+		m, _ := strconv.ParseUint(ms, 10, 64)
+		return strconv.FormatUint(m+1, 10) + "-0"
+	}
+
+	return ms + "-" + strconv.FormatUint(n+1, 10)
+}
+
+// timeout returns execution timeout of the job stored in stream entry.
+func (q *queue) timeout(c *redis.Client, id string) (time.Duration, error) {
+	messages, err := c.XRange(context.Background(), q.stream, id, id).Result()
+	if err != nil {
+		return 0, err
+	}
+
+	if len(messages) == 0 {
+		return 0, fmt.Errorf("undefined entry `%s`", id)
+	}
+
+	_, j, _, err := unpack(newEntry(messages[0], 0, time.Time{}).data)
+	if err != nil {
+		// broken entries are reclaimed immediately to be removed
+		return 0, nil
+	}
+
+	return j.Options.TimeoutDuration(), nil
+}
+
+// do single entry
+func (q *queue) do(c *redis.Client, h jobs.Handler, e *entry) error {
+	id, j, queued, err := unpack(e.data)
+	if err != nil {
+		q.ack(c, e)
+		return err
+	}
+
+	if e.attempt != 0 {
+		queued = e.queued
+	}
+
+	j.Delivery = &jobs.Delivery{Pipeline: q.pipe.Name(), Attempt: e.attempt, Queued: queued}
+
+	err = h(id, j)
+	if err == nil {
+		return q.ack(c, e)
+	}
+
+	if err == jobs.ErrRelease {
+		return q.release(c, e)
+	}
+
+	q.errHandler(id, j, err)
+
+	if !j.Options.CanRetry(e.attempt) {
+		return q.ack(c, e)
+	}
+
+	// keep entry pending till retry time
+	at := time.Now().Add(j.Options.RetryDuration()).UnixNano() / int64(time.Millisecond)
+	if err := c.HSet(context.Background(), q.retries(), e.id, at).Err(); err != nil {
+		return err
+	}
+
+	q.lsn(jobs.EventJobRelease, &jobs.JobEvent{ID: id, Job: j, Pipeline: q.pipe.Name(), Attempt: e.attempt + 1})
+
+	return nil
+}
+
+// ack and remove the entry from the stream.
+func (q *queue) ack(c *redis.Client, e *entry) error {
+	_, err := c.TxPipelined(context.Background(), func(p redis.Pipeliner) error {
+		p.XAck(context.Background(), q.stream, q.group, e.id)
+		p.XDel(context.Background(), q.stream, e.id)
+		return nil
+	})
+
+	return err
+}
+
+// release adds the entry to the stream again and removes the delivered one, stream counts deliveries of each entry
+// so released entry starts from the first attempt.
+func (q *queue) release(c *redis.Client, e *entry) error {
+	_, err := c.TxPipelined(context.Background(), func(p redis.Pipeliner) error {
+		p.XAdd(context.Background(), &redis.XAddArgs{Stream: q.stream, Values: []interface{}{"data", e.data}})
+		p.XAck(context.Background(), q.stream, q.group, e.id)
+		p.XDel(context.Background(), q.stream, e.id)
+		return nil
+	})
+
+	return err
+}
+
+// stop the queue consuming
+func (q *queue) stop() {
+	if atomic.LoadInt32(&q.active) == 0 {
+		return
+	}
+
+	atomic.StoreInt32(&q.active, 0)
+
+	close(q.wait)
+	q.muw.Lock()
+	q.wg.Wait()
+	q.muw.Unlock()
+}
+
+// push job into the stream or into the delayed set.
+func (q *queue) push(c *redis.Client, j *jobs.Job) (string, error) {
+	id, err := uuid.NewV4()
+	if err != nil {
+		return "", err
+	}
+
+	delay := j.Options.DelayDuration()
+	queued := time.Now().Add(delay)
+
+	data, err := pack(id.String(), j, queued)
+	if err != nil {
+		return "", err
+	}
+
+	if delay == 0 {
+		err = c.XAdd(context.Background(), &redis.XAddArgs{Stream: q.stream, Values: []interface{}{"data", data}}).Err()
+	} else {
+		err = c.ZAdd(context.Background(), q.delayed(), &redis.Z{
+			Score:  float64(queued.UnixNano() / int64(time.Millisecond)),
+			Member: data,
+		}).Err()
+	}
+
+	if err != nil {
+		return "", err
+	}
+
+	return id.String(), nil
+}
+
+// schedule moves due delayed jobs into the stream until stopped.
+func (q *queue) schedule(c *redis.Client, interval time.Duration, stop chan interface{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case now := <-ticker.C:
+			q.report(q.move(c, now))
+		}
+	}
+}
+
+// move due delayed jobs into the stream.
+func (q *queue) move(c *redis.Client, now time.Time) error {
+	for {
+		n, err := schedule.Run(
+			context.Background(),
+			c,
+			[]string{q.delayed(), q.stream},
+			now.UnixNano()/int64(time.Millisecond),
+			claimBatch,
+		).Int()
+		if err != nil || n < claimBatch {
+			return err
+		}
+	}
+}
+
+// stat returns stream stats.
+func (q *queue) stat(c *redis.Client) (*jobs.Stat, error) {
+	ctx := context.Background()
+
+	var (
+		length, delayed, retries *redis.IntCmd
+		pending                  *redis.XPendingCmd
+	)
+
+	_, err := c.Pipelined(ctx, func(p redis.Pipeliner) error {
+		length = p.XLen(ctx, q.stream)
+		pending = p.XPending(ctx, q.stream, q.group)
+		delayed = p.ZCard(ctx, q.delayed())
+		retries = p.HLen(ctx, q.retries())
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	stat := &jobs.Stat{InternalName: q.stream}
+	stat.Active = pending.Val().Count - retries.Val()
+	stat.Queue = length.Val() - pending.Val().Count
+	stat.Delayed = delayed.Val() + retries.Val()
+
+	return stat, nil
+}
+
+// report queue specific error
+func (q *queue) report(err error) {
+	if err != nil {
+		q.lsn(jobs.EventPipeError, &jobs.PipelineError{Pipeline: q.pipe, Caused: err})
+	}
+}
+
+// newEntry creates entry from the stream message.
+func newEntry(msg redis.XMessage, attempt int, queued time.Time) *entry {
+	data, _ := msg.Values["data"].(string)
+	return &entry{id: msg.ID, data: data, attempt: attempt, queued: queued}
+}
@@ -0,0 +1,53 @@
+package redis
+
+import (
+	"github.com/spiral/jobs/v2"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestBroker_Stat(t *testing.T) {
+	b, srv := newBroker(t)
+	defer srv.Close()
+
+	assert.NoError(t, b.Register(pipe))
+
+	serve(t, b)
+	defer b.Stop()
+
+	_, err := b.Push(pipe, &jobs.Job{Job: "test", Payload: "body", Options: &jobs.Options{}})
+	assert.NoError(t, err)
+
+	_, err = b.Push(pipe, &jobs.Job{Job: "test", Payload: "body", Options: &jobs.Options{Delay: 60}})
+	assert.NoError(t, err)
+
+	stat, err := b.Stat(pipe)
+	assert.NoError(t, err)
+	assert.Equal(t, "rr-stream", stat.InternalName)
+	assert.Equal(t, int64(1), stat.Queue)
+	assert.Equal(t, int64(1), stat.Delayed)
+	assert.Equal(t, int64(0), stat.Active)
+
+	exec := make(chan jobs.Handler, 1)
+	assert.NoError(t, b.Consume(pipe, exec, func(id string, j *jobs.Job, err error) {}))
+
+	waitJob := make(chan interface{})
+	exec <- func(id string, j *jobs.Job) error {
+		stat, err := b.Stat(pipe)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(0), stat.Queue)
+		assert.Equal(t, int64(1), stat.Active)
+
+		close(waitJob)
+		return nil
+	}
+
+	<-waitJob
+	assert.NoError(t, b.Consume(pipe, nil, nil))
+
+	stat, err = b.Stat(pipe)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), stat.Queue)
+	assert.Equal(t, int64(0), stat.Active)
+	assert.Equal(t, int64(1), stat.Delayed)
+}
@@ -0,0 +1,121 @@
+package spool
+
+import (
+	"github.com/spiral/jobs/v2"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+var (
+	pipe = &jobs.Pipeline{
+		"broker": "spool",
+		"name":   "default",
+		"poll":   1,
+	}
+)
+
+// newBroker creates broker spooling jobs in given directory.
+func newBroker(t *testing.T, dir string) *Broker {
+	b := &Broker{}
+	if _, err := b.Init(&Config{Dir: dir}); err != nil {
+		t.Fatal(err)
+	}
+
+	return b
+}
+
+// tempDir creates temporary spool directory.
+func tempDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "rr-spool")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return dir
+}
+
+// serve broker and wait till it's ready.
+func serve(t *testing.T, b *Broker) {
+	ready := make(chan interface{})
+	b.Listen(func(event int, ctx interface{}) {
+		if event == jobs.EventBrokerReady {
+			close(ready)
+		}
+	})
+
+	go func() { assert.NoError(t, b.Serve()) }()
+	<-ready
+}
+
+func TestBroker_Init(t *testing.T) {
+	b := &Broker{}
+	ok, err := b.Init(&Config{Dir: "spool"})
+	assert.True(t, ok)
+	assert.NoError(t, err)
+}
+
+func TestBroker_StopNotStarted(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	b := newBroker(t, dir)
+	b.Stop()
+}
+
+func TestBroker_Register(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	b := newBroker(t, dir)
+	assert.NoError(t, b.Register(pipe))
+}
+
+func TestBroker_Register_Twice(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	b := newBroker(t, dir)
+	assert.NoError(t, b.Register(pipe))
+	assert.Error(t, b.Register(pipe))
+}
+
+func TestBroker_Consume_Undefined(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	b := newBroker(t, dir)
+	assert.Error(t, b.Consume(pipe, nil, nil))
+}
+
+func TestBroker_Push_NotServing(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	b := newBroker(t, dir)
+	assert.NoError(t, b.Register(pipe))
+
+	_, err := b.Push(pipe, &jobs.Job{Job: "test", Payload: "body", Options: &jobs.Options{}})
+	assert.Error(t, err)
+	assert.Error(t, b.Health())
+}
+
+func TestBroker_Push_Undefined(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	b := newBroker(t, dir)
+	assert.NoError(t, b.Register(pipe))
+
+	serve(t, b)
+	defer b.Stop()
+
+	assert.NoError(t, b.Health())
+
+	_, err := b.Push(&jobs.Pipeline{"name": "other"}, &jobs.Job{Job: "test", Payload: "body", Options: &jobs.Options{}})
+	assert.Error(t, err)
+
+	_, err = b.Stat(&jobs.Pipeline{"name": "other"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,39 @@
+package spool
+
+import (
+	"fmt"
+	"github.com/spiral/roadrunner/service"
+	"time"
+)
+
+// Config defines spool broker configuration.
+type Config struct {
+	// Dir is root spool directory, each pipeline is stored in it's own sub directory.
+	Dir string
+
+	// Timeout to wait before retrying failed spool operations. Default 10 seconds.
+	Timeout int
+}
+
+// Hydrate config values.
+func (c *Config) Hydrate(cfg service.Config) error {
+	if err := cfg.Unmarshal(c); err != nil {
+		return err
+	}
+
+	if c.Dir == "" {
+		return fmt.Errorf("spool directory is missing")
+	}
+
+	return nil
+}
+
+// TimeoutDuration returns number of seconds to wait before retrying failed spool operations.
+func (c *Config) TimeoutDuration() time.Duration {
+	timeout := c.Timeout
+	if timeout == 0 {
+		timeout = 10
+	}
+
+	return time.Duration(timeout) * time.Second
+}
@@ -0,0 +1,193 @@
+package spool
+
+import (
+	"fmt"
+	"github.com/spiral/jobs/v2"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestBroker_Consume_Job(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	b := newBroker(t, dir)
+	assert.NoError(t, b.Register(pipe))
+
+	exec := make(chan jobs.Handler, 1)
+	assert.NoError(t, b.Consume(pipe, exec, func(id string, j *jobs.Job, err error) {}))
+
+	serve(t, b)
+	defer b.Stop()
+
+	jid, perr := b.Push(pipe, &jobs.Job{Job: "test", Payload: "body", Options: &jobs.Options{Timeout: 10}})
+	assert.NotEqual(t, "", jid)
+	assert.NoError(t, perr)
+
+	waitJob := make(chan interface{})
+	exec <- func(id string, j *jobs.Job) error {
+		assert.Equal(t, jid, id)
+		assert.Equal(t, "test", j.Job)
+		assert.Equal(t, "body", j.Payload)
+		assert.Equal(t, 10, j.Options.Timeout)
+		assert.Equal(t, 0, j.Delivery.Attempt)
+		close(waitJob)
+		return nil
+	}
+
+	<-waitJob
+}
+
+func TestBroker_Consume_Notify(t *testing.T) {
+	p := &jobs.Pipeline{"broker": "spool", "name": "default", "poll": 60}
+
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	b := newBroker(t, dir)
+	assert.NoError(t, b.Register(p))
+
+	exec := make(chan jobs.Handler, 1)
+	assert.NoError(t, b.Consume(p, exec, func(id string, j *jobs.Job, err error) {}))
+
+	serve(t, b)
+	defer b.Stop()
+
+	// consumer is idle waiting for notification
+	time.Sleep(50 * time.Millisecond)
+
+	start := time.Now()
+	jid, perr := b.Push(p, &jobs.Job{Job: "test", Payload: "body", Options: &jobs.Options{}})
+	assert.NoError(t, perr)
+
+	waitJob := make(chan interface{})
+	exec <- func(id string, j *jobs.Job) error {
+		assert.Equal(t, jid, id)
+		close(waitJob)
+		return nil
+	}
+
+	select {
+	case <-waitJob:
+		assert.True(t, time.Since(start) < time.Second)
+	case <-time.After(5 * time.Second):
+		t.Fatal("job was not consumed")
+	}
+}
+
+func TestBroker_Consume_Delayed(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	b := newBroker(t, dir)
+	assert.NoError(t, b.Register(pipe))
+
+	exec := make(chan jobs.Handler, 1)
+	assert.NoError(t, b.Consume(pipe, exec, func(id string, j *jobs.Job, err error) {}))
+
+	serve(t, b)
+	defer b.Stop()
+
+	start := time.Now()
+	jid, perr := b.Push(pipe, &jobs.Job{Job: "test", Payload: "body", Options: &jobs.Options{Delay: 1}})
+	assert.NoError(t, perr)
+
+	waitJob := make(chan interface{})
+	exec <- func(id string, j *jobs.Job) error {
+		assert.Equal(t, jid, id)
+		close(waitJob)
+		return nil
+	}
+
+	<-waitJob
+	assert.True(t, time.Since(start) >= time.Second)
+}
+
+func TestBroker_Consume_Errored_Attempts(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	b := newBroker(t, dir)
+	assert.NoError(t, b.Register(pipe))
+
+	var attempts int32
+	errHandled := make(chan interface{}, 3)
+	errHandler := func(id string, j *jobs.Job, err error) {
+		assert.Equal(t, "job failed", err.Error())
+		errHandled <- nil
+	}
+
+	exec := make(chan jobs.Handler, 1)
+	assert.NoError(t, b.Consume(pipe, exec, errHandler))
+
+	serve(t, b)
+	defer b.Stop()
+
+	jid, perr := b.Push(pipe, &jobs.Job{Job: "test", Payload: "body", Options: &jobs.Options{Attempts: 3}})
+	assert.NoError(t, perr)
+
+	exec <- func(id string, j *jobs.Job) error {
+		assert.Equal(t, jid, id)
+		assert.Equal(t, int(atomic.AddInt32(&attempts, 1))-1, j.Delivery.Attempt)
+		return fmt.Errorf("job failed")
+	}
+
+	<-errHandled
+	<-errHandled
+	<-errHandled
+	time.Sleep(50 * time.Millisecond)
+
+	assert.Equal(t, int32(3), atomic.LoadInt32(&attempts))
+
+	stat, err := b.Stat(pipe)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), stat.Queue)
+	assert.Equal(t, int64(0), stat.Active)
+	assert.Equal(t, int64(0), stat.Delayed)
+
+	// exhausted job is kept in failed directory
+	files, err := ioutil.ReadDir(filepath.Join(stat.InternalName, dirFailed))
+	assert.NoError(t, err)
+	assert.Len(t, files, 1)
+}
+
+func TestBroker_Consume_Broken(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	b := newBroker(t, dir)
+	assert.NoError(t, b.Register(pipe))
+
+	exec := make(chan jobs.Handler, 1)
+	assert.NoError(t, b.Consume(pipe, exec, func(id string, j *jobs.Job, err error) {}))
+
+	ready := make(chan interface{})
+	errs := make(chan error, 1)
+	b.Listen(func(event int, ctx interface{}) {
+		switch event {
+		case jobs.EventBrokerReady:
+			close(ready)
+		case jobs.EventPipeError:
+			errs <- ctx.(*jobs.PipelineError).Caused
+		}
+	})
+
+	go func() { assert.NoError(t, b.Serve()) }()
+	defer b.Stop()
+	<-ready
+
+	q := b.queue(pipe)
+	assert.NoError(t, ioutil.WriteFile(q.path(dirNew, "broken"), []byte("body"), 0644))
+
+	<-errs
+	time.Sleep(50 * time.Millisecond)
+
+	files, err := ioutil.ReadDir(filepath.Join(q.dir, dirFailed))
+	assert.NoError(t, err)
+	assert.Len(t, files, 1)
+}
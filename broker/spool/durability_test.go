@@ -0,0 +1,131 @@
+package spool
+
+import (
+	"github.com/spiral/jobs/v2"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestBroker_Durability_Restart(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	b := newBroker(t, dir)
+	assert.NoError(t, b.Register(pipe))
+	serve(t, b)
+
+	jid, err := b.Push(pipe, &jobs.Job{Job: "test", Payload: "body", Options: &jobs.Options{}})
+	assert.NoError(t, err)
+
+	b.Stop()
+
+	r := &Broker{}
+	_, err = r.Init(b.cfg)
+	assert.NoError(t, err)
+	assert.NoError(t, r.Register(pipe))
+
+	exec := make(chan jobs.Handler, 1)
+	assert.NoError(t, r.Consume(pipe, exec, func(id string, j *jobs.Job, err error) {}))
+
+	serve(t, r)
+	defer r.Stop()
+
+	waitJob := make(chan interface{})
+	exec <- func(id string, j *jobs.Job) error {
+		assert.Equal(t, jid, id)
+		close(waitJob)
+		return nil
+	}
+
+	<-waitJob
+}
+
+func TestBroker_Durability_ExpiredReservation(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	b := newBroker(t, dir)
+	assert.NoError(t, b.Register(pipe))
+
+	serve(t, b)
+	defer b.Stop()
+
+	jid, err := b.Push(pipe, &jobs.Job{Job: "test", Payload: "body", Options: &jobs.Options{Timeout: 1, Attempts: 2}})
+	assert.NoError(t, err)
+
+	// reserved by the consumer which never completes the job
+	q := b.queue(pipe)
+	entries, err := q.reserve(1)
+	assert.NoError(t, err)
+	assert.Len(t, entries, 1)
+
+	_, err = os.Stat(q.path(dirCur, entries[0].name.String()))
+	assert.NoError(t, err)
+
+	exec := make(chan jobs.Handler, 1)
+	assert.NoError(t, b.Consume(pipe, exec, func(id string, j *jobs.Job, err error) {}))
+
+	start := time.Now()
+	waitJob := make(chan interface{})
+	exec <- func(id string, j *jobs.Job) error {
+		assert.Equal(t, jid, id)
+		assert.Equal(t, 1, j.Delivery.Attempt)
+		close(waitJob)
+		return nil
+	}
+
+	<-waitJob
+	assert.True(t, time.Since(start) >= 500*time.Millisecond)
+}
+
+func TestBroker_Durability_ExpiredLastAttempt(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	b := newBroker(t, dir)
+	assert.NoError(t, b.Register(pipe))
+
+	ready := make(chan interface{})
+	failed := make(chan error, 1)
+	b.Listen(func(event int, ctx interface{}) {
+		switch event {
+		case jobs.EventBrokerReady:
+			close(ready)
+		case jobs.EventPipeError:
+			failed <- ctx.(*jobs.PipelineError).Caused
+		}
+	})
+
+	go func() { assert.NoError(t, b.Serve()) }()
+	<-ready
+	defer b.Stop()
+
+	_, err := b.Push(pipe, &jobs.Job{Job: "test", Payload: "body", Options: &jobs.Options{Timeout: 1}})
+	assert.NoError(t, err)
+
+	// the only attempt is abandoned by the consumer
+	q := b.queue(pipe)
+	entries, err := q.reserve(1)
+	assert.NoError(t, err)
+	assert.Len(t, entries, 1)
+
+	exec := make(chan jobs.Handler, 1)
+	assert.NoError(t, b.Consume(pipe, exec, func(id string, j *jobs.Job, err error) {}))
+
+	exec <- func(id string, j *jobs.Job) error {
+		t.Error("job must not be executed")
+		return nil
+	}
+
+	assert.Contains(t, (<-failed).Error(), "expired")
+
+	stat, err := b.Stat(pipe)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), stat.Queue)
+	assert.Equal(t, int64(0), stat.Active)
+
+	_, err = os.Stat(q.path(dirFailed, entries[0].name.job()))
+	assert.NoError(t, err)
+}
@@ -0,0 +1,96 @@
+package spool
+
+import (
+	"fmt"
+	json "github.com/json-iterator/go"
+	"github.com/spiral/jobs/v2"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// record is job file content.
+type record struct {
+	// ID is job id.
+	ID string `json:"id"`
+
+	// Job name.
+	Job string `json:"job"`
+
+	// Payload is job payload.
+	Payload string `json:"payload"`
+
+	// Options contains job options.
+	Options *jobs.Options `json:"options"`
+
+	// Attempt number, starting from 0.
+	Attempt int `json:"attempt"`
+}
+
+// pack job into the file content.
+func pack(id string, j *jobs.Job, attempt int) ([]byte, error) {
+	return json.Marshal(&record{ID: id, Job: j.Job, Payload: j.Payload, Options: j.Options, Attempt: attempt})
+}
+
+// unpack job from the file content.
+func unpack(data []byte) (*record, *jobs.Job, error) {
+	r := &record{}
+	if err := json.Unmarshal(data, r); err != nil {
+		return nil, nil, err
+	}
+
+	if r.Options == nil {
+		r.Options = &jobs.Options{}
+	}
+
+	return r, &jobs.Job{Job: r.Job, Payload: r.Payload, Options: r.Options}, nil
+}
+
+// name is job file name in a form of `available.id`, reserved files are suffixed with reservation deadline
+// (`available.id.deadline`). All times are unix nano, names are sorted in order of availability.
+type name struct {
+	available time.Time
+	id        string
+	deadline  time.Time
+}
+
+// String returns file name.
+func (n name) String() string {
+	s := fmt.Sprintf("%020d.%s", n.available.UnixNano(), n.id)
+	if !n.deadline.IsZero() {
+		s += fmt.Sprintf(".%d", n.deadline.UnixNano())
+	}
+
+	return s
+}
+
+// job returns file name without reservation deadline.
+func (n name) job() string {
+	return name{available: n.available, id: n.id}.String()
+}
+
+// parseName parses job file name.
+func parseName(s string) (name, error) {
+	parts := strings.Split(s, ".")
+	if len(parts) < 2 || len(parts) > 3 {
+		return name{}, fmt.Errorf("invalid job file name `%s`", s)
+	}
+
+	available, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return name{}, fmt.Errorf("invalid job file name `%s`", s)
+	}
+
+	n := name{available: time.Unix(0, available), id: parts[1]}
+
+	if len(parts) == 3 {
+		deadline, err := strconv.ParseInt(parts[2], 10, 64)
+		if err != nil {
+			return name{}, fmt.Errorf("invalid job file name `%s`", s)
+		}
+
+		n.deadline = time.Unix(0, deadline)
+	}
+
+	return n, nil
+}
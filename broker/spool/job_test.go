@@ -0,0 +1,31 @@
+package spool
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestName(t *testing.T) {
+	n := name{available: time.Unix(0, 100), id: "id"}
+	assert.Equal(t, "00000000000000000100.id", n.String())
+
+	n.deadline = time.Unix(0, 200)
+	assert.Equal(t, "00000000000000000100.id.200", n.String())
+	assert.Equal(t, "00000000000000000100.id", n.job())
+
+	parsed, err := parseName(n.String())
+	assert.NoError(t, err)
+	assert.Equal(t, n, parsed)
+
+	parsed, err = parseName(n.job())
+	assert.NoError(t, err)
+	assert.True(t, parsed.deadline.IsZero())
+}
+
+func TestName_Invalid(t *testing.T) {
+	for _, s := range []string{"", "id", "abc.id", "100.id.abc", "100.id.200.300"} {
+		_, err := parseName(s)
+		assert.Error(t, err, s)
+	}
+}
@@ -0,0 +1,522 @@
+package spool
+
+import (
+	"fmt"
+	"github.com/fsnotify/fsnotify"
+	"github.com/gofrs/uuid"
+	"github.com/spiral/jobs/v2"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// files being written, moved into new or delayed once complete
+	dirTmp = "tmp"
+
+	// jobs ready to be consumed
+	dirNew = "new"
+
+	// reserved jobs
+	dirCur = "cur"
+
+	// jobs waiting for their delay or retry
+	dirDelayed = "delayed"
+
+	// jobs which exhausted their attempts or can not be read
+	dirFailed = "failed"
+)
+
+type queue struct {
+	active int32
+	pipe   *jobs.Pipeline
+	dir    string
+	poll   time.Duration
+
+	// queue events
+	lsn func(event int, ctx interface{})
+
+	// wakes consumer when new jobs arrive
+	wake chan interface{}
+
+	// stop channel
+	wait chan interface{}
+
+	// active operations
+	muw sync.RWMutex
+	wg  sync.WaitGroup
+
+	// exec handlers
+	execPool   chan jobs.Handler
+	errHandler jobs.ErrorHandler
+}
+
+// entry is reserved job file.
+type entry struct {
+	name   name
+	record *record
+	job    *jobs.Job
+}
+
+// newQueue creates new spool directory wrapper.
+func newQueue(pipe *jobs.Pipeline, root string, lsn func(event int, ctx interface{})) *queue {
+	dir := pipe.String("dir", pipe.Name())
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(root, dir)
+	}
+
+	return &queue{
+		pipe: pipe,
+		dir:  dir,
+		poll: pipe.Duration("poll", time.Second),
+		lsn:  lsn,
+		wake: make(chan interface{}, 1),
+	}
+}
+
+// path returns path of the file in given spool sub directory.
+func (q *queue) path(sub string, file string) string {
+	return filepath.Join(q.dir, sub, file)
+}
+
+// declare creates spool directories.
+func (q *queue) declare() error {
+	for _, sub := range []string{dirTmp, dirNew, dirCur, dirDelayed, dirFailed} {
+		if err := os.MkdirAll(filepath.Join(q.dir, sub), 0755); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// watch wakes consumer on changes in new jobs directory until stopped. Consumer falls back to polling when
+// notifications are not available.
+func (q *queue) watch(stop chan interface{}) {
+	w, err := fsnotify.NewWatcher()
+	if err != nil {
+		q.report(err)
+		return
+	}
+	defer w.Close()
+
+	if err := w.Add(filepath.Join(q.dir, dirNew)); err != nil {
+		q.report(err)
+		return
+	}
+
+	for {
+		select {
+		case <-stop:
+			return
+		case _, ok := <-w.Events:
+			if !ok {
+				return
+			}
+
+			select {
+			case q.wake <- nil:
+			default:
+			}
+		case err, ok := <-w.Errors:
+			if !ok {
+				return
+			}
+
+			q.report(err)
+		}
+	}
+}
+
+// maintain moves due delayed jobs and recovers expired reservations until stopped.
+func (q *queue) maintain(stop chan interface{}) {
+	ticker := time.NewTicker(q.poll)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case now := <-ticker.C:
+			q.report(q.schedule(now))
+			q.report(q.recover(now))
+		}
+	}
+}
+
+// schedule moves due delayed jobs into new jobs directory.
+func (q *queue) schedule(now time.Time) error {
+	files, err := ioutil.ReadDir(filepath.Join(q.dir, dirDelayed))
+	if err != nil {
+		return err
+	}
+
+	for _, f := range files {
+		n, err := parseName(f.Name())
+		if err != nil {
+			q.report(q.fail(dirDelayed, f.Name(), err))
+			continue
+		}
+
+		if n.available.After(now) {
+			// files are sorted by availability
+			return nil
+		}
+
+		if err := q.move(dirDelayed, f.Name(), dirNew, f.Name()); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// recover moves jobs with expired reservation back to new jobs directory with the next attempt number,
+// reservations of dead consumers expire once job timeout is reached.
+func (q *queue) recover(now time.Time) error {
+	files, err := ioutil.ReadDir(filepath.Join(q.dir, dirCur))
+	if err != nil {
+		return err
+	}
+
+	for _, f := range files {
+		n, err := parseName(f.Name())
+		if err != nil {
+			q.report(q.fail(dirCur, f.Name(), err))
+			continue
+		}
+
+		if n.deadline.After(now) {
+			continue
+		}
+
+		if err := q.abandon(f.Name(), n); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// abandon counts expired reservation as failed attempt, job is retried right away or moved into failed jobs
+// directory when it was the last attempt. File is claimed by moving it into temporary directory first, so the
+// job is recovered by one process only.
+func (q *queue) abandon(file string, n name) error {
+	if err := os.Rename(q.path(dirCur, file), q.path(dirTmp, file)); err != nil {
+		if os.IsNotExist(err) {
+			// completed or recovered by another process
+			return nil
+		}
+
+		return err
+	}
+
+	data, err := ioutil.ReadFile(q.path(dirTmp, file))
+	if err != nil {
+		return err
+	}
+
+	r, j, err := unpack(data)
+	if err != nil {
+		return q.fail(dirTmp, file, err)
+	}
+
+	if !j.Options.CanRetry(r.Attempt) {
+		q.report(fmt.Errorf("reservation of job `%s` has expired on the last attempt", r.ID))
+		return q.move(dirTmp, file, dirFailed, n.job())
+	}
+
+	if err := q.write(r.ID, j, r.Attempt+1, n.available); err != nil {
+		return err
+	}
+
+	return q.remove(dirTmp, file)
+}
+
+// start consuming in background, queue is marked as consumed before the method returns so it can be stopped
+// right away.
+func (q *queue) start(tout time.Duration) {
+	q.wait = make(chan interface{})
+	atomic.StoreInt32(&q.active, 1)
+
+	go q.serve(tout, q.wait)
+}
+
+// serve consumers till given channel is closed.
+func (q *queue) serve(tout time.Duration, wait chan interface{}) {
+	var errored bool
+	for {
+		entries, stop, err := q.consume(wait)
+		if err != nil {
+			if errored {
+				// reoccurring error
+				time.Sleep(tout)
+			} else {
+				errored = true
+				q.report(err)
+			}
+
+			continue
+		}
+		errored = false
+
+		if stop {
+			return
+		}
+
+		for _, e := range entries {
+			h := <-q.execPool
+			go func(h jobs.Handler, e *entry) {
+				err := q.do(h, e)
+				q.execPool <- h
+				q.wg.Done()
+				q.report(err)
+			}(h, e)
+		}
+	}
+}
+
+// consume reserves ready jobs or waits for new jobs to arrive.
+func (q *queue) consume(wait chan interface{}) ([]*entry, bool, error) {
+	q.muw.Lock()
+	defer q.muw.Unlock()
+
+	select {
+	case <-wait:
+		return nil, true, nil
+	default:
+		entries, err := q.reserve(q.pipe.Integer("prefetch", 1))
+		if err != nil {
+			return nil, false, err
+		}
+
+		if len(entries) == 0 {
+			timer := time.NewTimer(q.poll)
+			defer timer.Stop()
+
+			select {
+			case <-wait:
+				return nil, true, nil
+			case <-q.wake:
+			case <-timer.C:
+			}
+
+			return nil, false, nil
+		}
+
+		q.wg.Add(len(entries))
+
+		return entries, false, nil
+	}
+}
+
+// reserve moves ready jobs into reserved directory, rename is atomic so only one consumer can reserve the job.
+func (q *queue) reserve(limit int) ([]*entry, error) {
+	files, err := ioutil.ReadDir(filepath.Join(q.dir, dirNew))
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []*entry
+	for _, f := range files {
+		if len(entries) >= limit {
+			break
+		}
+
+		n, err := parseName(f.Name())
+		if err != nil {
+			q.report(q.fail(dirNew, f.Name(), err))
+			continue
+		}
+
+		data, err := ioutil.ReadFile(q.path(dirNew, f.Name()))
+		if err != nil {
+			if os.IsNotExist(err) {
+				// reserved by another consumer
+				continue
+			}
+
+			return entries, err
+		}
+
+		r, j, err := unpack(data)
+		if err != nil {
+			q.report(q.fail(dirNew, f.Name(), err))
+			continue
+		}
+
+		n.deadline = time.Now().Add(j.Options.TimeoutDuration())
+		if err := os.Rename(q.path(dirNew, f.Name()), q.path(dirCur, n.String())); err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+
+			return entries, err
+		}
+
+		entries = append(entries, &entry{name: n, record: r, job: j})
+	}
+
+	return entries, nil
+}
+
+// do single job
+func (q *queue) do(h jobs.Handler, e *entry) error {
+	e.job.Delivery = &jobs.Delivery{Pipeline: q.pipe.Name(), Attempt: e.record.Attempt, Queued: e.name.available}
+
+	err := h(e.record.ID, e.job)
+	if err == nil {
+		return q.remove(dirCur, e.name.String())
+	}
+
+	if err == jobs.ErrRelease {
+		if err := q.write(e.record.ID, e.job, e.record.Attempt, time.Now()); err != nil {
+			return err
+		}
+
+		return q.remove(dirCur, e.name.String())
+	}
+
+	q.errHandler(e.record.ID, e.job, err)
+
+	if !e.job.Options.CanRetry(e.record.Attempt) {
+		return q.move(dirCur, e.name.String(), dirFailed, e.name.job())
+	}
+
+	available := time.Now().Add(e.job.Options.RetryDuration())
+	if err := q.write(e.record.ID, e.job, e.record.Attempt+1, available); err != nil {
+		return err
+	}
+
+	if err := q.remove(dirCur, e.name.String()); err != nil {
+		return err
+	}
+
+	q.lsn(jobs.EventJobRelease, &jobs.JobEvent{
+		ID:       e.record.ID,
+		Job:      e.job,
+		Pipeline: q.pipe.Name(),
+		Attempt:  e.record.Attempt + 1,
+	})
+
+	return nil
+}
+
+// stop the queue consuming
+func (q *queue) stop() {
+	if atomic.LoadInt32(&q.active) == 0 {
+		return
+	}
+
+	atomic.StoreInt32(&q.active, 0)
+
+	close(q.wait)
+	q.muw.Lock()
+	q.wg.Wait()
+	q.muw.Unlock()
+}
+
+// push job into the spool.
+func (q *queue) push(j *jobs.Job) (string, error) {
+	id, err := uuid.NewV4()
+	if err != nil {
+		return "", err
+	}
+
+	if err := q.write(id.String(), j, 0, time.Now().Add(j.Options.DelayDuration())); err != nil {
+		return "", err
+	}
+
+	return id.String(), nil
+}
+
+// write job file into temporary directory and move it into new or delayed jobs directory once it's complete.
+func (q *queue) write(id string, j *jobs.Job, attempt int, available time.Time) error {
+	data, err := pack(id, j, attempt)
+	if err != nil {
+		return err
+	}
+
+	n := name{available: available, id: id}
+
+	f, err := os.OpenFile(q.path(dirTmp, n.String()), os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return err
+	}
+
+	if err := f.Sync(); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return err
+	}
+
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+
+	target := dirNew
+	if available.After(time.Now()) {
+		target = dirDelayed
+	}
+
+	return q.move(dirTmp, n.String(), target, n.String())
+}
+
+// move file between spool directories, files moved by another process are ignored.
+func (q *queue) move(from, file, to, target string) error {
+	err := os.Rename(q.path(from, file), q.path(to, target))
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	return nil
+}
+
+// remove file, files moved by another process are ignored.
+func (q *queue) remove(from, file string) error {
+	err := os.Remove(q.path(from, file))
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	return nil
+}
+
+// fail reports the reason and moves unreadable file into failed jobs directory.
+func (q *queue) fail(from, file string, err error) error {
+	q.report(err)
+	return q.move(from, file, dirFailed, file)
+}
+
+// stat returns number of files in spool directories.
+func (q *queue) stat() (*jobs.Stat, error) {
+	stat := &jobs.Stat{InternalName: q.dir}
+
+	for sub, n := range map[string]*int64{dirNew: &stat.Queue, dirCur: &stat.Active, dirDelayed: &stat.Delayed} {
+		files, err := ioutil.ReadDir(filepath.Join(q.dir, sub))
+		if err != nil {
+			return nil, err
+		}
+
+		*n = int64(len(files))
+	}
+
+	return stat, nil
+}
+
+// report queue specific error
+func (q *queue) report(err error) {
+	if err != nil {
+		q.lsn(jobs.EventPipeError, &jobs.PipelineError{Pipeline: q.pipe, Caused: err})
+	}
+}
@@ -0,0 +1,155 @@
+package sql
+
+import (
+	"database/sql"
+	"github.com/spiral/jobs/v2"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+var (
+	pipe = &jobs.Pipeline{
+		"broker": "sql",
+		"name":   "default",
+		"table":  "jobs",
+		"poll":   1,
+	}
+)
+
+// newBroker creates broker storing jobs in sqlite database within given directory.
+func newBroker(t *testing.T, dir string) *Broker {
+	b := &Broker{}
+	_, err := b.Init(&Config{
+		Driver: "sqlite3",
+		DSN:    "file:" + filepath.Join(dir, "jobs.db") + "?_busy_timeout=5000",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return b
+}
+
+// tempDir creates temporary directory for the sqlite database.
+func tempDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "rr-sql")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return dir
+}
+
+// serve broker and wait till it's ready.
+func serve(t *testing.T, b *Broker) {
+	ready := make(chan interface{})
+	b.Listen(func(event int, ctx interface{}) {
+		if event == jobs.EventBrokerReady {
+			close(ready)
+		}
+	})
+
+	go func() { assert.NoError(t, b.Serve()) }()
+	<-ready
+}
+
+// open additional database handle.
+func open(t *testing.T, b *Broker) *sql.DB {
+	db, err := sql.Open(b.cfg.Driver, b.cfg.DSN)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return db
+}
+
+func TestBroker_Init(t *testing.T) {
+	b := &Broker{}
+	ok, err := b.Init(&Config{Driver: "postgres", DSN: "postgres://localhost/jobs"})
+	assert.True(t, ok)
+	assert.NoError(t, err)
+}
+
+func TestBroker_Init_Unsupported(t *testing.T) {
+	b := &Broker{}
+	ok, err := b.Init(&Config{Driver: "oracle", DSN: "oracle://localhost/jobs"})
+	assert.False(t, ok)
+	assert.Error(t, err)
+}
+
+func TestBroker_StopNotStarted(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	b := newBroker(t, dir)
+	b.Stop()
+}
+
+func TestBroker_Register(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	b := newBroker(t, dir)
+	assert.NoError(t, b.Register(pipe))
+}
+
+func TestBroker_Register_Twice(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	b := newBroker(t, dir)
+	assert.NoError(t, b.Register(pipe))
+	assert.Error(t, b.Register(pipe))
+}
+
+func TestBroker_Register_Invalid(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	b := newBroker(t, dir)
+	assert.Error(t, b.Register(&jobs.Pipeline{"broker": "sql", "name": "default"}))
+}
+
+func TestBroker_Consume_Undefined(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	b := newBroker(t, dir)
+	assert.Error(t, b.Consume(pipe, nil, nil))
+}
+
+func TestBroker_Push_NotServing(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	b := newBroker(t, dir)
+	assert.NoError(t, b.Register(pipe))
+
+	_, err := b.Push(pipe, &jobs.Job{Job: "test", Payload: "body", Options: &jobs.Options{}})
+	assert.Error(t, err)
+
+	_, err = b.Stat(pipe)
+	assert.Error(t, err)
+	assert.Error(t, b.Health())
+}
+
+func TestBroker_Health(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	b := newBroker(t, dir)
+	assert.NoError(t, b.Register(pipe))
+
+	serve(t, b)
+	defer b.Stop()
+
+	assert.NoError(t, b.Health())
+
+	_, err := b.Push(&jobs.Pipeline{"name": "other"}, &jobs.Job{Job: "test", Payload: "body", Options: &jobs.Options{}})
+	assert.Error(t, err)
+}
@@ -0,0 +1,79 @@
+package sql
+
+import (
+	"database/sql"
+	"fmt"
+	"github.com/spiral/roadrunner/service"
+	"time"
+)
+
+// Config defines sql broker configuration.
+type Config struct {
+	// Driver is database/sql driver name (postgres, mysql or sqlite3).
+	Driver string
+
+	// DSN is driver specific data source name.
+	DSN string
+
+	// Timeout to allocate the connection. Default 10 seconds.
+	Timeout int
+}
+
+// Hydrate config values.
+func (c *Config) Hydrate(cfg service.Config) error {
+	if err := cfg.Unmarshal(c); err != nil {
+		return err
+	}
+
+	if c.Driver == "" {
+		return fmt.Errorf("sql driver is missing")
+	}
+
+	if c.DSN == "" {
+		return fmt.Errorf("sql dsn is missing")
+	}
+
+	_, err := c.dialect()
+
+	return err
+}
+
+// TimeoutDuration returns number of seconds allowed to allocate the connection.
+func (c *Config) TimeoutDuration() time.Duration {
+	timeout := c.Timeout
+	if timeout == 0 {
+		timeout = 10
+	}
+
+	return time.Duration(timeout) * time.Second
+}
+
+// dialect returns sql dialect associated with the driver.
+func (c *Config) dialect() (*dialect, error) {
+	d, ok := dialects[c.Driver]
+	if !ok {
+		return nil, fmt.Errorf("unsupported sql driver `%s`", c.Driver)
+	}
+
+	return d, nil
+}
+
+// open database handle.
+func (c *Config) open() (*sql.DB, error) {
+	d, err := c.dialect()
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := sql.Open(c.Driver, c.DSN)
+	if err != nil {
+		return nil, err
+	}
+
+	if d.single {
+		// sqlite does not support concurrent writers
+		db.SetMaxOpenConns(1)
+	}
+
+	return db, nil
+}
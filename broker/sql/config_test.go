@@ -0,0 +1,66 @@
+package sql
+
+import (
+	json "github.com/json-iterator/go"
+	"github.com/spiral/roadrunner/service"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+type mockCfg struct{ cfg string }
+
+func (cfg *mockCfg) Get(name string) service.Config  { return nil }
+func (cfg *mockCfg) Unmarshal(out interface{}) error { return json.Unmarshal([]byte(cfg.cfg), out) }
+
+func Test_Config_Hydrate_Error(t *testing.T) {
+	cfg := &mockCfg{`{"dead`}
+	c := &Config{}
+
+	assert.Error(t, c.Hydrate(cfg))
+}
+
+func Test_Config_Hydrate_Error2(t *testing.T) {
+	cfg := &mockCfg{`{"dsn":"jobs.db"}`}
+	c := &Config{}
+
+	assert.Error(t, c.Hydrate(cfg))
+}
+
+func Test_Config_Hydrate_Error3(t *testing.T) {
+	cfg := &mockCfg{`{"driver":"sqlite3"}`}
+	c := &Config{}
+
+	assert.Error(t, c.Hydrate(cfg))
+}
+
+func Test_Config_Hydrate_Error4(t *testing.T) {
+	cfg := &mockCfg{`{"driver":"oracle","dsn":"oracle://localhost"}`}
+	c := &Config{}
+
+	assert.Error(t, c.Hydrate(cfg))
+}
+
+func Test_Config_Hydrate(t *testing.T) {
+	cfg := &mockCfg{`{"driver":"postgres","dsn":"postgres://localhost/jobs"}`}
+	c := &Config{}
+
+	assert.NoError(t, c.Hydrate(cfg))
+	assert.Equal(t, "postgres", c.Driver)
+}
+
+func Test_Config_TimeoutDuration(t *testing.T) {
+	cfg := &mockCfg{`{"driver":"mysql","dsn":"root@/jobs"}`}
+	c := &Config{}
+
+	assert.NoError(t, c.Hydrate(cfg))
+	assert.Equal(t, time.Second*10, c.TimeoutDuration())
+}
+
+func Test_Config_TimeoutDurationCustom(t *testing.T) {
+	cfg := &mockCfg{`{"driver":"mysql","dsn":"root@/jobs","timeout":1}`}
+	c := &Config{}
+
+	assert.NoError(t, c.Hydrate(cfg))
+	assert.Equal(t, time.Second*1, c.TimeoutDuration())
+}
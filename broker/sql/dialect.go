@@ -0,0 +1,98 @@
+package sql
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// dialect describes database specific sql syntax.
+type dialect struct {
+	// numbered placeholders ($1, $2) instead of question marks
+	numbered bool
+
+	// lock clause to reserve rows without blocking other consumers
+	lock string
+
+	// single connection database
+	single bool
+
+	// schema returns statements to create jobs table
+	schema func(table string) []string
+}
+
+// dialects associated with driver names.
+var dialects = map[string]*dialect{
+	"postgres": {
+		numbered: true,
+		lock:     " FOR UPDATE SKIP LOCKED",
+		schema:   schema("TEXT", true),
+	},
+	"mysql": {
+		lock:   " FOR UPDATE SKIP LOCKED",
+		schema: schema("LONGTEXT", false),
+	},
+	"sqlite3": {
+		single: true,
+		schema: schema("TEXT", true),
+	},
+}
+
+// bind converts question mark placeholders into dialect specific ones.
+func (d *dialect) bind(query string) string {
+	if !d.numbered {
+		return query
+	}
+
+	var (
+		b strings.Builder
+		n int
+	)
+
+	for _, r := range query {
+		if r == '?' {
+			n++
+			b.WriteString("$" + strconv.Itoa(n))
+			continue
+		}
+
+		b.WriteRune(r)
+	}
+
+	return b.String()
+}
+
+// schema creates table statements using given text type, index is created separately when supported.
+func schema(text string, separateIndex bool) func(table string) []string {
+	return func(table string) []string {
+		columns := []string{
+			"id VARCHAR(36) NOT NULL PRIMARY KEY",
+			"queue VARCHAR(255) NOT NULL",
+			"job VARCHAR(255) NOT NULL",
+			"payload " + text + " NOT NULL",
+			"options " + text + " NOT NULL",
+			"attempts INTEGER NOT NULL DEFAULT 0",
+			"created_at BIGINT NOT NULL",
+			"available_at BIGINT NOT NULL",
+			"reserved_at BIGINT NULL",
+		}
+
+		index := fmt.Sprintf("%s_queue_available", table)
+		if !separateIndex {
+			columns = append(columns, fmt.Sprintf("INDEX %s (queue, available_at)", index))
+		}
+
+		statements := []string{
+			fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (%s)", table, strings.Join(columns, ", ")),
+		}
+
+		if separateIndex {
+			statements = append(
+				statements,
+				fmt.Sprintf("CREATE INDEX IF NOT EXISTS %s ON %s (queue, available_at)", index, table),
+			)
+		}
+
+		return statements
+	}
+}
@@ -0,0 +1,27 @@
+package sql
+
+import (
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+func TestDialect_Bind(t *testing.T) {
+	query := "SELECT id FROM jobs WHERE queue = ? AND available_at <= ?"
+
+	assert.Equal(t, "SELECT id FROM jobs WHERE queue = $1 AND available_at <= $2", dialects["postgres"].bind(query))
+	assert.Equal(t, query, dialects["mysql"].bind(query))
+	assert.Equal(t, query, dialects["sqlite3"].bind(query))
+}
+
+func TestDialect_Schema(t *testing.T) {
+	statements := dialects["postgres"].schema("jobs")
+	assert.Len(t, statements, 2)
+	assert.True(t, strings.HasPrefix(statements[0], "CREATE TABLE IF NOT EXISTS jobs ("))
+	assert.Equal(t, "CREATE INDEX IF NOT EXISTS jobs_queue_available ON jobs (queue, available_at)", statements[1])
+
+	statements = dialects["mysql"].schema("jobs")
+	assert.Len(t, statements, 1)
+	assert.Contains(t, statements[0], "payload LONGTEXT NOT NULL")
+	assert.Contains(t, statements[0], "INDEX jobs_queue_available (queue, available_at)")
+}
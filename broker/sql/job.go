@@ -0,0 +1,55 @@
+package sql
+
+import (
+	json "github.com/json-iterator/go"
+	"github.com/spiral/jobs/v2"
+	"time"
+)
+
+// row is reserved job row.
+type row struct {
+	id       string
+	job      string
+	payload  string
+	options  string
+	attempts int
+
+	// available defines when job became available (unix milliseconds).
+	available int64
+
+	// reserved defines reservation time which identifies consumer owning the row (unix milliseconds).
+	reserved int64
+
+	// abandoned indicates that previous reservation of the row has expired without the job being finished,
+	// abandoned reservation is counted as failed attempt.
+	abandoned bool
+}
+
+// unpack job from the row.
+func (r *row) unpack() (*jobs.Job, error) {
+	j := &jobs.Job{Job: r.job, Payload: r.payload, Options: &jobs.Options{}}
+	if err := json.UnmarshalFromString(r.options, j.Options); err != nil {
+		return nil, err
+	}
+
+	return j, nil
+}
+
+// packOptions encodes job options into the column value.
+func packOptions(o *jobs.Options) (string, error) {
+	if o == nil {
+		o = &jobs.Options{}
+	}
+
+	return json.MarshalToString(o)
+}
+
+// millis returns time as unix milliseconds.
+func millis(t time.Time) int64 {
+	return t.UnixNano() / int64(time.Millisecond)
+}
+
+// fromMillis returns time from unix milliseconds.
+func fromMillis(ms int64) time.Time {
+	return time.Unix(0, ms*int64(time.Millisecond))
+}
@@ -0,0 +1,387 @@
+package sql
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/gofrs/uuid"
+	"github.com/spiral/jobs/v2"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// errAbandoned reported for jobs which run out of attempts due to expired reservations.
+var errAbandoned = errors.New("job reservation has expired, consumer did not finish the job")
+
+// execer executes statements, implemented by both *sql.DB and *sql.Tx.
+type execer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+}
+
+type queue struct {
+	active int32
+	pipe   *jobs.Pipeline
+	d      *dialect
+	table  string
+	name   string
+	poll   time.Duration
+
+	// queue events
+	lsn func(event int, ctx interface{})
+
+	// stop channel
+	wait chan interface{}
+
+	// active operations
+	muw sync.RWMutex
+	wg  sync.WaitGroup
+
+	// exec handlers
+	execPool   chan jobs.Handler
+	errHandler jobs.ErrorHandler
+}
+
+// newQueue creates new table wrapper.
+func newQueue(pipe *jobs.Pipeline, d *dialect, lsn func(event int, ctx interface{})) (*queue, error) {
+	if pipe.String("table", "") == "" {
+		return nil, fmt.Errorf("missing `table` parameter on sql pipeline `%s`", pipe.Name())
+	}
+
+	return &queue{
+		pipe:  pipe,
+		d:     d,
+		table: pipe.String("table", ""),
+		name:  pipe.String("queue", pipe.Name()),
+		poll:  pipe.Duration("poll", time.Second),
+		lsn:   lsn,
+	}, nil
+}
+
+// declare creates jobs table unless disabled by the pipeline.
+func (q *queue) declare(db *sql.DB) error {
+	if !q.pipe.Bool("declare", true) {
+		return nil
+	}
+
+	for _, statement := range q.d.schema(q.table) {
+		if _, err := db.Exec(statement); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// start consuming in background, queue is marked as consumed before the method returns so it can be stopped
+// right away.
+func (q *queue) start(db *sql.DB, tout time.Duration) {
+	q.wait = make(chan interface{})
+	atomic.StoreInt32(&q.active, 1)
+
+	go q.serve(db, tout, q.wait)
+}
+
+// serve consumers till given channel is closed.
+func (q *queue) serve(db *sql.DB, tout time.Duration, wait chan interface{}) {
+	var errored bool
+	for {
+		rows, stop, err := q.consume(db, wait)
+		if err != nil {
+			if errored {
+				// reoccurring error
+				time.Sleep(tout)
+			} else {
+				errored = true
+				q.report(err)
+			}
+
+			continue
+		}
+		errored = false
+
+		if stop {
+			return
+		}
+
+		for _, r := range rows {
+			h := <-q.execPool
+			go func(h jobs.Handler, r *row) {
+				err := q.do(db, h, r)
+				q.execPool <- h
+				q.wg.Done()
+				q.report(err)
+			}(h, r)
+		}
+	}
+}
+
+// consume reserves available rows or waits for the next poll.
+func (q *queue) consume(db *sql.DB, wait chan interface{}) ([]*row, bool, error) {
+	q.muw.Lock()
+	defer q.muw.Unlock()
+
+	select {
+	case <-wait:
+		return nil, true, nil
+	default:
+		rows, err := q.reserve(db, q.pipe.Integer("prefetch", 1))
+		if err != nil {
+			return nil, false, err
+		}
+
+		if len(rows) == 0 {
+			timer := time.NewTimer(q.poll)
+			defer timer.Stop()
+
+			select {
+			case <-wait:
+				return nil, true, nil
+			case <-timer.C:
+				return nil, false, nil
+			}
+		}
+
+		q.wg.Add(len(rows))
+
+		return rows, false, nil
+	}
+}
+
+// reserve available rows, rows are hidden from other consumers till the job timeout is reached. Rows reserved by
+// dead consumers become available again once their timeout is over.
+func (q *queue) reserve(db *sql.DB, limit int) ([]*row, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	now := millis(time.Now())
+
+	result, err := tx.Query(q.d.bind(fmt.Sprintf(
+		"SELECT id, job, payload, options, attempts, available_at, reserved_at FROM %s "+
+			"WHERE queue = ? AND available_at <= ? ORDER BY available_at, id LIMIT %d%s",
+		q.table,
+		limit,
+		q.d.lock,
+	)), q.name, now)
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []*row
+	for result.Next() {
+		r := &row{}
+		var reserved sql.NullInt64
+		if err := result.Scan(&r.id, &r.job, &r.payload, &r.options, &r.attempts, &r.available, &reserved); err != nil {
+			result.Close()
+			return nil, err
+		}
+
+		if reserved.Valid {
+			// consumer has crashed or has been killed while running the job
+			r.abandoned = true
+			r.attempts++
+		}
+
+		rows = append(rows, r)
+	}
+	result.Close()
+
+	if err := result.Err(); err != nil {
+		return nil, err
+	}
+
+	reserved := make([]*row, 0, len(rows))
+	for _, r := range rows {
+		timeout := time.Duration(0)
+		if j, err := r.unpack(); err == nil {
+			timeout = j.Options.TimeoutDuration()
+		}
+
+		// rows are matched by availability to skip rows reserved concurrently when locks are not supported
+		res, err := tx.Exec(
+			q.d.bind(fmt.Sprintf(
+				"UPDATE %s SET reserved_at = ?, available_at = ?, attempts = ? WHERE id = ? AND available_at = ?",
+				q.table,
+			)),
+			now,
+			now+int64(timeout/time.Millisecond),
+			r.attempts,
+			r.id,
+			r.available,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		if n, err := res.RowsAffected(); err != nil || n == 0 {
+			continue
+		}
+
+		r.reserved = now
+		reserved = append(reserved, r)
+	}
+
+	return reserved, tx.Commit()
+}
+
+// do single row
+func (q *queue) do(db *sql.DB, h jobs.Handler, r *row) error {
+	j, err := r.unpack()
+	if err != nil {
+		q.delete(db, r)
+		return err
+	}
+
+	j.Delivery = &jobs.Delivery{Pipeline: q.pipe.Name(), Attempt: r.attempts, Queued: fromMillis(r.available)}
+
+	if r.abandoned && !j.Options.CanRetry(r.attempts-1) {
+		// abandoned attempt was the last one
+		j.Delivery.Attempt = r.attempts - 1
+		q.errHandler(r.id, j, errAbandoned)
+
+		return q.delete(db, r)
+	}
+
+	err = h(r.id, j)
+	if err == nil {
+		return q.delete(db, r)
+	}
+
+	if err == jobs.ErrRelease {
+		_, err = db.Exec(
+			q.d.bind(fmt.Sprintf(
+				"UPDATE %s SET available_at = ?, reserved_at = NULL WHERE id = ? AND reserved_at = ?",
+				q.table,
+			)),
+			millis(time.Now()),
+			r.id,
+			r.reserved,
+		)
+
+		return err
+	}
+
+	q.errHandler(r.id, j, err)
+
+	if !j.Options.CanRetry(r.attempts) {
+		return q.delete(db, r)
+	}
+
+	_, err = db.Exec(
+		q.d.bind(fmt.Sprintf(
+			"UPDATE %s SET attempts = attempts + 1, available_at = ?, reserved_at = NULL WHERE id = ? AND reserved_at = ?",
+			q.table,
+		)),
+		millis(time.Now().Add(j.Options.RetryDuration())),
+		r.id,
+		r.reserved,
+	)
+	if err != nil {
+		return err
+	}
+
+	q.lsn(jobs.EventJobRelease, &jobs.JobEvent{ID: r.id, Job: j, Pipeline: q.pipe.Name(), Attempt: r.attempts + 1})
+
+	return nil
+}
+
+// delete the row unless it was reserved by another consumer.
+func (q *queue) delete(db *sql.DB, r *row) error {
+	_, err := db.Exec(
+		q.d.bind(fmt.Sprintf("DELETE FROM %s WHERE id = ? AND reserved_at = ?", q.table)),
+		r.id,
+		r.reserved,
+	)
+
+	return err
+}
+
+// stop the queue consuming
+func (q *queue) stop() {
+	if atomic.LoadInt32(&q.active) == 0 {
+		return
+	}
+
+	atomic.StoreInt32(&q.active, 0)
+
+	close(q.wait)
+	q.muw.Lock()
+	q.wg.Wait()
+	q.muw.Unlock()
+}
+
+// push job into the table using given database handle or transaction.
+func (q *queue) push(e execer, j *jobs.Job) (string, error) {
+	id, err := uuid.NewV4()
+	if err != nil {
+		return "", err
+	}
+
+	options, err := packOptions(j.Options)
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+
+	_, err = e.Exec(
+		q.d.bind(fmt.Sprintf(
+			"INSERT INTO %s (id, queue, job, payload, options, attempts, created_at, available_at) "+
+				"VALUES (?, ?, ?, ?, ?, 0, ?, ?)",
+			q.table,
+		)),
+		id.String(),
+		q.name,
+		j.Job,
+		j.Payload,
+		options,
+		millis(now),
+		millis(now.Add(j.Options.DelayDuration())),
+	)
+	if err != nil {
+		return "", err
+	}
+
+	return id.String(), nil
+}
+
+// stat returns table stats, rows with expired reservation are reported as queued.
+func (q *queue) stat(db *sql.DB) (*jobs.Stat, error) {
+	var queued, active, delayed sql.NullInt64
+
+	now := millis(time.Now())
+	err := db.QueryRow(
+		q.d.bind(fmt.Sprintf(
+			"SELECT "+
+				"SUM(CASE WHEN available_at <= ? THEN 1 ELSE 0 END), "+
+				"SUM(CASE WHEN available_at > ? AND reserved_at IS NOT NULL THEN 1 ELSE 0 END), "+
+				"SUM(CASE WHEN available_at > ? AND reserved_at IS NULL THEN 1 ELSE 0 END) "+
+				"FROM %s WHERE queue = ?",
+			q.table,
+		)),
+		now,
+		now,
+		now,
+		q.name,
+	).Scan(&queued, &active, &delayed)
+	if err != nil {
+		return nil, err
+	}
+
+	return &jobs.Stat{
+		InternalName: q.table,
+		Queue:        queued.Int64,
+		Active:       active.Int64,
+		Delayed:      delayed.Int64,
+	}, nil
+}
+
+// report queue specific error
+func (q *queue) report(err error) {
+	if err != nil {
+		q.lsn(jobs.EventPipeError, &jobs.PipelineError{Pipeline: q.pipe, Caused: err})
+	}
+}
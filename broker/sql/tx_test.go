@@ -0,0 +1,74 @@
+package sql
+
+import (
+	"github.com/spiral/jobs/v2"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"testing"
+)
+
+func TestBroker_PushTx(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	b := newBroker(t, dir)
+	assert.NoError(t, b.Register(pipe))
+
+	serve(t, b)
+	defer b.Stop()
+
+	db := open(t, b)
+	defer db.Close()
+
+	_, err := db.Exec("CREATE TABLE orders (id INTEGER PRIMARY KEY)")
+	assert.NoError(t, err)
+
+	// rolled back job is never queued
+	tx, err := db.Begin()
+	assert.NoError(t, err)
+
+	_, err = tx.Exec("INSERT INTO orders (id) VALUES (1)")
+	assert.NoError(t, err)
+
+	_, err = b.PushTx(tx, pipe, &jobs.Job{Job: "test", Payload: "rollback", Options: &jobs.Options{}})
+	assert.NoError(t, err)
+	assert.NoError(t, tx.Rollback())
+
+	stat, err := b.Stat(pipe)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), stat.Queue)
+
+	// committed job is queued together with business data
+	tx, err = db.Begin()
+	assert.NoError(t, err)
+
+	_, err = tx.Exec("INSERT INTO orders (id) VALUES (2)")
+	assert.NoError(t, err)
+
+	jid, err := b.PushTx(tx, pipe, &jobs.Job{Job: "test", Payload: "commit", Options: &jobs.Options{}})
+	assert.NoError(t, err)
+	assert.NoError(t, tx.Commit())
+
+	exec := make(chan jobs.Handler, 1)
+	assert.NoError(t, b.Consume(pipe, exec, func(id string, j *jobs.Job, err error) {}))
+
+	waitJob := make(chan interface{})
+	exec <- func(id string, j *jobs.Job) error {
+		assert.Equal(t, jid, id)
+		assert.Equal(t, "commit", j.Payload)
+		close(waitJob)
+		return nil
+	}
+
+	<-waitJob
+}
+
+func TestBroker_PushTx_Undefined(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	b := newBroker(t, dir)
+
+	_, err := b.PushTx(nil, pipe, &jobs.Job{Job: "test", Payload: "body", Options: &jobs.Options{}})
+	assert.Error(t, err)
+}
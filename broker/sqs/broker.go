@@ -7,7 +7,10 @@ import (
 	"sync"
 )
 
-// Broker represents SQS broker.
+// Broker represents SQS broker. Job attempt number is carried by the message and is not derived from the receive
+// count, retried jobs are sent again with the next attempt number. Retry delays above the message delay limit
+// (900 seconds) are completed by the visibility timeout. Retried messages start with reset receive count, jobs
+// which run out of attempts are moved into the dead letter queue defined by pipeline `dlq` option.
 type Broker struct {
 	cfg     *Config
 	sqs     *sqs.SQS
@@ -69,7 +72,7 @@ func (b *Broker) Serve() (err error) {
 	for _, q := range b.queues {
 		qq := q
 		if qq.execPool != nil {
-			go qq.serve(b.sqs, b.cfg.TimeoutDuration())
+			qq.start(b.sqs, b.cfg.TimeoutDuration())
 		}
 	}
 
@@ -118,7 +121,7 @@ func (b *Broker) Consume(pipe *jobs.Pipeline, execPool chan jobs.Handler, errHan
 	q.errHandler = errHandler
 
 	if b.sqs != nil && q.execPool != nil {
-		go q.serve(b.sqs, b.cfg.TimeoutDuration())
+		q.start(b.sqs, b.cfg.TimeoutDuration())
 	}
 
 	return nil
@@ -135,14 +138,15 @@ func (b *Broker) Push(pipe *jobs.Pipeline, j *jobs.Job) (string, error) {
 		return "", fmt.Errorf("undefined queue `%s`", pipe.Name())
 	}
 
-	if j.Options.Delay > 900 || j.Options.RetryDelay > 900 {
-		return "", fmt.Errorf("unable to push into `%s`, maximum delay value is 900", pipe.Name())
+	if j.Options.Delay > maxDelay {
+		return "", fmt.Errorf("unable to push into `%s`, maximum delay value is %v", pipe.Name(), maxDelay)
 	}
 
 	return q.send(b.sqs, j)
 }
 
-// Stat must fetch statistics about given pipeline or return error.
+// Stat must fetch statistics about given pipeline or return error. Age of the oldest message is only published
+// as CloudWatch metric and not available via queue attributes, OldestAge is always zero.
 func (b *Broker) Stat(pipe *jobs.Pipeline) (stat *jobs.Stat, err error) {
 	if err := b.isServing(); err != nil {
 		return nil, err
@@ -156,6 +160,106 @@ func (b *Broker) Stat(pipe *jobs.Pipeline) (stat *jobs.Stat, err error) {
 	return q.stat(b.sqs)
 }
 
+// List returns pending jobs of the pipeline. Messages are received with zero visibility timeout and stay
+// available to consumers, SQS samples messages randomly so the list might be incomplete. Listing increases
+// message receive count used by the redrive policy of the queue.
+func (b *Broker) List(pipe *jobs.Pipeline, offset, limit int) ([]*jobs.JobInfo, error) {
+	if err := b.isServing(); err != nil {
+		return nil, err
+	}
+
+	q := b.queue(pipe)
+	if q == nil {
+		return nil, fmt.Errorf("undefined queue `%s`", pipe.Name())
+	}
+
+	return q.list(b.sqs, offset, limit)
+}
+
+// Peek returns pending job by it's id, messages are received the same way as by List.
+func (b *Broker) Peek(pipe *jobs.Pipeline, id string) (*jobs.JobInfo, error) {
+	if err := b.isServing(); err != nil {
+		return nil, err
+	}
+
+	q := b.queue(pipe)
+	if q == nil {
+		return nil, fmt.Errorf("undefined queue `%s`", pipe.Name())
+	}
+
+	return q.peek(b.sqs, id)
+}
+
+// Purge removes all messages from the queue. SQS does not allow to purge ready messages only, delayed and
+// in-flight messages are always removed, purge is only allowed when delayed jobs are requested to be purged as
+// well. Failed jobs are kept in the dead letter queue and can not be purged. Number of removed messages is not
+// reported.
+func (b *Broker) Purge(pipe *jobs.Pipeline, opts jobs.PurgeOptions) (int, error) {
+	if err := b.isServing(); err != nil {
+		return 0, err
+	}
+
+	q := b.queue(pipe)
+	if q == nil {
+		return 0, fmt.Errorf("undefined queue `%s`", pipe.Name())
+	}
+
+	if opts.Failed {
+		return 0, fmt.Errorf("unable to purge failed jobs of `%s`, sqs broker does not keep failed jobs", pipe.Name())
+	}
+
+	if !opts.Delayed {
+		return 0, fmt.Errorf("unable to purge `%s` without delayed jobs, sqs purges delayed and in-flight jobs too", pipe.Name())
+	}
+
+	if _, err := b.sqs.PurgeQueue(&sqs.PurgeQueueInput{QueueUrl: q.url}); err != nil {
+		return 0, err
+	}
+
+	return -1, nil
+}
+
+// Requeue moves jobs from the dead letter queue defined by pipeline `dlq` option back to the pipeline.
+func (b *Broker) Requeue(pipe *jobs.Pipeline, opts jobs.RequeueOptions) (int, error) {
+	if err := b.isServing(); err != nil {
+		return 0, err
+	}
+
+	q := b.queue(pipe)
+	if q == nil {
+		return 0, fmt.Errorf("undefined queue `%s`", pipe.Name())
+	}
+
+	dlq := pipe.String("dlq", "")
+	if dlq == "" {
+		return 0, fmt.Errorf("missing `dlq` parameter on sqs pipeline `%s`", pipe.Name())
+	}
+
+	return q.requeue(b.sqs, dlq, opts)
+}
+
+// Health returns error if broker is unable to reach any of it's queues.
+func (b *Broker) Health() error {
+	if err := b.isServing(); err != nil {
+		return err
+	}
+
+	b.mu.Lock()
+	queues := make([]*queue, 0, len(b.queues))
+	for _, q := range b.queues {
+		queues = append(queues, q)
+	}
+	b.mu.Unlock()
+
+	for _, q := range queues {
+		if _, err := q.stat(b.sqs); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 // check if broker is serving
 func (b *Broker) isServing() error {
 	b.mu.Lock()
@@ -138,7 +138,7 @@ func TestBroker_Consume_PushTooBigDelay(t *testing.T) {
 	assert.Error(t, perr)
 }
 
-func TestBroker_Consume_PushTooBigDelay2(t *testing.T) {
+func TestBroker_Consume_PushLongRetryDelay(t *testing.T) {
 	b := &Broker{}
 	_, err := b.Init(cfg)
 	if err != nil {
@@ -164,11 +164,11 @@ func TestBroker_Consume_PushTooBigDelay2(t *testing.T) {
 		Job:     "test",
 		Payload: "body",
 		Options: &jobs.Options{
-			RetryDelay: 901,
+			RetryDelay: 1800,
 		},
 	})
 
-	assert.Error(t, perr)
+	assert.NoError(t, perr)
 }
 
 func TestBroker_ConsumeAfterStart_Job(t *testing.T) {
@@ -368,3 +368,50 @@ func TestBroker_Consume_Errored_Attempts(t *testing.T) {
 	<-errHandled
 	assert.Equal(t, 3, attempts)
 }
+
+func TestBroker_Consume_Errored_LongRetryDelay(t *testing.T) {
+	b := &Broker{}
+	_, err := b.Init(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = b.Register(pipe)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ready := make(chan interface{})
+	released := make(chan *jobs.JobEvent, 1)
+	b.Listen(func(event int, ctx interface{}) {
+		switch event {
+		case jobs.EventBrokerReady:
+			close(ready)
+		case jobs.EventJobRelease:
+			released <- ctx.(*jobs.JobEvent)
+		}
+	})
+
+	exec := make(chan jobs.Handler, 1)
+	assert.NoError(t, b.Consume(pipe, exec, func(id string, j *jobs.Job, err error) {}))
+
+	go func() { assert.NoError(t, b.Serve()) }()
+	defer b.Stop()
+
+	<-ready
+
+	jid, perr := b.Push(pipe, &jobs.Job{
+		Job:     "test",
+		Payload: "body",
+		Options: &jobs.Options{Attempts: 3, RetryDelay: 1800},
+	})
+	assert.NoError(t, perr)
+
+	exec <- func(id string, j *jobs.Job) error {
+		return fmt.Errorf("job failed")
+	}
+
+	// retry is accepted by sqs and counted as the next attempt
+	e := <-released
+	assert.Equal(t, jid, e.ID)
+	assert.Equal(t, 1, e.Attempt)
+}
@@ -0,0 +1,70 @@
+package sqs
+
+import (
+	"github.com/spiral/jobs/v2"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestBroker_List_Peek(t *testing.T) {
+	b := &Broker{}
+	_, err := b.Init(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = b.Register(pipe)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ready := make(chan interface{})
+	b.Listen(func(event int, ctx interface{}) {
+		if event == jobs.EventBrokerReady {
+			close(ready)
+		}
+	})
+
+	go func() { assert.NoError(t, b.Serve()) }()
+	defer b.Stop()
+
+	<-ready
+
+	_, err = b.Purge(pipe, jobs.PurgeOptions{Delayed: true})
+	assert.NoError(t, err)
+
+	jid, perr := b.Push(pipe, &jobs.Job{
+		Job:     "test",
+		Payload: "body",
+		Options: &jobs.Options{},
+	})
+	assert.NoError(t, perr)
+
+	for i := 0; i < 3; i++ {
+		list, err := b.List(pipe, 0, 10)
+		assert.NoError(t, err)
+		assert.Len(t, list, 1)
+		assert.Equal(t, jid, list[0].ID)
+		assert.Equal(t, 0, list[0].Attempt)
+	}
+
+	info, err := b.Peek(pipe, jid)
+	assert.NoError(t, err)
+	assert.Equal(t, "body", info.Job.Payload)
+
+	_, err = b.Peek(pipe, "missing")
+	assert.Error(t, err)
+
+	// inspected job is still delivered as the first attempt
+	exec := make(chan jobs.Handler, 1)
+	assert.NoError(t, b.Consume(pipe, exec, func(id string, j *jobs.Job, err error) {}))
+
+	waitJob := make(chan interface{})
+	exec <- func(id string, j *jobs.Job) error {
+		assert.Equal(t, jid, id)
+		assert.Equal(t, 0, j.Delivery.Attempt)
+		close(waitJob)
+		return nil
+	}
+
+	<-waitJob
+}
@@ -17,14 +17,22 @@ var jobAttributes = []*string{
 	aws.String("rr-retryDelay"),
 }
 
-// pack job metadata into headers
-func pack(url *string, j *jobs.Job) *sqs.SendMessageInput {
+// message attributes to be received, attempt number is missing in messages pushed by earlier versions and job id
+// is only set on retried messages
+var messageAttributes = append(
+	[]*string{aws.String("rr-attempt"), aws.String("rr-id"), aws.String("rr-retryAt")},
+	jobAttributes...,
+)
+
+// pack job metadata into headers, attempt number is carried by the message
+func pack(url *string, j *jobs.Job, attempt int) *sqs.SendMessageInput {
 	return &sqs.SendMessageInput{
 		QueueUrl:     url,
 		DelaySeconds: aws.Int64(int64(j.Options.Delay)),
 		MessageBody:  aws.String(j.Payload),
 		MessageAttributes: map[string]*sqs.MessageAttributeValue{
 			"rr-job":         {DataType: aws.String("String"), StringValue: aws.String(j.Job)},
+			"rr-attempt":     {DataType: aws.String("Number"), StringValue: awsString(attempt)},
 			"rr-maxAttempts": {DataType: aws.String("String"), StringValue: awsString(j.Options.Attempts)},
 			"rr-delay":       {DataType: aws.String("String"), StringValue: awsDuration(j.Options.DelayDuration())},
 			"rr-timeout":     {DataType: aws.String("String"), StringValue: awsDuration(j.Options.TimeoutDuration())},
@@ -33,12 +41,19 @@ func pack(url *string, j *jobs.Job) *sqs.SendMessageInput {
 	}
 }
 
-// unpack restores jobs.Options
+// unpack restores jobs.Options. Attempt number is carried by the message since receive count is also increased
+// by inspections.
 func unpack(msg *sqs.Message) (id string, attempt int, j *jobs.Job, err error) {
 	if _, ok := msg.Attributes["ApproximateReceiveCount"]; !ok {
 		return "", 0, nil, fmt.Errorf("missing attribute `%s`", "ApproximateReceiveCount")
 	}
-	attempt, _ = strconv.Atoi(*msg.Attributes["ApproximateReceiveCount"])
+
+	if v, ok := msg.MessageAttributes["rr-attempt"]; ok && v.StringValue != nil {
+		attempt, _ = strconv.Atoi(*v.StringValue)
+	} else {
+		attempt, _ = strconv.Atoi(*msg.Attributes["ApproximateReceiveCount"])
+		attempt--
+	}
 
 	for _, attr := range jobAttributes {
 		if _, ok := msg.MessageAttributes[*attr]; !ok {
@@ -68,7 +83,47 @@ func unpack(msg *sqs.Message) (id string, attempt int, j *jobs.Job, err error) {
 		j.Options.RetryDelay = retryDelay
 	}
 
-	return *msg.MessageId, attempt - 1, j, nil
+	if v, ok := msg.MessageAttributes["rr-id"]; ok && v.StringValue != nil {
+		// retried message
+		return *v.StringValue, attempt, j, nil
+	}
+
+	return *msg.MessageId, attempt, j, nil
+}
+
+// queued returns time when message became available in the queue, only known for the first attempt.
+func queued(msg *sqs.Message, attempt int, j *jobs.Job) time.Time {
+	if attempt != 0 {
+		return time.Time{}
+	}
+
+	sent, ok := msg.Attributes["SentTimestamp"]
+	if !ok || sent == nil {
+		return time.Time{}
+	}
+
+	ms, err := strconv.ParseInt(*sent, 10, 64)
+	if err != nil {
+		return time.Time{}
+	}
+
+	return time.Unix(0, ms*int64(time.Millisecond)).Add(j.Options.DelayDuration())
+}
+
+// retryWait returns number of seconds left till the retried job is due, retries delayed above message delay limit
+// carry the time they are due at.
+func retryWait(msg *sqs.Message, now time.Time) int64 {
+	v, ok := msg.MessageAttributes["rr-retryAt"]
+	if !ok || v.StringValue == nil {
+		return 0
+	}
+
+	at, err := strconv.ParseInt(*v.StringValue, 10, 64)
+	if err != nil {
+		return 0
+	}
+
+	return at - now.Unix()
 }
 
 func awsString(n int) *string {
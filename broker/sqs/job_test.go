@@ -3,8 +3,11 @@ package sqs
 import (
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/sqs"
+	"github.com/spiral/jobs/v2"
 	"github.com/stretchr/testify/assert"
+	"strconv"
 	"testing"
+	"time"
 )
 
 func Test_Unpack(t *testing.T) {
@@ -17,3 +20,45 @@ func Test_Unpack(t *testing.T) {
 	_, _, _, err := unpack(msg)
 	assert.Error(t, err)
 }
+
+func Test_Unpack_Attempt(t *testing.T) {
+	j := &jobs.Job{Job: "test", Payload: "body", Options: &jobs.Options{Attempts: 3}}
+	send := pack(aws.String("url"), j, 2)
+	send.MessageAttributes["rr-id"] = &sqs.MessageAttributeValue{StringValue: aws.String("job-id")}
+
+	msg := &sqs.Message{
+		MessageId:         aws.String("message-id"),
+		Body:              send.MessageBody,
+		Attributes:        map[string]*string{"ApproximateReceiveCount": aws.String("10")},
+		MessageAttributes: send.MessageAttributes,
+	}
+
+	id, attempt, uj, err := unpack(msg)
+	assert.NoError(t, err)
+	assert.Equal(t, "job-id", id)
+	assert.Equal(t, 2, attempt)
+	assert.Equal(t, "test", uj.Job)
+	assert.Equal(t, 3, uj.Options.Attempts)
+
+	// pushed by earlier versions
+	delete(msg.MessageAttributes, "rr-attempt")
+	delete(msg.MessageAttributes, "rr-id")
+
+	id, attempt, _, err = unpack(msg)
+	assert.NoError(t, err)
+	assert.Equal(t, "message-id", id)
+	assert.Equal(t, 9, attempt)
+}
+
+func Test_RetryWait(t *testing.T) {
+	now := time.Now()
+
+	msg := &sqs.Message{MessageAttributes: map[string]*sqs.MessageAttributeValue{}}
+	assert.Equal(t, int64(0), retryWait(msg, now))
+
+	msg.MessageAttributes["rr-retryAt"] = &sqs.MessageAttributeValue{
+		StringValue: aws.String(strconv.FormatInt(now.Add(time.Hour).Unix(), 10)),
+	}
+	assert.Equal(t, int64(3600), retryWait(msg, now))
+	assert.True(t, retryWait(msg, now.Add(2*time.Hour)) < 0)
+}
@@ -11,6 +11,14 @@ import (
 	"time"
 )
 
+const (
+	// maxDelay is maximum message delay (in seconds) supported by SQS.
+	maxDelay = 900
+
+	// maxVisibility is maximum visibility timeout (in seconds) supported by SQS.
+	maxVisibility = 43200
+)
+
 type queue struct {
 	active       int32
 	pipe         *jobs.Pipeline
@@ -85,14 +93,20 @@ func (q *queue) declareQueue(s *sqs.SQS) (*string, error) {
 	return r.QueueUrl, nil
 }
 
-// serve consumers
-func (q *queue) serve(s *sqs.SQS, tout time.Duration) {
+// start consuming in background, queue is marked as consumed before the method returns so it can be stopped
+// right away.
+func (q *queue) start(s *sqs.SQS, tout time.Duration) {
 	q.wait = make(chan interface{})
 	atomic.StoreInt32(&q.active, 1)
 
+	go q.serve(s, tout, q.wait)
+}
+
+// serve consumers till given channel is closed.
+func (q *queue) serve(s *sqs.SQS, tout time.Duration, wait chan interface{}) {
 	var errored bool
 	for {
-		messages, stop, err := q.consume(s)
+		messages, stop, err := q.consume(s, wait)
 		if err != nil {
 			if errored {
 				// reoccurring error
@@ -123,12 +137,12 @@ func (q *queue) serve(s *sqs.SQS, tout time.Duration) {
 }
 
 // consume and allocate connection.
-func (q *queue) consume(s *sqs.SQS) ([]*sqs.Message, bool, error) {
+func (q *queue) consume(s *sqs.SQS, wait chan interface{}) ([]*sqs.Message, bool, error) {
 	q.muw.Lock()
 	defer q.muw.Unlock()
 
 	select {
-	case <-q.wait:
+	case <-wait:
 		return nil, true, nil
 	default:
 		r, err := s.ReceiveMessage(&sqs.ReceiveMessageInput{
@@ -136,8 +150,8 @@ func (q *queue) consume(s *sqs.SQS) ([]*sqs.Message, bool, error) {
 			MaxNumberOfMessages:   aws.Int64(int64(q.pipe.Integer("prefetch", 1))),
 			WaitTimeSeconds:       aws.Int64(int64(q.reserve.Seconds())),
 			VisibilityTimeout:     aws.Int64(int64(q.lockReserved.Seconds())),
-			AttributeNames:        []*string{aws.String("ApproximateReceiveCount")},
-			MessageAttributeNames: jobAttributes,
+			AttributeNames:        []*string{aws.String("ApproximateReceiveCount"), aws.String("SentTimestamp")},
+			MessageAttributeNames: messageAttributes,
 		})
 		if err != nil {
 			return nil, false, err
@@ -157,6 +171,21 @@ func (q *queue) do(s *sqs.SQS, h jobs.Handler, msg *sqs.Message) (err error) {
 		return err
 	}
 
+	if wait := retryWait(msg, time.Now()); wait > 0 {
+		// retry delay exceeds message delay limit, rest of the delay is waited out by the visibility timeout
+		if wait > maxVisibility {
+			wait = maxVisibility
+		}
+
+		_, err = s.ChangeMessageVisibility(&sqs.ChangeMessageVisibilityInput{
+			QueueUrl:          q.url,
+			ReceiptHandle:     msg.ReceiptHandle,
+			VisibilityTimeout: aws.Int64(wait),
+		})
+
+		return err
+	}
+
 	// block the job based on known timeout
 	_, err = s.ChangeMessageVisibility(&sqs.ChangeMessageVisibilityInput{
 		QueueUrl:          q.url,
@@ -168,24 +197,75 @@ func (q *queue) do(s *sqs.SQS, h jobs.Handler, msg *sqs.Message) (err error) {
 		return err
 	}
 
+	j.Delivery = &jobs.Delivery{Pipeline: q.pipe.Name(), Attempt: attempt, Queued: queued(msg, attempt, j)}
+
 	err = h(id, j)
 	if err == nil {
 		return q.deleteMessage(s, msg, nil)
 	}
 
+	if err == jobs.ErrRelease {
+		_, err = s.ChangeMessageVisibility(&sqs.ChangeMessageVisibilityInput{
+			QueueUrl:          q.url,
+			ReceiptHandle:     msg.ReceiptHandle,
+			VisibilityTimeout: aws.Int64(0),
+		})
+
+		return err
+	}
+
 	q.errHandler(id, j, err)
 
 	if !j.Options.CanRetry(attempt) {
+		if err := q.bury(s, id, attempt, j); err != nil {
+			// message is delivered again once job timeout expires
+			return err
+		}
+
 		return q.deleteMessage(s, msg, err)
 	}
 
-	// retry after specified duration
-	_, err = s.ChangeMessageVisibility(&sqs.ChangeMessageVisibilityInput{
-		QueueUrl:          q.url,
-		ReceiptHandle:     msg.ReceiptHandle,
-		VisibilityTimeout: aws.Int64(int64(j.Options.RetryDelay)),
-	})
+	// retry after specified duration, message is sent again to carry the next attempt number and the job id
+	retry := pack(q.url, j, attempt+1)
+	retry.MessageAttributes["rr-id"] = &sqs.MessageAttributeValue{DataType: aws.String("String"), StringValue: aws.String(id)}
+
+	retry.DelaySeconds = aws.Int64(int64(j.Options.RetryDelay))
+	if j.Options.RetryDelay > maxDelay {
+		retry.DelaySeconds = aws.Int64(maxDelay)
+		retry.MessageAttributes["rr-retryAt"] = &sqs.MessageAttributeValue{
+			DataType:    aws.String("Number"),
+			StringValue: aws.String(strconv.FormatInt(time.Now().Add(j.Options.RetryDuration()).Unix(), 10)),
+		}
+	}
+
+	if _, err = s.SendMessage(retry); err != nil {
+		// message is delivered again once job timeout expires
+		return err
+	}
+
+	q.lsn(jobs.EventJobRelease, &jobs.JobEvent{ID: id, Job: j, Pipeline: q.pipe.Name(), Attempt: attempt + 1})
+
+	return q.deleteMessage(s, msg, nil)
+}
+
+// bury moves the failed job into the dead letter queue defined by pipeline `dlq` option (if any), retried messages
+// start with reset receive count and are never moved by the redrive policy of the queue.
+func (q *queue) bury(s *sqs.SQS, id string, attempt int, j *jobs.Job) error {
+	dlq := q.pipe.String("dlq", "")
+	if dlq == "" {
+		return nil
+	}
+
+	r, err := s.GetQueueUrl(&sqs.GetQueueUrlInput{QueueName: aws.String(dlq)})
+	if err != nil {
+		return err
+	}
+
+	dead := pack(r.QueueUrl, j, attempt)
+	dead.DelaySeconds = aws.Int64(0)
+	dead.MessageAttributes["rr-id"] = &sqs.MessageAttributeValue{DataType: aws.String("String"), StringValue: aws.String(id)}
 
+	_, err = s.SendMessage(dead)
 	return err
 }
 
@@ -210,7 +290,7 @@ func (q *queue) stop() {
 
 // add job to the queue
 func (q *queue) send(s *sqs.SQS, j *jobs.Job) (string, error) {
-	r, err := s.SendMessage(pack(q.url, j))
+	r, err := s.SendMessage(pack(q.url, j, 0))
 	if err != nil {
 		return "", err
 	}
@@ -264,3 +344,147 @@ func (q *queue) report(err error) {
 		q.lsn(jobs.EventPipeError, &jobs.PipelineError{Pipeline: q.pipe, Caused: err})
 	}
 }
+
+// list receives pending messages with zero visibility timeout, so they stay available to consumers.
+func (q *queue) list(s *sqs.SQS, offset, limit int) ([]*jobs.JobInfo, error) {
+	list := make([]*jobs.JobInfo, 0)
+
+	err := q.scan(s, func(n int, info *jobs.JobInfo) bool {
+		if n >= offset {
+			list = append(list, info)
+		}
+
+		return limit <= 0 || len(list) < limit
+	})
+
+	return list, err
+}
+
+// peek receives pending messages until the message with given id is found.
+func (q *queue) peek(s *sqs.SQS, id string) (*jobs.JobInfo, error) {
+	var found *jobs.JobInfo
+
+	err := q.scan(s, func(n int, info *jobs.JobInfo) bool {
+		if info.ID == id {
+			found = info
+		}
+
+		return found == nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	if found == nil {
+		return nil, fmt.Errorf("undefined job `%s`", id)
+	}
+
+	return found, nil
+}
+
+// scan receives messages until given function returns false or no new messages are received. SQS samples
+// messages randomly, the scan stops as soon as received batch contains no unseen messages. Job attempt is
+// carried by the message, receives made by the scan are not counted as attempts.
+func (q *queue) scan(s *sqs.SQS, f func(n int, info *jobs.JobInfo) bool) error {
+	seen := make(map[string]bool)
+
+	for {
+		r, err := s.ReceiveMessage(&sqs.ReceiveMessageInput{
+			QueueUrl:              q.url,
+			MaxNumberOfMessages:   aws.Int64(10),
+			VisibilityTimeout:     aws.Int64(0),
+			AttributeNames:        []*string{aws.String("ApproximateReceiveCount")},
+			MessageAttributeNames: messageAttributes,
+		})
+		if err != nil {
+			return err
+		}
+
+		fresh := 0
+		for _, msg := range r.Messages {
+			if seen[*msg.MessageId] {
+				continue
+			}
+
+			seen[*msg.MessageId] = true
+			fresh++
+
+			id, attempt, j, err := unpack(msg)
+			if err != nil {
+				// foreign message
+				continue
+			}
+
+			if !f(len(seen)-1, &jobs.JobInfo{ID: id, Job: j, State: jobs.JobReady, Attempt: attempt}) {
+				return nil
+			}
+		}
+
+		if fresh == 0 {
+			return nil
+		}
+	}
+}
+
+// requeue moves messages from the dead letter queue back to the queue as new jobs with reset attempt number.
+// Messages which do not match the options are made visible in the dead letter queue again.
+func (q *queue) requeue(s *sqs.SQS, dlq string, opts jobs.RequeueOptions) (n int, err error) {
+	r, err := s.GetQueueUrl(&sqs.GetQueueUrlInput{QueueName: aws.String(dlq)})
+	if err != nil {
+		return 0, err
+	}
+
+	var skipped []*sqs.Message
+	defer func() {
+		for _, msg := range skipped {
+			s.ChangeMessageVisibility(&sqs.ChangeMessageVisibilityInput{
+				QueueUrl:          r.QueueUrl,
+				ReceiptHandle:     msg.ReceiptHandle,
+				VisibilityTimeout: aws.Int64(0),
+			})
+		}
+	}()
+
+	for !opts.Exceeded(n) {
+		res, err := s.ReceiveMessage(&sqs.ReceiveMessageInput{
+			QueueUrl:              r.QueueUrl,
+			MaxNumberOfMessages:   aws.Int64(10),
+			VisibilityTimeout:     aws.Int64(int64(q.lockReserved.Seconds())),
+			AttributeNames:        []*string{aws.String("ApproximateReceiveCount")},
+			MessageAttributeNames: messageAttributes,
+		})
+		if err != nil {
+			return n, err
+		}
+
+		if len(res.Messages) == 0 {
+			return n, nil
+		}
+
+		for _, msg := range res.Messages {
+			_, _, j, err := unpack(msg)
+			if err != nil || !opts.Match(j.Job) || opts.Exceeded(n) {
+				// foreign, filtered or exceeding message
+				skipped = append(skipped, msg)
+				continue
+			}
+
+			send := pack(q.url, j, 0)
+			send.DelaySeconds = aws.Int64(0)
+
+			if _, err := s.SendMessage(send); err != nil {
+				skipped = append(skipped, msg)
+				return n, err
+			}
+
+			if _, err := s.DeleteMessage(&sqs.DeleteMessageInput{QueueUrl: r.QueueUrl, ReceiptHandle: msg.ReceiptHandle}); err != nil {
+				return n, err
+			}
+
+			n++
+		}
+	}
+
+	return n, nil
+}
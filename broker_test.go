@@ -50,7 +50,7 @@ func (b *testBroker) Serve() error {
 	for _, q := range b.queues {
 		qq := q
 		if qq.execPool != nil {
-			go qq.serve()
+			qq.start()
 		}
 	}
 	b.wait = make(chan error)
@@ -100,7 +100,7 @@ func (b *testBroker) Consume(pipe *Pipeline, execPool chan Handler, errHandler E
 
 	if b.wait != nil {
 		if q.execPool != nil {
-			go q.serve()
+			q.start()
 		}
 	}
 
@@ -185,8 +185,10 @@ type testQueue struct {
 	muw sync.Mutex
 	wg  sync.WaitGroup
 
-	// stop channel
-	wait chan interface{}
+	// stop channel and jobs waiting for consuming to start
+	muq     sync.Mutex
+	wait    chan interface{}
+	pending []*entry
 
 	// exec handlers
 	execPool   chan Handler
@@ -213,13 +215,27 @@ func newQueue() *testQueue {
 //	return nil
 //}
 
-// serve consumers
-func (q *testQueue) serve() {
+// start consuming in background
+func (q *testQueue) start() {
+	q.muq.Lock()
 	q.wait = make(chan interface{})
+	pending := q.pending
+	q.pending = nil
+	q.muq.Unlock()
+
 	atomic.StoreInt32(&q.active, 1)
 
+	go q.serve(q.wait)
+
+	for _, e := range pending {
+		go q.deliver(e)
+	}
+}
+
+// serve consumers
+func (q *testQueue) serve(wait chan interface{}) {
 	for {
-		e := q.consume()
+		e := q.consume(wait)
 		if e == nil {
 			return
 		}
@@ -236,12 +252,12 @@ func (q *testQueue) serve() {
 }
 
 // allocate one job entry
-func (q *testQueue) consume() *entry {
+func (q *testQueue) consume(wait chan interface{}) *entry {
 	q.muw.Lock()
 	defer q.muw.Unlock()
 
 	select {
-	case <-q.wait:
+	case <-wait:
 		return nil
 	case e := <-q.jobs:
 		q.wg.Add(1)
@@ -259,10 +275,16 @@ func (q *testQueue) do(h Handler, e *entry) {
 		return
 	}
 
+	if err == ErrRelease {
+		atomic.AddInt64(&q.st.Queue, ^int64(0))
+		q.push(e.id, e.job, e.attempt, 0)
+		return
+	}
+
 	q.errHandler(e.id, e.job, err)
 
+	atomic.AddInt64(&q.st.Queue, ^int64(0))
 	if !e.job.Options.CanRetry(e.attempt) {
-		atomic.AddInt64(&q.st.Queue, ^int64(0))
 		return
 	}
 
@@ -287,9 +309,7 @@ func (q *testQueue) stop() {
 func (q *testQueue) push(id string, j *Job, attempt int, delay time.Duration) {
 	if delay == 0 {
 		atomic.AddInt64(&q.st.Queue, 1)
-		go func() {
-			q.jobs <- &entry{id: id, job: j, attempt: attempt}
-		}()
+		go q.deliver(&entry{id: id, job: j, attempt: attempt})
 
 		return
 	}
@@ -300,10 +320,40 @@ func (q *testQueue) push(id string, j *Job, attempt int, delay time.Duration) {
 		atomic.AddInt64(&q.st.Delayed, ^int64(0))
 		atomic.AddInt64(&q.st.Queue, 1)
 
-		q.jobs <- &entry{id: id, job: j, attempt: attempt}
+		q.deliver(&entry{id: id, job: j, attempt: attempt})
 	}()
 }
 
+// deliver job to the consumer, job is kept till the next start once consuming stops.
+func (q *testQueue) deliver(e *entry) {
+	for {
+		q.muq.Lock()
+		wait := q.wait
+
+		stopped := wait == nil
+		if !stopped {
+			select {
+			case <-wait:
+				stopped = true
+			default:
+			}
+		}
+
+		if stopped {
+			q.pending = append(q.pending, e)
+			q.muq.Unlock()
+			return
+		}
+		q.muq.Unlock()
+
+		select {
+		case q.jobs <- e:
+			return
+		case <-wait:
+		}
+	}
+}
+
 func (q *testQueue) stat() *Stat {
 	return &Stat{
 		InternalName: ":memory:",
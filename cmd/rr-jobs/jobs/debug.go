@@ -71,6 +71,34 @@ func (s *debugger) listener(event int, ctx interface{}) {
 			e.Error(),
 		))
 
+	case jobs.EventJobRetry:
+		e := ctx.(*jobs.JobError)
+		s.logger.Warning(util.Sprintf(
+			"job.<yellow+hb>RTRY</reset> <yellow>%s</reset> <gray+hb>%s</reset> attempt <white+hb>%v</reset> in %s",
+			e.Job.Job,
+			e.ID,
+			e.Attempt+1,
+			time.Until(e.NextRetry).Round(time.Second),
+		))
+
+	case jobs.EventJobDead:
+		e := ctx.(*jobs.JobError)
+		s.logger.Error(util.Sprintf(
+			"job.<red+hb>DEAD</reset> <red>%s</reset> <gray+hb>%s</reset> after <white+hb>%v</reset> attempt(s)",
+			e.Job.Job,
+			e.ID,
+			e.Attempt+1,
+		))
+
+	case jobs.EventJobTimeout:
+		e := ctx.(*jobs.JobEvent)
+		s.logger.Warning(util.Sprintf(
+			"job.<yellow+hb>TOUT</reset> <yellow>%s</reset> <gray+hb>%s</reset> %s",
+			e.Job.Job,
+			e.ID,
+			elapsed(e.Elapsed()),
+		))
+
 	case jobs.EventPushError:
 		e := ctx.(*jobs.JobError)
 		s.logger.Error(util.Sprintf(
@@ -103,6 +131,121 @@ func (s *debugger) listener(event int, ctx interface{}) {
 			e.Pipeline.Name(),
 			e.Error(),
 		))
+
+	case jobs.EventBreakerOpen:
+		e := ctx.(*jobs.BreakerEvent)
+		s.logger.Warning(util.Sprintf(
+			"[%s]: breaker <red+hb>open</reset> {<red>%s</reset>} error rate <white+hb>%.0f%%</reset>",
+			e.Pipeline.Broker(),
+			e.Pipeline.Name(),
+			e.ErrorRate*100,
+		))
+
+	case jobs.EventBreakerHalfOpen:
+		e := ctx.(*jobs.BreakerEvent)
+		s.logger.Info(util.Sprintf(
+			"[%s]: breaker <yellow+hb>half-open</reset> {<yellow>%s</reset>}",
+			e.Pipeline.Broker(),
+			e.Pipeline.Name(),
+		))
+
+	case jobs.EventBreakerClose:
+		e := ctx.(*jobs.BreakerEvent)
+		s.logger.Info(util.Sprintf(
+			"[%s]: breaker <green+hb>closed</reset> {<green>%s</reset>}",
+			e.Pipeline.Broker(),
+			e.Pipeline.Name(),
+		))
+
+	case jobs.EventScale:
+		e := ctx.(*jobs.ScaleEvent)
+		s.logger.Info(util.Sprintf(
+			"workers scaled from <white+hb>%v</reset> to <green+hb>%v</reset> (backlog <yellow>%v</reset>, wait %s)",
+			e.From,
+			e.To,
+			e.Backlog,
+			elapsed(e.QueueWait),
+		))
+
+	case jobs.EventScaleError:
+		e := ctx.(*jobs.ScaleEvent)
+		s.logger.Error(util.Sprintf(
+			"unable to scale workers from <white+hb>%v</reset> to <red+hb>%v</reset>: <red>%s</reset>",
+			e.From,
+			e.To,
+			e.Caused,
+		))
+
+	case jobs.EventPushFailover:
+		e := ctx.(*jobs.FailoverEvent)
+		s.logger.Warning(util.Sprintf(
+			"job.<yellow+hb>FOVR</reset> <yellow>%s</reset> <gray+hb>%s</reset> {%s} => {<yellow+hb>%s</reset>}",
+			e.Job.Job,
+			e.ID,
+			e.Pipeline,
+			e.Target,
+		))
+
+	case jobs.EventFailback:
+		e := ctx.(*jobs.FailoverEvent)
+		s.logger.Info(util.Sprintf(
+			"moved <white+hb>%v</reset> jobs back {%s} => {<green+hb>%s</reset>}",
+			e.Moved,
+			e.Target,
+			e.Pipeline,
+		))
+
+	case jobs.EventPushDeferred:
+		e := ctx.(*jobs.OutboxEvent)
+		s.logger.Warning(util.Sprintf(
+			"job.<yellow+hb>DEFR</reset> <yellow>%s</reset> <gray+hb>%s</reset> {%s} <yellow>%s</reset>",
+			e.Job.Job,
+			e.ID,
+			e.Pipeline,
+			e.Caused,
+		))
+
+	case jobs.EventPushReplayed:
+		e := ctx.(*jobs.OutboxEvent)
+		s.logger.Info(util.Sprintf(
+			"job.<green+hb>RPLY</reset> <green>%s</reset> <gray+hb>%s</reset> => <white+hb>%s</reset> {%s}",
+			e.Job.Job,
+			e.ID,
+			e.Pushed,
+			e.Pipeline,
+		))
+
+	case jobs.EventOutboxError:
+		e := ctx.(*jobs.OutboxEvent)
+		s.logger.Error(util.Sprintf("outbox: <red+hb>%s</reset>", e.Caused))
+
+	case jobs.EventPushDiscarded:
+		e := ctx.(*jobs.OutboxEvent)
+		name := "-"
+		if e.Job != nil {
+			name = e.Job.Job
+		}
+
+		s.logger.Error(util.Sprintf(
+			"job.<red+hb>DISC</reset> <red>%s</reset> <gray+hb>%s</reset> {%s} <red>%s</reset>",
+			name,
+			e.ID,
+			e.Pipeline,
+			e.Caused,
+		))
+
+	case jobs.EventAuditError:
+		e := ctx.(*jobs.AuditError)
+		s.logger.Error(util.Sprintf("audit: <red>%s</reset> <red+hb>%s</reset>", e.Event, e.Caused))
+
+	case jobs.EventNotifyError:
+		e := ctx.(*jobs.NotifyError)
+		s.logger.Error(util.Sprintf(
+			"notify: <white+hb>%v</reset> event(s) lost {%s} <red+hb>%s</reset>",
+			len(e.Events),
+			e.URL,
+			e.Caused,
+		))
 	}
 }
 
@@ -0,0 +1,105 @@
+// Copyright (c) 2018 SpiralScout
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+package jobs
+
+import (
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+	"github.com/spiral/jobs/v2"
+	rr "github.com/spiral/roadrunner/cmd/rr/cmd"
+	"github.com/spiral/roadrunner/cmd/util"
+	"os"
+	"time"
+)
+
+var (
+	failedOffset, failedLimit int
+	failedRetry, failedDelete string
+)
+
+func init() {
+	failedCommand := &cobra.Command{
+		Use:   "jobs:failed <pipeline>",
+		Short: "List, retry or delete failed jobs retained by the pipeline broker",
+		Args:  cobra.ExactArgs(1),
+		RunE:  failedHandler,
+	}
+
+	failedCommand.Flags().IntVarP(&failedOffset, "offset", "o", 0, "offset of the first job")
+	failedCommand.Flags().IntVarP(&failedLimit, "limit", "l", 20, "maximum number of jobs to list")
+	failedCommand.Flags().StringVar(&failedRetry, "retry", "", "id of the failed job to push back to the pipeline")
+	failedCommand.Flags().StringVar(&failedDelete, "delete", "", "id of the failed job to delete")
+
+	rr.CLI.AddCommand(failedCommand)
+}
+
+func failedHandler(cmd *cobra.Command, args []string) error {
+	client, err := util.RPCClient(rr.Container)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	var result string
+	switch {
+	case failedRetry != "":
+		if err := client.Call("jobs.RetryFailed", jobs.FailedRequest{Pipeline: args[0], ID: failedRetry}, &result); err != nil {
+			return err
+		}
+
+		util.Printf("job <gray+hb>%s</reset> has been pushed back to <green+hb>%s</reset>\n", failedRetry, args[0])
+		return nil
+
+	case failedDelete != "":
+		if err := client.Call("jobs.DeleteFailed", jobs.FailedRequest{Pipeline: args[0], ID: failedDelete}, &result); err != nil {
+			return err
+		}
+
+		util.Printf("job <gray+hb>%s</reset> has been deleted\n", failedDelete)
+		return nil
+	}
+
+	var l jobs.FailedList
+	r := jobs.ListRequest{Pipeline: args[0], Offset: failedOffset, Limit: failedLimit}
+	if err := client.Call("jobs.ListFailed", r, &l); err != nil {
+		return err
+	}
+
+	FailedTable(l.Jobs).Render()
+	return nil
+}
+
+// FailedTable renders table with information about failed jobs.
+func FailedTable(list []*jobs.FailedJob) *tablewriter.Table {
+	tw := tablewriter.NewWriter(os.Stdout)
+	tw.SetHeader([]string{"ID", "Job", "Attempts", "Failed", "Error"})
+
+	for _, j := range list {
+		tw.Append([]string{
+			util.Sprintf("<gray+hb>%s</reset>", j.ID),
+			util.Sprintf("<cyan>%s</reset>", j.Job.Job),
+			util.Sprintf("<white+hb>%v</reset>", j.Attempts),
+			util.Sprintf("<yellow>%s</reset>", j.Failed.Format(time.RFC3339)),
+			util.Sprintf("<red>%s</reset>", truncate(j.Error, 40)),
+		})
+	}
+
+	return tw
+}
@@ -0,0 +1,90 @@
+// Copyright (c) 2018 SpiralScout
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+package jobs
+
+import (
+	"fmt"
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+	"github.com/spiral/jobs/v2"
+	rr "github.com/spiral/roadrunner/cmd/rr/cmd"
+	"github.com/spiral/roadrunner/cmd/util"
+	"os"
+)
+
+var listOffset, listLimit int
+
+func init() {
+	listCommand := &cobra.Command{
+		Use:   "jobs:list <pipeline>",
+		Short: "List pending jobs of the pipeline",
+		Args:  cobra.ExactArgs(1),
+		RunE:  listHandler,
+	}
+
+	listCommand.Flags().IntVarP(&listOffset, "offset", "o", 0, "offset of the first job")
+	listCommand.Flags().IntVarP(&listLimit, "limit", "l", 20, "maximum number of jobs to list")
+
+	rr.CLI.AddCommand(listCommand)
+}
+
+func listHandler(cmd *cobra.Command, args []string) error {
+	client, err := util.RPCClient(rr.Container)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	var l jobs.JobList
+	r := jobs.ListRequest{Pipeline: args[0], Offset: listOffset, Limit: listLimit}
+	if err := client.Call("jobs.List", r, &l); err != nil {
+		return err
+	}
+
+	JobTable(l.Jobs).Render()
+	return nil
+}
+
+// JobTable renders table with information about pending jobs.
+func JobTable(list []*jobs.JobInfo) *tablewriter.Table {
+	tw := tablewriter.NewWriter(os.Stdout)
+	tw.SetHeader([]string{"ID", "Job", "State", "Attempt", "Payload"})
+
+	for _, j := range list {
+		tw.Append([]string{
+			util.Sprintf("<gray+hb>%s</reset>", j.ID),
+			util.Sprintf("<cyan>%s</reset>", j.Job.Job),
+			util.Sprintf("<yellow>%s</reset>", j.State),
+			util.Sprintf("<white+hb>%v</reset>", j.Attempt),
+			truncate(j.Job.Payload, 40),
+		})
+	}
+
+	return tw
+}
+
+// truncate long payloads
+func truncate(s string, max int) string {
+	if len(s) <= max {
+		return s
+	}
+
+	return fmt.Sprintf("%s...", s[:max])
+}
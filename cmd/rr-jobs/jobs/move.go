@@ -0,0 +1,104 @@
+// Copyright (c) 2018 SpiralScout
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+package jobs
+
+import (
+	"github.com/spf13/cobra"
+	"github.com/spiral/jobs/v2"
+	rr "github.com/spiral/roadrunner/cmd/rr/cmd"
+	"github.com/spiral/roadrunner/cmd/util"
+)
+
+// moveBatch defines number of jobs to move within one RPC call.
+const moveBatch = 100
+
+var (
+	moveRate, moveMax       int
+	moveDelayed, moveDryRun bool
+)
+
+func init() {
+	moveCommand := &cobra.Command{
+		Use:   "jobs:move <from> <to>",
+		Short: "Move jobs from one pipeline to another",
+		Args:  cobra.ExactArgs(2),
+		RunE:  moveHandler,
+	}
+
+	moveCommand.Flags().IntVarP(&moveRate, "rate", "r", 0, "maximum number of jobs to move per second")
+	moveCommand.Flags().IntVarP(&moveMax, "max", "m", 0, "maximum number of jobs to move")
+	moveCommand.Flags().BoolVar(&moveDelayed, "delayed", false, "move delayed jobs as well")
+	moveCommand.Flags().BoolVar(&moveDryRun, "dry-run", false, "only report number of jobs to be moved")
+
+	rr.CLI.AddCommand(moveCommand)
+}
+
+func moveHandler(cmd *cobra.Command, args []string) error {
+	client, err := util.RPCClient(rr.Container)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	r := jobs.MoveRequest{
+		From:    args[0],
+		To:      args[1],
+		Options: jobs.MoveOptions{Rate: moveRate, Delayed: moveDelayed, DryRun: moveDryRun, Max: moveMax},
+	}
+
+	var n int
+	if moveDryRun {
+		if err := client.Call("jobs.Move", r, &n); err != nil {
+			return err
+		}
+
+		util.Printf("<green+hb>%v</reset> <green>job(s) to be moved from</reset> <white+hb>%s</reset>\n", n, args[0])
+		return nil
+	}
+
+	total := 0
+	for moveMax == 0 || total < moveMax {
+		r.Options.Max = moveBatch
+		if moveMax != 0 && moveMax-total < moveBatch {
+			r.Options.Max = moveMax - total
+		}
+
+		if err := client.Call("jobs.Move", r, &n); err != nil {
+			util.Printf("\n")
+			return err
+		}
+
+		total += n
+		util.Printf(
+			"\r<green>move</reset> <white+hb>%s</reset> <green>to</reset> <white+hb>%s</reset><green>:</reset> <green+hb>%v</reset> <green>job(s)</reset>",
+			args[0],
+			args[1],
+			total,
+		)
+
+		if n < r.Options.Max {
+			// source pipeline is drained
+			break
+		}
+	}
+
+	util.Printf("\n")
+	return nil
+}
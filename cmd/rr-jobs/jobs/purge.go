@@ -0,0 +1,91 @@
+// Copyright (c) 2018 SpiralScout
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+package jobs
+
+import (
+	"bufio"
+	"github.com/spf13/cobra"
+	"github.com/spiral/jobs/v2"
+	rr "github.com/spiral/roadrunner/cmd/rr/cmd"
+	"github.com/spiral/roadrunner/cmd/util"
+	"os"
+	"strings"
+)
+
+var (
+	purgeDelayed, purgeFailed, purgeForce bool
+)
+
+func init() {
+	purgeCommand := &cobra.Command{
+		Use:   "jobs:purge <pipeline>",
+		Short: "Remove pending jobs from the pipeline",
+		Args:  cobra.ExactArgs(1),
+		RunE:  purgeHandler,
+	}
+
+	purgeCommand.Flags().BoolVar(&purgeDelayed, "delayed", false, "purge delayed jobs")
+	purgeCommand.Flags().BoolVar(&purgeFailed, "failed", false, "purge failed jobs")
+	purgeCommand.Flags().BoolVarP(&purgeForce, "force", "f", false, "do not ask for confirmation")
+
+	rr.CLI.AddCommand(purgeCommand)
+}
+
+func purgeHandler(cmd *cobra.Command, args []string) error {
+	if !purgeForce && !confirm(args[0]) {
+		return nil
+	}
+
+	client, err := util.RPCClient(rr.Container)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	util.Printf("<green>purge pipeline</reset> <white+hb>%s</reset><green>: </reset>", args[0])
+
+	var n int
+	r := jobs.PurgeRequest{
+		Pipeline: args[0],
+		Options:  jobs.PurgeOptions{Delayed: purgeDelayed, Failed: purgeFailed},
+	}
+
+	if err := client.Call("jobs.Purge", r, &n); err != nil {
+		return err
+	}
+
+	if n < 0 {
+		util.Printf("<green+hb>done</reset>\n")
+		return nil
+	}
+
+	util.Printf("<green+hb>%v</reset> <green>job(s) removed</reset>\n", n)
+	return nil
+}
+
+// confirm asks user to confirm purging of the pipeline
+func confirm(pipeline string) bool {
+	util.Printf("<yellow>all pending jobs of</reset> <white+hb>%s</reset> <yellow>will be removed, continue? [y/N]: </reset>", pipeline)
+
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	answer = strings.ToLower(strings.TrimSpace(answer))
+
+	return answer == "y" || answer == "yes"
+}
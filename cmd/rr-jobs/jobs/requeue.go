@@ -0,0 +1,69 @@
+// Copyright (c) 2018 SpiralScout
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+package jobs
+
+import (
+	"github.com/spf13/cobra"
+	"github.com/spiral/jobs/v2"
+	rr "github.com/spiral/roadrunner/cmd/rr/cmd"
+	"github.com/spiral/roadrunner/cmd/util"
+)
+
+var (
+	requeueJobs []string
+	requeueMax  int
+)
+
+func init() {
+	requeueCommand := &cobra.Command{
+		Use:   "jobs:requeue <pipeline>",
+		Short: "Move failed jobs back to the pipeline",
+		Args:  cobra.ExactArgs(1),
+		RunE:  requeueHandler,
+	}
+
+	requeueCommand.Flags().StringSliceVarP(&requeueJobs, "job", "j", nil, "requeue only jobs matching given name pattern")
+	requeueCommand.Flags().IntVarP(&requeueMax, "max", "m", 0, "maximum number of jobs to requeue")
+
+	rr.CLI.AddCommand(requeueCommand)
+}
+
+func requeueHandler(cmd *cobra.Command, args []string) error {
+	client, err := util.RPCClient(rr.Container)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	util.Printf("<green>requeue pipeline</reset> <white+hb>%s</reset><green>: </reset>", args[0])
+
+	var n int
+	r := jobs.RequeueRequest{
+		Pipeline: args[0],
+		Options:  jobs.RequeueOptions{Jobs: requeueJobs, Max: requeueMax},
+	}
+
+	if err := client.Call("jobs.Requeue", r, &n); err != nil {
+		return err
+	}
+
+	util.Printf("<green+hb>%v</reset> <green>job(s) requeued</reset>\n", n)
+	return nil
+}
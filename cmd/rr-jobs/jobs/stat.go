@@ -97,12 +97,34 @@ func showStats(client *rpc.Client) {
 	StatTable(s.Pipelines).Render()
 }
 
+// ageUnknown lists brokers which are unable to tell the age of the oldest pending job.
+var ageUnknown = map[string]bool{"amqp": true, "sqs": true}
+
 // StatTable renders table with information about all active pipelines.
 func StatTable(pipelines []*jobs.Stat) *tablewriter.Table {
 	tw := tablewriter.NewWriter(os.Stdout)
-	tw.SetHeader([]string{"Pipeline", "Broker", "Name", "Queue", "Delayed", "Active"})
+	tw.SetHeader([]string{
+		"Pipeline", "Broker", "Name", "Queue", "Delayed", "Active", "Capacity", "Oldest",
+		"Pushed", "Push/s", "Processed", "Errors", "Retried", "Failed", "Dropped", "Outbox",
+		"Rate/s", "Avg", "P95", "Breaker",
+	})
 
 	for _, p := range pipelines {
+		oldest := p.OldestAge.Round(time.Second).String()
+		if ageUnknown[p.Broker] {
+			oldest = "n/a"
+		}
+
+		capacity := "-"
+		if p.Capacity != 0 {
+			capacity = humanize.Comma(p.Capacity)
+		}
+
+		breaker := "-"
+		if p.Breaker != "" {
+			breaker = p.Breaker
+		}
+
 		tw.Append([]string{
 			util.Sprintf("<cyan>%s</reset>", p.Pipeline),
 			util.Sprintf("<white+hb>%s</reset>", p.Broker),
@@ -110,6 +132,20 @@ func StatTable(pipelines []*jobs.Stat) *tablewriter.Table {
 			util.Sprintf("<magenta>%s</reset>", humanize.Comma(p.Queue)),
 			util.Sprintf("<yellow>%s</reset>", humanize.Comma(p.Delayed)),
 			util.Sprintf("<green>%s</reset>", humanize.Comma(p.Active)),
+			util.Sprintf("<white+hb>%s</reset>", capacity),
+			util.Sprintf("<yellow>%s</reset>", oldest),
+			util.Sprintf("<magenta>%s</reset>", humanize.Comma(p.Pushed)),
+			util.Sprintf("<white+hb>%.2f</reset>", p.PushRate),
+			util.Sprintf("<green>%s</reset>", humanize.Comma(p.Processed)),
+			util.Sprintf("<red>%s</reset>", humanize.Comma(p.Errors)),
+			util.Sprintf("<yellow>%s</reset>", humanize.Comma(p.Retried)),
+			util.Sprintf("<red>%s</reset>", humanize.Comma(p.Failed)),
+			util.Sprintf("<red>%s</reset>", humanize.Comma(p.Dropped)),
+			util.Sprintf("<yellow>%s</reset>", humanize.Comma(p.Outbox)),
+			util.Sprintf("<white+hb>%.2f</reset>", p.ProcessRate),
+			util.Sprintf("<cyan>%v</reset>", p.AvgDuration.Round(time.Microsecond)),
+			util.Sprintf("<cyan>%v</reset>", p.P95Duration.Round(time.Microsecond)),
+			util.Sprintf("<white+hb>%s</reset>", breaker),
 		})
 	}
 
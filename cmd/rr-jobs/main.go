@@ -5,6 +5,13 @@ import (
 	"github.com/spiral/jobs/v2/broker/amqp"
 	"github.com/spiral/jobs/v2/broker/beanstalk"
 	"github.com/spiral/jobs/v2/broker/ephemeral"
+	"github.com/spiral/jobs/v2/broker/fanout"
+	"github.com/spiral/jobs/v2/broker/http"
+	"github.com/spiral/jobs/v2/broker/local"
+	"github.com/spiral/jobs/v2/broker/nats"
+	"github.com/spiral/jobs/v2/broker/redis"
+	"github.com/spiral/jobs/v2/broker/spool"
+	"github.com/spiral/jobs/v2/broker/sql"
 	"github.com/spiral/jobs/v2/broker/sqs"
 
 	rr "github.com/spiral/roadrunner/cmd/rr/cmd"
@@ -12,6 +19,8 @@ import (
 	"github.com/spiral/roadrunner/service/metrics"
 	"github.com/spiral/roadrunner/service/rpc"
 
+	_ "github.com/go-sql-driver/mysql"
+	_ "github.com/lib/pq"
 	_ "github.com/spiral/jobs/v2/cmd/rr-jobs/jobs"
 )
 
@@ -23,6 +32,13 @@ func main() {
 			"ephemeral": &ephemeral.Broker{},
 			"beanstalk": &beanstalk.Broker{},
 			"sqs":       &sqs.Broker{},
+			"redis":     &redis.Broker{},
+			"nats":      &nats.Broker{},
+			"local":     &local.Broker{},
+			"sql":       &sql.Broker{},
+			"spool":     &spool.Broker{},
+			"http":      &http.Broker{},
+			"fanout":    &fanout.Broker{},
 		},
 	})
 
@@ -17,6 +17,24 @@ type Config struct {
 	// Pipelines defines mapping between PHP job pipeline and associated job broker.
 	Pipelines map[string]*Pipeline
 
+	// Schemas defines JSON schemas to validate job payloads with (by job name pattern).
+	Schemas map[string]*Schema
+
+	// Audit configures persistent audit log of job lifecycle events.
+	Audit *AuditConfig
+
+	// Notify defines webhooks to be notified about job and pipeline failures.
+	Notify []*NotifyConfig
+
+	// Autoscale configures automatic scaling of the worker pool.
+	Autoscale *AutoscaleConfig
+
+	// Health configures HTTP health and readiness endpoints.
+	Health *HealthConfig
+
+	// Outbox configures local journal of jobs which can not be pushed due to broker outage.
+	Outbox *OutboxConfig
+
 	// Consuming specifies names of pipelines to be consumed on service start.
 	Consume []string
 
@@ -24,6 +42,7 @@ type Config struct {
 	parent    service.Config
 	pipelines Pipelines
 	route     Dispatcher
+	schemas   Schemas
 }
 
 // Hydrate populates config values.
@@ -46,9 +65,31 @@ func (c *Config) Hydrate(cfg service.Config) (err error) {
 		}
 	}
 
+	if c.Autoscale != nil {
+		c.Autoscale.InitDefaults()
+		if err := c.Autoscale.Valid(); err != nil {
+			return err
+		}
+	}
+
+	if c.Outbox != nil {
+		c.Outbox.InitDefaults()
+	}
+
 	c.parent = cfg
 	c.route = initDispatcher(c.Dispatch)
 
+	c.schemas, err = initSchemas(c.Schemas)
+	if err != nil {
+		return err
+	}
+
+	for _, s := range c.schemas {
+		if s.Quarantine != "" && c.pipelines.Get(s.Quarantine) == nil {
+			return fmt.Errorf("undefined quarantine pipeline `%s`", s.Quarantine)
+		}
+	}
+
 	return nil
 }
 
@@ -14,17 +14,32 @@ type Dispatcher map[string]*Options
 func initDispatcher(routes map[string]*Options) Dispatcher {
 	dispatcher := make(Dispatcher)
 	for pattern, opts := range routes {
-		pattern = strings.ToLower(pattern)
-		pattern = strings.Trim(pattern, "-.*")
+		dispatcher[normalizePattern(pattern)] = opts
+	}
 
-		for _, s := range separators {
-			pattern = strings.Replace(pattern, s, ".", -1)
-		}
+	return dispatcher
+}
 
-		dispatcher[pattern] = opts
+// normalizeName brings job name to the form comparable with normalized patterns.
+func normalizeName(name string) string {
+	name = strings.ToLower(name)
+	for _, s := range separators {
+		name = strings.Replace(name, s, ".", -1)
 	}
 
-	return dispatcher
+	return name
+}
+
+// normalizePattern brings job name pattern to the canonical form.
+func normalizePattern(pattern string) string {
+	pattern = strings.ToLower(pattern)
+	pattern = strings.Trim(pattern, "-.*")
+
+	for _, s := range separators {
+		pattern = strings.Replace(pattern, s, ".", -1)
+	}
+
+	return pattern
 }
 
 // match clarifies target job pipeline and other job options. Can return nil.
@@ -35,8 +35,102 @@ const (
 
 	// EventBrokerReady thrown when broken is ready to accept/serve tasks.
 	EventBrokerReady
+
+	// EventJobRetry thrown when failed job is scheduled for the next attempt. See JobError as context.
+	EventJobRetry
+
+	// EventJobDead thrown when failed job has exhausted all of it's attempts. See JobError as context.
+	EventJobDead
+
+	// EventJobRelease thrown when broker has returned job back to the queue for the next attempt. JobEvent is
+	// passed as context.
+	EventJobRelease
+
+	// EventJobTimeout thrown when job execution took longer than job timeout, broker might already deliver the job
+	// to another consumer. JobEvent is passed as context.
+	EventJobTimeout
+
+	// EventBreakerOpen thrown when pipeline consuming has been paused by circuit breaker. BreakerEvent is passed
+	// as context.
+	EventBreakerOpen
+
+	// EventBreakerHalfOpen thrown when circuit breaker starts probing the pipeline. BreakerEvent is passed as context.
+	EventBreakerHalfOpen
+
+	// EventBreakerClose thrown when circuit breaker resumed pipeline consuming. BreakerEvent is passed as context.
+	EventBreakerClose
+
+	// EventScale thrown when worker pool has been resized by autoscaler. ScaleEvent is passed as context.
+	EventScale
+
+	// EventScaleError thrown when autoscaler is unable to resize the worker pool. ScaleEvent is passed as context.
+	EventScaleError
+
+	// EventPushFailover thrown when job has been pushed into fallback pipeline. FailoverEvent is passed as context.
+	EventPushFailover
+
+	// EventFailback thrown when jobs have been moved from fallback pipeline back to the primary one. FailoverEvent
+	// is passed as context.
+	EventFailback
+
+	// EventPushDeferred thrown when job has been journaled in the outbox due to broker outage. OutboxEvent is passed
+	// as context.
+	EventPushDeferred
+
+	// EventPushReplayed thrown when journaled job has been pushed into the pipeline. OutboxEvent is passed as context.
+	EventPushReplayed
+
+	// EventOutboxError thrown when outbox is unable to replay the jobs. OutboxEvent is passed as context.
+	EventOutboxError
+
+	// EventPushDiscarded thrown when journaled job is broken or rejected by the broker and has been moved out of
+	// the replay. OutboxEvent is passed as context.
+	EventPushDiscarded
+
+	// EventAuditError thrown when job event can not be written into the audit log. AuditError is passed as context.
+	EventAuditError
+
+	// EventNotifyError thrown when notifications can not be delivered to the webhook after all retries. NotifyError
+	// is passed as context.
+	EventNotifyError
 )
 
+// eventNames contains printable names of job and pipeline events.
+var eventNames = map[int]string{
+	EventPushOK:      "push",
+	EventPushError:   "push.error",
+	EventJobStart:    "job.start",
+	EventJobOK:       "job.ok",
+	EventJobError:    "job.error",
+	EventJobRetry:    "job.retry",
+	EventJobDead:     "job.dead",
+	EventJobRelease:  "job.release",
+	EventJobTimeout:  "job.timeout",
+	EventPipeConsume: "pipe.consume",
+	EventPipeActive:  "pipe.active",
+	EventPipeStop:    "pipe.stop",
+	EventPipeStopped: "pipe.stopped",
+	EventPipeError:   "pipe.error",
+
+	EventBreakerOpen:     "breaker.open",
+	EventBreakerHalfOpen: "breaker.half-open",
+	EventBreakerClose:    "breaker.close",
+
+	EventScale:      "scale",
+	EventScaleError: "scale.error",
+
+	EventPushFailover: "push.failover",
+	EventFailback:     "failback",
+
+	EventPushDeferred: "push.deferred",
+	EventPushReplayed: "push.replayed",
+	EventOutboxError:  "outbox.error",
+
+	EventPushDiscarded: "push.discarded",
+	EventAuditError:    "audit.error",
+	EventNotifyError:   "notify.error",
+}
+
 // JobEvent represent job event.
 type JobEvent struct {
 	// String is job id.
@@ -45,6 +139,15 @@ type JobEvent struct {
 	// Job is failed job.
 	Job *Job
 
+	// Pipeline is name of the job pipeline (when known).
+	Pipeline string
+
+	// Attempt is job attempt number, starting from 0.
+	Attempt int
+
+	// QueueWait defines how long job has been waiting in the queue before the execution (when known).
+	QueueWait time.Duration
+
 	// event timings
 	start   time.Time
 	elapsed time.Duration
@@ -66,6 +169,18 @@ type JobError struct {
 	// Caused contains job specific error.
 	Caused error
 
+	// Pipeline is name of the job pipeline (when known).
+	Pipeline string
+
+	// Attempt is job attempt number, starting from 0.
+	Attempt int
+
+	// QueueWait defines how long job has been waiting in the queue before the execution (when known).
+	QueueWait time.Duration
+
+	// NextRetry defines when job will be executed again, only set for EventJobRetry.
+	NextRetry time.Time
+
 	// event timings
 	start   time.Time
 	elapsed time.Duration
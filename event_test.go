@@ -50,3 +50,44 @@ func TestPipelineError_Error(t *testing.T) {
 
 	assert.Equal(t, "error", e.Error())
 }
+
+func TestService_Error_RetryDead(t *testing.T) {
+	svc := &Service{}
+
+	events := make(chan int, 2)
+	var ctx *JobError
+	svc.AddListener(func(event int, c interface{}) {
+		ctx = c.(*JobError)
+		events <- event
+	})
+
+	j := &Job{Job: "job", Options: &Options{Attempts: 2, RetryDelay: 10}}
+
+	j.Delivery = &Delivery{Pipeline: "default", Attempt: 0}
+	svc.error("id", j, errors.New("error"))
+	assert.Equal(t, EventJobRetry, <-events)
+	assert.Equal(t, "default", ctx.Pipeline)
+	assert.Equal(t, 0, ctx.Attempt)
+	assert.True(t, ctx.NextRetry.After(time.Now().Add(9*time.Second)))
+
+	j.Delivery = &Delivery{Pipeline: "default", Attempt: 1}
+	svc.error("id", j, errors.New("error"))
+	assert.Equal(t, EventJobDead, <-events)
+	assert.Equal(t, 1, ctx.Attempt)
+	assert.True(t, ctx.NextRetry.IsZero())
+}
+
+func TestService_Error_UnknownAttempt(t *testing.T) {
+	svc := &Service{}
+
+	events := make(chan int, 1)
+	svc.AddListener(func(event int, c interface{}) {
+		events <- event
+	})
+
+	j := &Job{Job: "job", Options: &Options{Attempts: 2}}
+	j.Delivery = &Delivery{Pipeline: "default", AttemptUnknown: true}
+
+	svc.error("id", j, errors.New("error"))
+	assert.Len(t, events, 0)
+}
@@ -0,0 +1,243 @@
+package jobs
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// failoverCheck defines how often broker health of the failover pipelines is refreshed.
+const failoverCheck = time.Second
+
+// FailoverEvent is passed with failover and failback events.
+type FailoverEvent struct {
+	// ID of the job pushed into fallback pipeline, empty for failback.
+	ID string
+
+	// Job pushed into fallback pipeline, nil for failback.
+	Job *Job
+
+	// Pipeline is name of the primary pipeline.
+	Pipeline string
+
+	// Target is name of the fallback pipeline.
+	Target string
+
+	// Caused contains error of the primary pipeline, nil for failback.
+	Caused error
+
+	// Moved defines number of jobs moved back to the primary pipeline, only set for failback.
+	Moved int
+}
+
+// failover pushes jobs into fallback pipelines when primary pipeline broker is unavailable. Failover is configured
+// using pipeline options:
+//
+//	failover: ["sqs", "local"] # fallback pipelines in order of preference
+//	failback: 30               # seconds between attempts to move jobs back into recovered primary, 0 - disabled
+//
+// Broker health is cached and refreshed every second while serving, pushes do not check brokers on their own.
+type failover struct {
+	svc      *Service
+	pipe     *Pipeline
+	targets  []*Pipeline
+	interval time.Duration
+
+	// cached broker health of the pipelines
+	mu     sync.Mutex
+	health map[*Pipeline]error
+
+	stopped chan interface{}
+	once    sync.Once
+}
+
+// newFailover creates failover of the given pipeline.
+func newFailover(svc *Service, pipe *Pipeline) (*failover, error) {
+	f := &failover{
+		svc:      svc,
+		pipe:     pipe,
+		interval: pipe.Duration("failback", 0),
+		health:   make(map[*Pipeline]error),
+		stopped:  make(chan interface{}),
+	}
+
+	for _, name := range pipe.Strings("failover") {
+		target := svc.cfg.pipelines.Get(name)
+		if target == nil {
+			return nil, fmt.Errorf("undefined failover pipeline `%s`", name)
+		}
+
+		if target == pipe {
+			return nil, fmt.Errorf("pipeline `%s` can not fail over to itself", name)
+		}
+
+		f.targets = append(f.targets, target)
+	}
+
+	return f, nil
+}
+
+// push job into the first available pipeline. Pipelines with unhealthy brokers are skipped without push attempt,
+// push errors not caused by broker connectivity are returned right away.
+func (f *failover) push(job *Job) (string, error) {
+	var caused error
+	for i, target := range append([]*Pipeline{f.pipe}, f.targets...) {
+		j := job
+		if i != 0 {
+			j = &Job{Job: job.Job, Payload: job.Payload, Options: &Options{}}
+			if job.Options != nil {
+				*j.Options = *job.Options
+			}
+
+			j.Options.Pipeline = target.Name()
+		}
+
+		if err := f.healthy(target); err != nil {
+			f.svc.throw(EventPushError, &JobError{Job: j, Caused: err, Pipeline: target.Name()})
+			if caused == nil {
+				caused = err
+			}
+
+			continue
+		}
+
+		id, err := f.svc.push(target, j)
+		if err != nil {
+			if !f.svc.unavailable(target, err) {
+				// job is rejected by the available broker, fallback pipelines would reject it as well
+				return "", err
+			}
+
+			if caused == nil {
+				caused = err
+			}
+
+			continue
+		}
+
+		if i != 0 {
+			f.svc.throw(EventPushFailover, &FailoverEvent{
+				ID:       id,
+				Job:      j,
+				Pipeline: f.pipe.Name(),
+				Target:   target.Name(),
+				Caused:   caused,
+			})
+		}
+
+		return id, nil
+	}
+
+	return "", caused
+}
+
+// serve refreshes broker health and moves jobs back from fallback pipelines while primary pipeline is healthy,
+// until stopped.
+func (f *failover) serve() {
+	check := time.NewTicker(failoverCheck)
+	defer check.Stop()
+
+	var failback <-chan time.Time
+	if f.interval != 0 {
+		ticker := time.NewTicker(f.interval)
+		defer ticker.Stop()
+
+		failback = ticker.C
+	}
+
+	for {
+		select {
+		case <-f.stopped:
+			return
+		case <-check.C:
+			for _, pipe := range append([]*Pipeline{f.pipe}, f.targets...) {
+				f.check(pipe)
+			}
+		case <-failback:
+			f.failback()
+		}
+	}
+}
+
+// healthy returns cached broker health of the pipeline, broker is checked only when health is not known yet.
+func (f *failover) healthy(pipe *Pipeline) error {
+	f.mu.Lock()
+	err, ok := f.health[pipe]
+	f.mu.Unlock()
+
+	if !ok {
+		return f.check(pipe)
+	}
+
+	return err
+}
+
+// check refreshes broker health of the pipeline.
+func (f *failover) check(pipe *Pipeline) error {
+	err := f.svc.healthy(pipe)
+
+	f.mu.Lock()
+	f.health[pipe] = err
+	f.mu.Unlock()
+
+	return err
+}
+
+// failback moves ready jobs from fallback pipelines into recovered primary pipeline. Consumed fallback pipelines
+// are skipped as their jobs are executed anyway.
+func (f *failover) failback() {
+	if f.check(f.pipe) != nil {
+		return
+	}
+
+	for _, target := range f.targets {
+		f.svc.mup.Lock()
+		consuming := f.svc.pipelines[target]
+		f.svc.mup.Unlock()
+
+		if consuming {
+			continue
+		}
+
+		stat, err := f.svc.Stat(target)
+		if err != nil {
+			f.svc.throw(EventPipeError, &PipelineError{Pipeline: target, Caused: err})
+			continue
+		}
+
+		if stat.Queue == 0 {
+			continue
+		}
+
+		// jobs are pushed directly, failover of the primary pipeline would bring them back
+		n, err := f.svc.move(target, f.pipe, MoveOptions{}, f.svc.push)
+		if err != nil {
+			f.svc.throw(EventPipeError, &PipelineError{Pipeline: target, Caused: err})
+		}
+
+		if n != 0 {
+			f.svc.throw(EventFailback, &FailoverEvent{Pipeline: f.pipe.Name(), Target: target.Name(), Moved: n})
+		}
+	}
+}
+
+// stop moving jobs back.
+func (f *failover) stop() {
+	f.once.Do(func() {
+		close(f.stopped)
+	})
+}
+
+// healthy returns error if pipeline broker is undefined or reports connectivity issues.
+func (svc *Service) healthy(pipe *Pipeline) error {
+	b, ok := svc.Brokers[pipe.Broker()]
+	if !ok {
+		return fmt.Errorf("undefined broker `%s`", pipe.Broker())
+	}
+
+	if hc, ok := b.(HealthChecker); ok {
+		return hc.Health()
+	}
+
+	return nil
+}
@@ -0,0 +1,292 @@
+package jobs
+
+import (
+	"errors"
+	"github.com/sirupsen/logrus"
+	"github.com/spiral/roadrunner/service"
+	"github.com/stretchr/testify/assert"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type flakyBroker struct {
+	testBroker
+	down int32
+}
+
+func (b *flakyBroker) Health() error {
+	if atomic.LoadInt32(&b.down) == 1 {
+		return errors.New("connection is dead")
+	}
+
+	return nil
+}
+
+func (b *flakyBroker) Push(pipe *Pipeline, j *Job) (string, error) {
+	if err := b.Health(); err != nil {
+		return "", err
+	}
+
+	return b.testBroker.Push(pipe, j)
+}
+
+func TestService_Failover(t *testing.T) {
+	c := service.NewContainer(logrus.New())
+	c.Register("jobs", &Service{Brokers: map[string]Broker{
+		"ephemeral": &testBroker{},
+		"dead":      &deadBroker{},
+	}})
+
+	assert.NoError(t, c.Init(viperConfig(`{
+	"jobs":{
+		"pipelines":{
+			"default":{"broker":"dead", "failover":["broken", "backup"]},
+			"broken":{"broker":"dead"},
+			"backup":{"broker":"ephemeral"}
+		}
+	}
+}`)))
+
+	svc := jobs(c)
+
+	ready := make(chan interface{}, 2)
+	failover := make(chan *FailoverEvent, 1)
+	errors := make(chan *JobError, 2)
+	svc.AddListener(func(event int, ctx interface{}) {
+		switch event {
+		case EventBrokerReady:
+			ready <- nil
+		case EventPushFailover:
+			failover <- ctx.(*FailoverEvent)
+		case EventPushError:
+			errors <- ctx.(*JobError)
+		}
+	})
+
+	go func() { c.Serve() }()
+	defer c.Stop()
+	<-ready
+	<-ready
+
+	id, err := svc.Push(&Job{Job: "test", Payload: "body", Options: &Options{Pipeline: "default"}})
+	assert.NoError(t, err)
+
+	assert.Equal(t, "default", (<-errors).Pipeline)
+	assert.Equal(t, "broken", (<-errors).Pipeline)
+
+	e := <-failover
+	assert.Equal(t, id, e.ID)
+	assert.Equal(t, "default", e.Pipeline)
+	assert.Equal(t, "backup", e.Target)
+	assert.Equal(t, "backup", e.Job.Options.Pipeline)
+	assert.Equal(t, "connection is dead", e.Caused.Error())
+
+	stat, err := svc.Stat(svc.cfg.pipelines.Get("backup"))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), stat.Queue)
+}
+
+func TestService_Failover_Exhausted(t *testing.T) {
+	c := service.NewContainer(logrus.New())
+	c.Register("jobs", &Service{Brokers: map[string]Broker{"dead": &deadBroker{}}})
+
+	assert.NoError(t, c.Init(viperConfig(`{
+	"jobs":{
+		"pipelines":{
+			"default":{"broker":"dead", "failover":["backup"]},
+			"backup":{"broker":"dead"}
+		}
+	}
+}`)))
+
+	svc := jobs(c)
+
+	ready := make(chan interface{})
+	svc.AddListener(func(event int, ctx interface{}) {
+		if event == EventBrokerReady {
+			close(ready)
+		}
+	})
+
+	go func() { c.Serve() }()
+	defer c.Stop()
+	<-ready
+
+	_, err := svc.Push(&Job{Job: "test", Payload: "body", Options: &Options{Pipeline: "default"}})
+	assert.Error(t, err)
+	assert.Equal(t, "connection is dead", err.Error())
+}
+
+func TestService_Failover_Rejected(t *testing.T) {
+	c := service.NewContainer(logrus.New())
+	c.Register("jobs", &Service{Brokers: map[string]Broker{
+		"ephemeral": &testBroker{},
+		"reject":    &rejectBroker{},
+	}})
+
+	assert.NoError(t, c.Init(viperConfig(`{
+	"jobs":{
+		"pipelines":{
+			"default":{"broker":"reject", "failover":["backup"]},
+			"backup":{"broker":"ephemeral"}
+		}
+	}
+}`)))
+
+	svc := jobs(c)
+
+	ready := make(chan interface{}, 2)
+	svc.AddListener(func(event int, ctx interface{}) {
+		if event == EventBrokerReady {
+			ready <- nil
+		}
+	})
+
+	go func() { c.Serve() }()
+	defer c.Stop()
+	<-ready
+	<-ready
+
+	_, err := svc.Push(&Job{Job: "test", Payload: "body", Options: &Options{Pipeline: "default"}})
+	assert.Error(t, err)
+	assert.Equal(t, "job rejected", err.Error())
+
+	stat, err := svc.Stat(svc.cfg.pipelines.Get("backup"))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), stat.Queue)
+}
+
+func TestService_Failback(t *testing.T) {
+	primary := &flakyBroker{down: 1}
+
+	c := service.NewContainer(logrus.New())
+	c.Register("jobs", &Service{Brokers: map[string]Broker{"ephemeral": &testBroker{}, "flaky": primary}})
+
+	assert.NoError(t, c.Init(viperConfig(`{
+	"jobs":{
+		"pipelines":{
+			"default":{"broker":"flaky", "failover":["backup"]},
+			"backup":{"broker":"ephemeral"}
+		}
+	}
+}`)))
+
+	svc := jobs(c)
+
+	pipe := svc.cfg.pipelines.Get("default")
+	(*pipe)["failback"] = 1
+
+	f, err := newFailover(svc, pipe)
+	assert.NoError(t, err)
+	svc.failovers[pipe] = f
+
+	ready := make(chan interface{}, 2)
+	failback := make(chan *FailoverEvent, 1)
+	svc.AddListener(func(event int, ctx interface{}) {
+		switch event {
+		case EventBrokerReady:
+			ready <- nil
+		case EventFailback:
+			failback <- ctx.(*FailoverEvent)
+		}
+	})
+
+	go func() { c.Serve() }()
+	defer c.Stop()
+	<-ready
+	<-ready
+
+	for i := 0; i < 2; i++ {
+		_, err = svc.Push(&Job{Job: "test", Payload: "body", Options: &Options{Pipeline: "default"}})
+		assert.NoError(t, err)
+	}
+
+	atomic.StoreInt32(&primary.down, 0)
+
+	select {
+	case e := <-failback:
+		assert.Equal(t, "default", e.Pipeline)
+		assert.Equal(t, "backup", e.Target)
+		assert.Equal(t, 2, e.Moved)
+	case <-time.After(5 * time.Second):
+		t.Fatal("jobs were not moved back")
+	}
+
+	stat, err := svc.Stat(pipe)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), stat.Queue)
+
+	stat, err = svc.Stat(svc.cfg.pipelines.Get("backup"))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), stat.Queue)
+}
+
+func TestService_Failover_Undefined(t *testing.T) {
+	c := service.NewContainer(logrus.New())
+	c.Register("jobs", &Service{Brokers: map[string]Broker{"ephemeral": &testBroker{}}})
+
+	assert.Error(t, c.Init(viperConfig(`{
+	"jobs":{
+		"pipelines":{
+			"default":{"broker":"ephemeral", "failover":["missing"]}
+		}
+	}
+}`)))
+
+	c = service.NewContainer(logrus.New())
+	c.Register("jobs", &Service{Brokers: map[string]Broker{"ephemeral": &testBroker{}}})
+
+	assert.Error(t, c.Init(viperConfig(`{
+	"jobs":{
+		"pipelines":{
+			"default":{"broker":"ephemeral", "failover":["default"]}
+		}
+	}
+}`)))
+}
+
+func TestService_Failover_HealthCached(t *testing.T) {
+	primary := &flakyBroker{}
+
+	c := service.NewContainer(logrus.New())
+	c.Register("jobs", &Service{Brokers: map[string]Broker{"ephemeral": &testBroker{}, "flaky": primary}})
+
+	assert.NoError(t, c.Init(viperConfig(`{
+	"jobs":{
+		"pipelines":{
+			"default":{"broker":"flaky", "failover":["backup"]},
+			"backup":{"broker":"ephemeral"}
+		}
+	}
+}`)))
+
+	svc := jobs(c)
+	f := svc.failovers[svc.cfg.pipelines.Get("default")]
+
+	ready := make(chan interface{}, 2)
+	svc.AddListener(func(event int, ctx interface{}) {
+		if event == EventBrokerReady {
+			ready <- nil
+		}
+	})
+
+	go func() { c.Serve() }()
+	defer c.Stop()
+	<-ready
+	<-ready
+
+	_, err := svc.Push(&Job{Job: "test", Payload: "body", Options: &Options{Pipeline: "default"}})
+	assert.NoError(t, err)
+
+	// broker outage is noticed on the next health refresh
+	atomic.StoreInt32(&primary.down, 1)
+	assert.NoError(t, f.healthy(svc.cfg.pipelines.Get("default")))
+
+	time.Sleep(failoverCheck + 100*time.Millisecond)
+	assert.Error(t, f.healthy(svc.cfg.pipelines.Get("default")))
+
+	stat, err := svc.Stat(svc.cfg.pipelines.Get("default"))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), stat.Queue)
+}
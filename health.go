@@ -0,0 +1,192 @@
+package jobs
+
+import (
+	"context"
+	json "github.com/json-iterator/go"
+	"net"
+	"net/http"
+	"sort"
+)
+
+// HealthConfig configures HTTP health and readiness endpoints.
+type HealthConfig struct {
+	// Address to serve /health and /ready endpoints on.
+	Address string
+}
+
+// Health describes state of the brokers, pipelines and worker pool.
+type Health struct {
+	// Healthy indicates that all brokers are connected and worker pool is alive.
+	Healthy bool `json:"healthy"`
+
+	// Ready indicates that service is healthy and all pipelines to be consumed on start are consumed, pipelines
+	// paused by the operator or by the open circuit breaker are not taken into account.
+	Ready bool `json:"ready"`
+
+	// Brokers contains connectivity state of every broker.
+	Brokers []*BrokerHealth `json:"brokers"`
+
+	// Pipelines contains consuming state of every pipeline.
+	Pipelines []*PipelineHealth `json:"pipelines"`
+
+	// Workers contains worker pool state, nil when service runs without workers.
+	Workers *WorkersHealth `json:"workers,omitempty"`
+}
+
+// BrokerHealth describes broker connectivity.
+type BrokerHealth struct {
+	// Broker name.
+	Broker string `json:"broker"`
+
+	// Healthy indicates that broker is able to serve pipelines.
+	Healthy bool `json:"healthy"`
+
+	// Error reported by the broker.
+	Error string `json:"error,omitempty"`
+}
+
+// PipelineHealth describes pipeline consuming state.
+type PipelineHealth struct {
+	// Pipeline name.
+	Pipeline string `json:"pipeline"`
+
+	// Broker is name of associated broker.
+	Broker string `json:"broker"`
+
+	// Consuming indicates that pipeline is being consumed.
+	Consuming bool `json:"consuming"`
+
+	// Required indicates that pipeline must be consumed for service to be ready.
+	Required bool `json:"required"`
+
+	// Paused indicates that pipeline consuming is paused by the operator.
+	Paused bool `json:"paused"`
+
+	// Breaker defines state of the pipeline circuit breaker (if any).
+	Breaker string `json:"breaker,omitempty"`
+}
+
+// WorkersHealth describes worker pool liveness.
+type WorkersHealth struct {
+	// Total number of workers.
+	Total int `json:"total"`
+
+	// Active is number of ready or working workers.
+	Active int `json:"active"`
+}
+
+// Health checks brokers connectivity, pipelines consuming state and worker pool liveness.
+func (svc *Service) Health() *Health {
+	h := &Health{Healthy: true, Ready: true}
+
+	for name, b := range svc.Brokers {
+		bh := &BrokerHealth{Broker: name, Healthy: true}
+		if hc, ok := b.(HealthChecker); ok {
+			if err := hc.Health(); err != nil {
+				bh.Healthy, bh.Error = false, err.Error()
+				h.Healthy = false
+			}
+		}
+
+		h.Brokers = append(h.Brokers, bh)
+	}
+
+	sort.Slice(h.Brokers, func(i, j int) bool { return h.Brokers[i].Broker < h.Brokers[j].Broker })
+
+	// pipelines consumed on start, including the ones executed by their brokers
+	required := make(map[*Pipeline]bool)
+	for _, p := range svc.cfg.pipelines.Names(svc.cfg.Consume...) {
+		required[p] = svc.pool(p) != nil
+	}
+
+	for _, p := range svc.cfg.pipelines {
+		ph := &PipelineHealth{Pipeline: p.Name(), Broker: p.Broker(), Required: required[p]}
+
+		svc.mup.Lock()
+		ph.Consuming, ph.Paused = svc.pipelines[p], svc.held[p]
+		svc.mup.Unlock()
+
+		if b, ok := svc.breakers[p]; ok {
+			ph.Breaker = b.State()
+		}
+
+		if ph.Required && !ph.Consuming && !ph.Paused && ph.Breaker != BreakerOpen {
+			h.Ready = false
+		}
+
+		h.Pipelines = append(h.Pipelines, ph)
+	}
+
+	if svc.rr != nil {
+		h.Workers = &WorkersHealth{}
+		for _, w := range svc.rr.Workers() {
+			h.Workers.Total++
+			if w.State().IsActive() {
+				h.Workers.Active++
+			}
+		}
+
+		if h.Workers.Active == 0 {
+			h.Healthy = false
+		}
+	}
+
+	h.Ready = h.Ready && h.Healthy
+	return h
+}
+
+// healthServer serves health and readiness endpoints.
+type healthServer struct {
+	http *http.Server
+	ln   net.Listener
+}
+
+// newHealthServer creates health endpoints server.
+func newHealthServer(svc *Service, cfg *HealthConfig) *healthServer {
+	s := &healthServer{}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
+		h := svc.Health()
+		s.respond(w, h, h.Healthy)
+	})
+
+	mux.HandleFunc("/ready", func(w http.ResponseWriter, r *http.Request) {
+		h := svc.Health()
+		s.respond(w, h, h.Ready)
+	})
+
+	s.http = &http.Server{Addr: cfg.Address, Handler: mux}
+	return s
+}
+
+// listen opens health endpoints socket.
+func (s *healthServer) listen() (err error) {
+	s.ln, err = net.Listen("tcp", s.http.Addr)
+	return err
+}
+
+// serve health endpoints until stopped.
+func (s *healthServer) serve() error {
+	err := s.http.Serve(s.ln)
+	if err != nil && err != http.ErrServerClosed {
+		return err
+	}
+
+	return nil
+}
+
+// stop health endpoints.
+func (s *healthServer) stop() {
+	s.http.Shutdown(context.Background())
+}
+
+// respond with health report and status code.
+func (s *healthServer) respond(w http.ResponseWriter, h *Health, ok bool) {
+	w.Header().Set("Content-Type", "application/json")
+	if !ok {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+
+	json.NewEncoder(w).Encode(h)
+}
@@ -0,0 +1,190 @@
+package jobs
+
+import (
+	"errors"
+	json "github.com/json-iterator/go"
+	"github.com/sirupsen/logrus"
+	"github.com/spiral/roadrunner/service"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"testing"
+)
+
+type deadBroker struct{ testBroker }
+
+func (b *deadBroker) Health() error {
+	return errors.New("connection is dead")
+}
+
+func TestService_Health(t *testing.T) {
+	c := service.NewContainer(logrus.New())
+	c.Register("jobs", &Service{Brokers: map[string]Broker{"ephemeral": &testBroker{}}})
+
+	assert.NoError(t, c.Init(viperConfig(`{
+	"jobs":{
+		"pipelines":{"default":{"broker":"ephemeral"}},
+		"health":{"address":"localhost:0"}
+	}
+}`)))
+
+	ready := make(chan interface{})
+	jobs(c).AddListener(func(event int, ctx interface{}) {
+		if event == EventBrokerReady {
+			close(ready)
+		}
+	})
+
+	go func() { c.Serve() }()
+	defer c.Stop()
+	<-ready
+
+	h := jobs(c).Health()
+	assert.True(t, h.Healthy)
+	assert.True(t, h.Ready)
+	assert.Nil(t, h.Workers)
+	assert.Len(t, h.Brokers, 1)
+	assert.Len(t, h.Pipelines, 1)
+	assert.Equal(t, "default", h.Pipelines[0].Pipeline)
+	assert.False(t, h.Pipelines[0].Consuming)
+
+	addr := jobs(c).health.ln.Addr().String()
+	for _, path := range []string{"/health", "/ready"} {
+		r, err := http.Get("http://" + addr + path)
+		assert.NoError(t, err)
+
+		report := &Health{}
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(report))
+		r.Body.Close()
+
+		assert.Equal(t, http.StatusOK, r.StatusCode)
+		assert.True(t, report.Ready)
+	}
+}
+
+func TestService_Health_DeadBroker(t *testing.T) {
+	c := service.NewContainer(logrus.New())
+	c.Register("jobs", &Service{Brokers: map[string]Broker{"dead": &deadBroker{}}})
+
+	assert.NoError(t, c.Init(viperConfig(`{
+	"jobs":{
+		"pipelines":{"default":{"broker":"dead"}},
+		"health":{"address":"localhost:0"}
+	}
+}`)))
+
+	ready := make(chan interface{})
+	jobs(c).AddListener(func(event int, ctx interface{}) {
+		if event == EventBrokerReady {
+			close(ready)
+		}
+	})
+
+	go func() { c.Serve() }()
+	defer c.Stop()
+	<-ready
+
+	h := jobs(c).Health()
+	assert.False(t, h.Healthy)
+	assert.False(t, h.Ready)
+	assert.Equal(t, "connection is dead", h.Brokers[0].Error)
+
+	r, err := http.Get("http://" + jobs(c).health.ln.Addr().String() + "/ready")
+	assert.NoError(t, err)
+	r.Body.Close()
+
+	assert.Equal(t, http.StatusServiceUnavailable, r.StatusCode)
+}
+
+func TestService_Health_ServeError(t *testing.T) {
+	c := service.NewContainer(logrus.New())
+	c.Register("jobs", &Service{Brokers: map[string]Broker{"ephemeral": &testBroker{}}})
+
+	assert.NoError(t, c.Init(viperConfig(`{
+	"jobs":{
+		"pipelines":{"default":{"broker":"ephemeral"}},
+		"health":{"address":"localhost:0"}
+	}
+}`)))
+
+	svc := jobs(c)
+
+	ready := make(chan interface{})
+	svc.AddListener(func(event int, ctx interface{}) {
+		if event == EventBrokerReady {
+			close(ready)
+		}
+	})
+
+	served := make(chan error, 1)
+	go func() { served <- svc.Serve() }()
+	<-ready
+
+	// endpoints are no longer able to accept connections
+	svc.health.ln.Close()
+
+	assert.Error(t, <-served)
+}
+
+func TestService_Health_Paused(t *testing.T) {
+	b := &executorBroker{consumed: make(chan *Pipeline, 2)}
+
+	c := service.NewContainer(logrus.New())
+	c.Register("jobs", &Service{Brokers: map[string]Broker{"executor": b}})
+
+	assert.NoError(t, c.Init(viperConfig(`{
+	"jobs":{
+		"pipelines":{
+			"webhook":{"broker":"executor"},
+			"fragile":{"broker":"executor", "breaker":{"cooldown":"1h"}}
+		},
+		"consume": ["webhook", "fragile"]
+	}
+}`)))
+
+	svc := jobs(c)
+	webhook, fragile := svc.cfg.pipelines.Get("webhook"), svc.cfg.pipelines.Get("fragile")
+
+	stopped := make(chan interface{})
+	svc.AddListener(func(event int, ctx interface{}) {
+		if event == EventPipeStopped && ctx == fragile {
+			close(stopped)
+		}
+	})
+
+	go func() { c.Serve() }()
+	defer c.Stop()
+
+	<-b.consumed
+	<-b.consumed
+
+	h := svc.Health()
+	assert.True(t, h.Ready)
+	for _, ph := range h.Pipelines {
+		assert.True(t, ph.Required)
+		assert.True(t, ph.Consuming)
+	}
+
+	// paused by the operator
+	assert.NoError(t, svc.pause(webhook))
+
+	// paused by the breaker
+	br := svc.breakers[fragile]
+	br.mu.Lock()
+	br.open(1)
+	br.unlock()
+	<-stopped
+
+	h = svc.Health()
+	assert.True(t, h.Ready)
+	for _, ph := range h.Pipelines {
+		assert.False(t, ph.Consuming)
+		assert.Equal(t, ph.Pipeline == "webhook", ph.Paused)
+	}
+
+	// stopped without operator request
+	assert.NoError(t, svc.resume(webhook))
+	<-b.consumed
+	assert.NoError(t, svc.Consume(webhook, nil, nil))
+
+	assert.False(t, svc.Health().Ready)
+}
@@ -0,0 +1,166 @@
+package jobs
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	// JobReady indicates that job is waiting to be consumed.
+	JobReady = "ready"
+
+	// JobDelayed indicates that job is waiting for it's delay to pass.
+	JobDelayed = "delayed"
+
+	// JobReserved indicates that job is being processed.
+	JobReserved = "reserved"
+
+	// JobBuried indicates that job has been buried by the broker.
+	JobBuried = "buried"
+)
+
+// JobInfo describes pending job.
+type JobInfo struct {
+	// ID is broker specific job id.
+	ID string `json:"id"`
+
+	// Job contains job name, payload and options.
+	Job *Job `json:"job"`
+
+	// State of the job (when known).
+	State string `json:"state,omitempty"`
+
+	// Attempt is job attempt number, starting from 0.
+	Attempt int `json:"attempt"`
+
+	// Available defines when delayed job becomes ready, zero when unknown.
+	Available time.Time `json:"available"`
+}
+
+// FailedJob describes job which has exhausted all of it's attempts.
+type FailedJob struct {
+	// ID is broker specific job id.
+	ID string `json:"id"`
+
+	// Job contains job name, payload and options.
+	Job *Job `json:"job"`
+
+	// Attempts defines number of job attempts made.
+	Attempts int `json:"attempts"`
+
+	// Error returned by the last attempt.
+	Error string `json:"error"`
+
+	// Failed defines when the last attempt failed.
+	Failed time.Time `json:"failed"`
+}
+
+// List returns pending jobs of the pipeline.
+func (svc *Service) List(pipe *Pipeline, offset, limit int) ([]*JobInfo, error) {
+	i, err := svc.inspector(pipe)
+	if err != nil {
+		return nil, err
+	}
+
+	return i.List(pipe, offset, limit)
+}
+
+// Peek returns pending job of the pipeline by it's id.
+func (svc *Service) Peek(pipe *Pipeline, id string) (*JobInfo, error) {
+	i, err := svc.inspector(pipe)
+	if err != nil {
+		return nil, err
+	}
+
+	return i.Peek(pipe, id)
+}
+
+// Purge removes pending jobs from the pipeline.
+func (svc *Service) Purge(pipe *Pipeline, opts PurgeOptions) (int, error) {
+	b, ok := svc.Brokers[pipe.Broker()]
+	if !ok {
+		return 0, fmt.Errorf("undefined broker `%s`", pipe.Broker())
+	}
+
+	p, ok := b.(Purger)
+	if !ok {
+		return 0, fmt.Errorf("broker `%s` does not support purging", pipe.Broker())
+	}
+
+	return p.Purge(pipe, opts)
+}
+
+// Requeue moves failed jobs back to the pipeline.
+func (svc *Service) Requeue(pipe *Pipeline, opts RequeueOptions) (int, error) {
+	b, ok := svc.Brokers[pipe.Broker()]
+	if !ok {
+		return 0, fmt.Errorf("undefined broker `%s`", pipe.Broker())
+	}
+
+	r, ok := b.(Requeuer)
+	if !ok {
+		return 0, fmt.Errorf("broker `%s` does not support requeue", pipe.Broker())
+	}
+
+	return r.Requeue(pipe, opts)
+}
+
+// ListFailed returns failed jobs retained by the pipeline broker.
+func (svc *Service) ListFailed(pipe *Pipeline, offset, limit int) ([]*FailedJob, error) {
+	f, err := svc.failedInspector(pipe)
+	if err != nil {
+		return nil, err
+	}
+
+	return f.ListFailed(pipe, offset, limit)
+}
+
+// RetryFailed pushes failed job back to the pipeline.
+func (svc *Service) RetryFailed(pipe *Pipeline, id string) error {
+	f, err := svc.failedInspector(pipe)
+	if err != nil {
+		return err
+	}
+
+	return f.RetryFailed(pipe, id)
+}
+
+// DeleteFailed removes failed job from the pipeline broker.
+func (svc *Service) DeleteFailed(pipe *Pipeline, id string) error {
+	f, err := svc.failedInspector(pipe)
+	if err != nil {
+		return err
+	}
+
+	return f.DeleteFailed(pipe, id)
+}
+
+// inspector returns pipeline broker which is able to inspect pending jobs.
+func (svc *Service) inspector(pipe *Pipeline) (Inspector, error) {
+	b, ok := svc.Brokers[pipe.Broker()]
+	if !ok {
+		return nil, fmt.Errorf("undefined broker `%s`", pipe.Broker())
+	}
+
+	i, ok := b.(Inspector)
+	if !ok {
+		return nil, fmt.Errorf("broker `%s` does not support job inspection", pipe.Broker())
+	}
+
+	return i, nil
+}
+
+// failedInspector returns pipeline broker which retains failed jobs.
+func (svc *Service) failedInspector(pipe *Pipeline) (FailedInspector, error) {
+	b, ok := svc.Brokers[pipe.Broker()]
+	if !ok {
+		return nil, fmt.Errorf("undefined broker `%s`", pipe.Broker())
+	}
+
+	f, ok := b.(FailedInspector)
+	if !ok {
+		return nil, fmt.Errorf("broker `%s` does not retain failed jobs", pipe.Broker())
+	}
+
+	return f, nil
+}
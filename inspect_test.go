@@ -0,0 +1,139 @@
+package jobs
+
+import (
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+type inspectBroker struct {
+	testBroker
+	jobs []*JobInfo
+}
+
+func (b *inspectBroker) List(pipe *Pipeline, offset, limit int) ([]*JobInfo, error) {
+	return b.jobs[offset : offset+limit], nil
+}
+
+func (b *inspectBroker) Peek(pipe *Pipeline, id string) (*JobInfo, error) {
+	return b.jobs[0], nil
+}
+
+func (b *inspectBroker) Purge(pipe *Pipeline, opts PurgeOptions) (int, error) {
+	n := len(b.jobs)
+	b.jobs = nil
+
+	return n, nil
+}
+
+func (b *inspectBroker) Requeue(pipe *Pipeline, opts RequeueOptions) (n int, err error) {
+	for _, j := range b.jobs {
+		if opts.Exceeded(n) {
+			break
+		}
+
+		if opts.Match(j.Job.Job) {
+			n++
+		}
+	}
+
+	return n, nil
+}
+
+func (b *inspectBroker) ListFailed(pipe *Pipeline, offset, limit int) ([]*FailedJob, error) {
+	var list []*FailedJob
+	for _, j := range b.jobs[offset : offset+limit] {
+		list = append(list, &FailedJob{ID: j.ID, Job: j.Job, Attempts: j.Attempt + 1})
+	}
+
+	return list, nil
+}
+
+func (b *inspectBroker) RetryFailed(pipe *Pipeline, id string) error {
+	return b.DeleteFailed(pipe, id)
+}
+
+func (b *inspectBroker) DeleteFailed(pipe *Pipeline, id string) error {
+	for i, j := range b.jobs {
+		if j.ID == id {
+			b.jobs = append(b.jobs[:i], b.jobs[i+1:]...)
+			return nil
+		}
+	}
+
+	return fmt.Errorf("undefined failed job `%s`", id)
+}
+
+func TestService_List(t *testing.T) {
+	b := &inspectBroker{jobs: []*JobInfo{{ID: "1"}, {ID: "2"}}}
+	svc := &Service{Brokers: map[string]Broker{"test": b, "other": &testBroker{}}}
+
+	list, err := svc.List(&Pipeline{"broker": "test"}, 1, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, "2", list[0].ID)
+
+	info, err := svc.Peek(&Pipeline{"broker": "test"}, "1")
+	assert.NoError(t, err)
+	assert.Equal(t, "1", info.ID)
+
+	_, err = svc.List(&Pipeline{"broker": "other"}, 0, 1)
+	assert.Error(t, err)
+
+	_, err = svc.Peek(&Pipeline{"broker": "missing"}, "1")
+	assert.Error(t, err)
+}
+
+func TestService_Purge(t *testing.T) {
+	b := &inspectBroker{jobs: []*JobInfo{{ID: "1"}, {ID: "2"}}}
+	svc := &Service{Brokers: map[string]Broker{"test": b, "other": &testBroker{}}}
+
+	n, err := svc.Purge(&Pipeline{"broker": "test"}, PurgeOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, n)
+
+	_, err = svc.Purge(&Pipeline{"broker": "other"}, PurgeOptions{})
+	assert.Error(t, err)
+}
+
+func TestService_Requeue(t *testing.T) {
+	b := &inspectBroker{jobs: []*JobInfo{
+		{ID: "1", Job: &Job{Job: "spiral.jobs.tests.local.job"}},
+		{ID: "2", Job: &Job{Job: "spiral.jobs.tests.other.job"}},
+		{ID: "3", Job: &Job{Job: "spiral/jobs/tests/local/job"}},
+	}}
+	svc := &Service{Brokers: map[string]Broker{"test": b, "other": &testBroker{}}}
+
+	n, err := svc.Requeue(&Pipeline{"broker": "test"}, RequeueOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, n)
+
+	n, err = svc.Requeue(&Pipeline{"broker": "test"}, RequeueOptions{Jobs: []string{"spiral.jobs.tests.local.*"}})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, n)
+
+	n, err = svc.Requeue(&Pipeline{"broker": "test"}, RequeueOptions{Max: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, n)
+
+	_, err = svc.Requeue(&Pipeline{"broker": "other"}, RequeueOptions{})
+	assert.Error(t, err)
+}
+
+func TestService_Failed(t *testing.T) {
+	b := &inspectBroker{jobs: []*JobInfo{{ID: "1"}, {ID: "2"}, {ID: "3"}}}
+	svc := &Service{Brokers: map[string]Broker{"test": b, "other": &testBroker{}}}
+
+	list, err := svc.ListFailed(&Pipeline{"broker": "test"}, 1, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, "2", list[0].ID)
+
+	assert.NoError(t, svc.RetryFailed(&Pipeline{"broker": "test"}, "1"))
+	assert.NoError(t, svc.DeleteFailed(&Pipeline{"broker": "test"}, "2"))
+	assert.Error(t, svc.DeleteFailed(&Pipeline{"broker": "test"}, "2"))
+	assert.Len(t, b.jobs, 1)
+
+	_, err = svc.ListFailed(&Pipeline{"broker": "other"}, 0, 1)
+	assert.Error(t, err)
+
+	assert.Error(t, svc.RetryFailed(&Pipeline{"broker": "missing"}, "1"))
+}
@@ -1,6 +1,9 @@
 package jobs
 
-import json "github.com/json-iterator/go"
+import (
+	json "github.com/json-iterator/go"
+	"time"
+)
 
 // Handler handles job execution.
 type Handler func(id string, j *Job) error
@@ -18,6 +21,34 @@ type Job struct {
 
 	// Options contains set of PipelineOptions specific to job execution. Can be empty.
 	Options *Options `json:"options,omitempty"`
+
+	// Delivery contains broker provided information about job delivery, set on consume only.
+	Delivery *Delivery `json:"-"`
+}
+
+// Delivery carries information about specific job delivery.
+type Delivery struct {
+	// Pipeline job has been consumed from.
+	Pipeline string
+
+	// Attempt number, starting from 0.
+	Attempt int
+
+	// AttemptUnknown is set when broker is unable to tell the attempt number. Broker decides about the retry on it's
+	// own and failures of such jobs are not reported as retried or dead.
+	AttemptUnknown bool
+
+	// Queued defines when job became available in the queue. Zero when unknown.
+	Queued time.Time
+}
+
+// QueueWait returns duration job spent in the queue before given time, 0 when unknown.
+func (d *Delivery) QueueWait(now time.Time) time.Duration {
+	if d == nil || d.Queued.IsZero() || now.Before(d.Queued) {
+		return 0
+	}
+
+	return now.Sub(d.Queued)
 }
 
 // Body packs job payload into binary payload.
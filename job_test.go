@@ -3,6 +3,7 @@ package jobs
 import (
 	"github.com/stretchr/testify/assert"
 	"testing"
+	"time"
 )
 
 func TestJob_Body(t *testing.T) {
@@ -16,3 +17,19 @@ func TestJob_Context(t *testing.T) {
 
 	assert.Equal(t, []byte(`{"id":"id","job":"job"}`), j.Context("id"))
 }
+
+func TestDelivery_QueueWait(t *testing.T) {
+	now := time.Now()
+
+	var d *Delivery
+	assert.Equal(t, time.Duration(0), d.QueueWait(now))
+
+	d = &Delivery{}
+	assert.Equal(t, time.Duration(0), d.QueueWait(now))
+
+	d = &Delivery{Queued: now.Add(time.Second)}
+	assert.Equal(t, time.Duration(0), d.QueueWait(now))
+
+	d = &Delivery{Queued: now.Add(-time.Second)}
+	assert.Equal(t, time.Second, d.QueueWait(now))
+}
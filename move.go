@@ -0,0 +1,321 @@
+package jobs
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"time"
+)
+
+const (
+	// moveCheck defines how often source pipeline is checked for remaining jobs.
+	moveCheck = 100 * time.Millisecond
+
+	// movePage defines number of pending jobs inspected at once when moving delayed jobs.
+	movePage = 100
+)
+
+// MoveOptions defines how jobs are moved between pipelines.
+type MoveOptions struct {
+	// Max defines maximum number of jobs to move, 0 - no limit. Jobs already delivered by the source
+	// broker are always moved, so the limit can be slightly exceeded.
+	Max int `json:"max"`
+
+	// Rate limits number of moved jobs per second, 0 - no limit.
+	Rate int `json:"rate"`
+
+	// Delayed enables moving of delayed jobs of the source pipeline.
+	Delayed bool `json:"delayed"`
+
+	// DryRun only reports number of jobs to be moved.
+	DryRun bool `json:"dryRun"`
+}
+
+// Move consumes jobs from one pipeline and pushes them into another, using failover and outbox of the target
+// pipeline. Source pipeline must not be consumed by the service. Delayed jobs of brokers able to inspect and
+// remove pending jobs are moved right away keeping the rest of their delay, delayed jobs of other brokers are
+// only moved when their delay is over. Move stops at the first failed push, the job is released back into the source
+// pipeline without spending it's attempt.
+func (svc *Service) Move(from, to *Pipeline, opts MoveOptions) (int, error) {
+	return svc.move(from, to, opts, svc.deliver)
+}
+
+// move jobs between pipelines using given push function.
+func (svc *Service) move(from, to *Pipeline, opts MoveOptions, push func(*Pipeline, *Job) (string, error)) (int, error) {
+	if from == to {
+		return 0, fmt.Errorf("unable to move jobs into the same pipeline `%s`", from.Name())
+	}
+
+	if _, ok := svc.Brokers[to.Broker()]; !ok {
+		return 0, fmt.Errorf("undefined broker `%s`", to.Broker())
+	}
+
+	svc.mup.Lock()
+	consuming := svc.pipelines[from]
+	svc.mup.Unlock()
+
+	if consuming {
+		return 0, fmt.Errorf("pipeline `%s` is being consumed, stop it first", from.Name())
+	}
+
+	if opts.DryRun {
+		stat, err := svc.Stat(from)
+		if err != nil {
+			return 0, err
+		}
+
+		n := stat.Queue
+		if opts.Delayed {
+			n += stat.Delayed
+		}
+
+		if opts.Max > 0 && n > int64(opts.Max) {
+			n = int64(opts.Max)
+		}
+
+		return int(n), nil
+	}
+
+	m := newMover(svc, from, to, opts, push)
+	defer m.stop()
+
+	if opts.Delayed {
+		if err := m.moveDelayed(); err != nil {
+			return m.moved(), err
+		}
+	}
+
+	execPool := make(chan Handler, 1)
+	execPool <- m.move
+
+	if err := svc.Consume(from, execPool, m.error); err != nil {
+		return m.moved(), err
+	}
+
+	err := m.wait()
+	m.release()
+
+	if stopErr := svc.Consume(from, nil, nil); err == nil {
+		err = stopErr
+	}
+
+	return m.moved(), err
+}
+
+// mover pushes consumed jobs into the target pipeline.
+type mover struct {
+	svc      *Service
+	from, to *Pipeline
+	opts     MoveOptions
+	push     func(*Pipeline, *Job) (string, error)
+	limit    *time.Ticker
+
+	mu       sync.Mutex
+	n        int
+	err      error
+	failed   chan interface{}
+	released chan interface{}
+}
+
+// newMover creates new mover between given pipelines.
+func newMover(svc *Service, from, to *Pipeline, opts MoveOptions, push func(*Pipeline, *Job) (string, error)) *mover {
+	m := &mover{
+		svc:      svc,
+		from:     from,
+		to:       to,
+		opts:     opts,
+		push:     push,
+		failed:   make(chan interface{}),
+		released: make(chan interface{}),
+	}
+
+	if opts.Rate > 0 {
+		m.limit = time.NewTicker(time.Second / time.Duration(opts.Rate))
+	}
+
+	return m
+}
+
+// move pushes job into the target pipeline preserving it's name and options. Once any push fails the job is
+// released back to the source broker, jobs delivered after the failure are held till the move stops and released
+// as well, so they are not delivered again while the source pipeline is still consumed.
+func (m *mover) move(id string, j *Job) error {
+	select {
+	case <-m.failed:
+		<-m.released
+		return ErrRelease
+	default:
+	}
+
+	if m.limit != nil {
+		<-m.limit.C
+	}
+
+	if _, err := m.push(m.to, m.copy(j, m.to, 0)); err != nil {
+		m.error(id, j, err)
+		return ErrRelease
+	}
+
+	m.count()
+
+	return nil
+}
+
+// moveDelayed pushes delayed jobs of the source pipeline into the target pipeline with the rest of their delay
+// and removes them from the source pipeline. Brokers unable to inspect and remove pending jobs are skipped,
+// their delayed jobs are moved once they are consumed.
+func (m *mover) moveDelayed() error {
+	b := m.svc.Brokers[m.from.Broker()]
+
+	i, ok := b.(Inspector)
+	if !ok {
+		return nil
+	}
+
+	r, ok := b.(Remover)
+	if !ok {
+		return nil
+	}
+
+	// removed jobs shift the rest of the list, so offset only counts skipped ones
+	for offset := 0; !m.limited(); {
+		list, err := i.List(m.from, offset, movePage)
+		if err != nil {
+			return err
+		}
+
+		if len(list) == 0 {
+			return nil
+		}
+
+		for _, info := range list {
+			if info.State != JobDelayed || info.Available.IsZero() {
+				offset++
+				continue
+			}
+
+			if m.limited() {
+				return nil
+			}
+
+			if m.limit != nil {
+				<-m.limit.C
+			}
+
+			if _, err := m.push(m.to, m.copy(info.Job, m.to, remaining(info.Available, time.Now()))); err != nil {
+				return err
+			}
+
+			if err := r.Remove(m.from, info.ID); err != nil {
+				return err
+			}
+
+			m.count()
+		}
+	}
+
+	return nil
+}
+
+// copy returns job copy addressed to the given pipeline with given delay (in seconds).
+func (m *mover) copy(j *Job, pipe *Pipeline, delay int) *Job {
+	job := &Job{Job: j.Job, Payload: j.Payload, Options: &Options{}}
+	if j.Options != nil {
+		*job.Options = *j.Options
+	}
+
+	job.Options.Pipeline = pipe.Name()
+	job.Options.Delay = delay
+
+	return job
+}
+
+// error remembers first error to stop the move.
+func (m *mover) error(id string, j *Job, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.err == nil {
+		m.err = err
+		close(m.failed)
+	}
+}
+
+// wait till source pipeline is drained, limit is reached or push fails.
+func (m *mover) wait() error {
+	check := time.NewTicker(moveCheck)
+	defer check.Stop()
+
+	for {
+		select {
+		case <-m.failed:
+		case <-check.C:
+		}
+
+		m.mu.Lock()
+		err := m.err
+		m.mu.Unlock()
+
+		if err != nil {
+			return err
+		}
+
+		if m.limited() {
+			return nil
+		}
+
+		stat, err := m.svc.Brokers[m.from.Broker()].Stat(m.from)
+		if err != nil {
+			return err
+		}
+
+		if stat.Queue == 0 && stat.Active == 0 && (!m.opts.Delayed || stat.Delayed == 0) {
+			return nil
+		}
+	}
+}
+
+// count moved job.
+func (m *mover) count() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.n++
+}
+
+// limited returns true when maximum number of jobs has been moved.
+func (m *mover) limited() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.opts.Max > 0 && m.n >= m.opts.Max
+}
+
+// moved returns number of moved jobs.
+func (m *mover) moved() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.n
+}
+
+// release held jobs, must be called before consuming of the source pipeline stops.
+func (m *mover) release() {
+	close(m.released)
+}
+
+// stop the rate limiter.
+func (m *mover) stop() {
+	if m.limit != nil {
+		m.limit.Stop()
+	}
+}
+
+// remaining returns delay (in seconds) left till given time, 0 when the time has passed already.
+func remaining(at time.Time, now time.Time) int {
+	if !at.After(now) {
+		return 0
+	}
+
+	return int(math.Ceil(at.Sub(now).Seconds()))
+}
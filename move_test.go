@@ -0,0 +1,335 @@
+package jobs
+
+import (
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"github.com/spiral/roadrunner/service"
+	"github.com/stretchr/testify/assert"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestService_Move(t *testing.T) {
+	c := service.NewContainer(logrus.New())
+	c.Register("jobs", &Service{Brokers: map[string]Broker{"ephemeral": &testBroker{}}})
+
+	assert.NoError(t, c.Init(viperConfig(`{
+	"jobs":{
+		"pipelines":{
+			"default":{"broker":"ephemeral"},
+			"target":{"broker":"ephemeral"}
+		}
+	}
+}`)))
+
+	svc := jobs(c)
+	from, to := svc.cfg.pipelines.Get("default"), svc.cfg.pipelines.Get("target")
+
+	ready := make(chan interface{})
+	svc.AddListener(func(event int, ctx interface{}) {
+		if event == EventBrokerReady {
+			close(ready)
+		}
+	})
+
+	go func() { c.Serve() }()
+	defer c.Stop()
+	<-ready
+
+	for i := 0; i < 3; i++ {
+		_, err := svc.push(from, &Job{Job: "test", Payload: "body", Options: &Options{Pipeline: "default", Attempts: 3}})
+		assert.NoError(t, err)
+	}
+
+	n, err := svc.Move(from, to, MoveOptions{DryRun: true, Max: 2})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, n)
+
+	n, err = svc.Move(from, to, MoveOptions{Rate: 100})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, n)
+
+	stat, err := svc.Stat(to)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), stat.Queue)
+	assert.False(t, stat.Consuming)
+
+	stat, err = svc.Stat(from)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), stat.Queue)
+	assert.False(t, stat.Consuming)
+
+	moved := make(chan *Job, 3)
+	execPool := make(chan Handler, 1)
+	execPool <- func(id string, j *Job) error {
+		moved <- j
+		return nil
+	}
+
+	assert.NoError(t, svc.Consume(to, execPool, svc.error))
+
+	j := <-moved
+	assert.Equal(t, "test", j.Job)
+	assert.Equal(t, "target", j.Options.Pipeline)
+	assert.Equal(t, 3, j.Options.Attempts)
+
+	_, err = svc.Move(to, from, MoveOptions{})
+	assert.Error(t, err)
+
+	_, err = svc.Move(from, from, MoveOptions{})
+	assert.Error(t, err)
+}
+
+func TestMove_Remaining(t *testing.T) {
+	now := time.Now()
+
+	assert.Equal(t, 0, remaining(time.Time{}, now))
+	assert.Equal(t, 0, remaining(now.Add(-time.Second), now))
+	assert.Equal(t, 2, remaining(now.Add(1500*time.Millisecond), now))
+}
+
+func TestService_Move_PushError(t *testing.T) {
+	c := service.NewContainer(logrus.New())
+	c.Register("jobs", &Service{Brokers: map[string]Broker{
+		"ephemeral": &testBroker{},
+		"flaky":     &flakyBroker{down: 1},
+	}})
+
+	assert.NoError(t, c.Init(viperConfig(`{
+	"jobs":{
+		"pipelines":{
+			"default":{"broker":"ephemeral"},
+			"target":{"broker":"flaky"}
+		}
+	}
+}`)))
+
+	svc := jobs(c)
+	from, to := svc.cfg.pipelines.Get("default"), svc.cfg.pipelines.Get("target")
+
+	ready := make(chan interface{}, 2)
+	svc.AddListener(func(event int, ctx interface{}) {
+		if event == EventBrokerReady {
+			ready <- nil
+		}
+	})
+
+	go func() { c.Serve() }()
+	defer c.Stop()
+	<-ready
+	<-ready
+
+	for i := 0; i < 3; i++ {
+		_, err := svc.push(from, &Job{Job: "test", Payload: "body", Options: &Options{Pipeline: "default", Attempts: 1}})
+		assert.NoError(t, err)
+	}
+
+	n, err := svc.Move(from, to, MoveOptions{})
+	assert.Error(t, err)
+	assert.Equal(t, "connection is dead", err.Error())
+	assert.Equal(t, 0, n)
+
+	// jobs are pushed back without spending their only attempt
+	stat, err := svc.Stat(from)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), stat.Queue)
+	assert.False(t, stat.Consuming)
+
+	kept := make(chan *Job, 3)
+	execPool := make(chan Handler, 1)
+	execPool <- func(id string, j *Job) error {
+		kept <- j
+		return nil
+	}
+
+	assert.NoError(t, svc.Consume(from, execPool, svc.error))
+
+	for i := 0; i < 3; i++ {
+		j := <-kept
+		assert.Equal(t, "default", j.Options.Pipeline)
+		assert.Equal(t, "body", j.Payload)
+	}
+}
+
+func TestService_Move_Failover(t *testing.T) {
+	c := service.NewContainer(logrus.New())
+	c.Register("jobs", &Service{Brokers: map[string]Broker{
+		"ephemeral": &testBroker{},
+		"flaky":     &flakyBroker{down: 1},
+	}})
+
+	assert.NoError(t, c.Init(viperConfig(`{
+	"jobs":{
+		"pipelines":{
+			"default":{"broker":"ephemeral"},
+			"target":{"broker":"flaky", "failover":["backup"]},
+			"backup":{"broker":"ephemeral"}
+		}
+	}
+}`)))
+
+	svc := jobs(c)
+	from, to := svc.cfg.pipelines.Get("default"), svc.cfg.pipelines.Get("target")
+
+	ready := make(chan interface{}, 2)
+	svc.AddListener(func(event int, ctx interface{}) {
+		if event == EventBrokerReady {
+			ready <- nil
+		}
+	})
+
+	go func() { c.Serve() }()
+	defer c.Stop()
+	<-ready
+	<-ready
+
+	for i := 0; i < 2; i++ {
+		_, err := svc.push(from, &Job{Job: "test", Payload: "body", Options: &Options{Pipeline: "default"}})
+		assert.NoError(t, err)
+	}
+
+	n, err := svc.Move(from, to, MoveOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, n)
+
+	stat, err := svc.Stat(svc.cfg.pipelines.Get("backup"))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), stat.Queue)
+}
+
+// delayBroker keeps delayed jobs aside so they can be inspected and removed.
+type delayBroker struct {
+	testBroker
+	dmu     sync.Mutex
+	delayed map[*Pipeline][]*JobInfo
+}
+
+func (b *delayBroker) Push(pipe *Pipeline, j *Job) (string, error) {
+	if j.Options.Delay == 0 {
+		return b.testBroker.Push(pipe, j)
+	}
+
+	b.dmu.Lock()
+	defer b.dmu.Unlock()
+
+	if b.delayed == nil {
+		b.delayed = make(map[*Pipeline][]*JobInfo)
+	}
+
+	id := fmt.Sprintf("%s-%v", pipe.Name(), len(b.delayed[pipe]))
+	b.delayed[pipe] = append(b.delayed[pipe], &JobInfo{
+		ID:        id,
+		Job:       j,
+		State:     JobDelayed,
+		Available: time.Now().Add(j.Options.DelayDuration()),
+	})
+
+	return id, nil
+}
+
+func (b *delayBroker) Stat(pipe *Pipeline) (*Stat, error) {
+	stat, err := b.testBroker.Stat(pipe)
+	if err != nil {
+		return nil, err
+	}
+
+	b.dmu.Lock()
+	defer b.dmu.Unlock()
+
+	stat.Delayed += int64(len(b.delayed[pipe]))
+
+	return stat, nil
+}
+
+func (b *delayBroker) List(pipe *Pipeline, offset, limit int) ([]*JobInfo, error) {
+	b.dmu.Lock()
+	defer b.dmu.Unlock()
+
+	list := b.delayed[pipe]
+	if offset >= len(list) {
+		return []*JobInfo{}, nil
+	}
+
+	list = list[offset:]
+	if limit > 0 && limit < len(list) {
+		list = list[:limit]
+	}
+
+	return append([]*JobInfo{}, list...), nil
+}
+
+func (b *delayBroker) Peek(pipe *Pipeline, id string) (*JobInfo, error) {
+	return nil, fmt.Errorf("undefined job `%s`", id)
+}
+
+func (b *delayBroker) Remove(pipe *Pipeline, id string) error {
+	b.dmu.Lock()
+	defer b.dmu.Unlock()
+
+	for i, info := range b.delayed[pipe] {
+		if info.ID == id {
+			b.delayed[pipe] = append(b.delayed[pipe][:i], b.delayed[pipe][i+1:]...)
+			return nil
+		}
+	}
+
+	return fmt.Errorf("undefined job `%s`", id)
+}
+
+func TestService_Move_Delayed(t *testing.T) {
+	c := service.NewContainer(logrus.New())
+	c.Register("jobs", &Service{Brokers: map[string]Broker{"delay": &delayBroker{}}})
+
+	assert.NoError(t, c.Init(viperConfig(`{
+	"jobs":{
+		"pipelines":{
+			"default":{"broker":"delay"},
+			"target":{"broker":"delay"}
+		}
+	}
+}`)))
+
+	svc := jobs(c)
+	from, to := svc.cfg.pipelines.Get("default"), svc.cfg.pipelines.Get("target")
+
+	ready := make(chan interface{})
+	svc.AddListener(func(event int, ctx interface{}) {
+		if event == EventBrokerReady {
+			close(ready)
+		}
+	})
+
+	go func() { c.Serve() }()
+	defer c.Stop()
+	<-ready
+
+	_, err := svc.push(from, &Job{Job: "test", Payload: "ready", Options: &Options{Pipeline: "default"}})
+	assert.NoError(t, err)
+
+	_, err = svc.push(from, &Job{Job: "test", Payload: "delayed", Options: &Options{Pipeline: "default", Delay: 60}})
+	assert.NoError(t, err)
+
+	start := time.Now()
+	n, err := svc.Move(from, to, MoveOptions{Delayed: true})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, n)
+	assert.True(t, time.Since(start) < 10*time.Second)
+
+	stat, err := svc.Stat(from)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), stat.Queue)
+	assert.Equal(t, int64(0), stat.Delayed)
+
+	stat, err = svc.Stat(to)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), stat.Queue)
+	assert.Equal(t, int64(1), stat.Delayed)
+
+	list, err := svc.List(to, 0, 0)
+	assert.NoError(t, err)
+	assert.Len(t, list, 1)
+	assert.Equal(t, "delayed", list[0].Job.Payload)
+	assert.Equal(t, "target", list[0].Job.Options.Pipeline)
+	assert.True(t, list[0].Job.Options.Delay > 50 && list[0].Job.Options.Delay <= 60)
+}
@@ -0,0 +1,422 @@
+package jobs
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/cenkalti/backoff/v4"
+	json "github.com/json-iterator/go"
+	"net/http"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+)
+
+// NotifyConfig configures single webhook endpoint to be notified about job and pipeline failures.
+type NotifyConfig struct {
+	// URL of the webhook endpoint.
+	URL string
+
+	// Events defines list of events to notify about. Defaults to `job.error` and `pipe.error`.
+	Events []string
+
+	// Pipelines limits notifications to given pipelines. Empty to notify about all pipelines.
+	Pipelines []string
+
+	// Jobs limits notifications to jobs matching given name patterns. Empty to notify about all jobs. Pipeline
+	// events are not related to any job and always pass this filter.
+	Jobs []string
+
+	// Template defines request body template (text/template), events are available as `.Events`.
+	// Defaults to JSON encoded list of events.
+	Template string
+
+	// Headers to be sent with every request.
+	Headers map[string]string
+
+	// Secret enables HMAC-SHA256 signing of request body, signature is passed in X-Signature header.
+	Secret string
+
+	// Window defines number of seconds to aggregate events into single notification. Zero to send
+	// every event immediately.
+	Window int
+
+	// Retries defines how many times failed notification must be retried. Defaults to 3.
+	Retries int
+
+	// Timeout of single request in seconds. Defaults to 10 seconds.
+	Timeout int
+
+	// Workers defines number of notifications sent concurrently. Defaults to 1.
+	Workers int
+
+	// Queue limits number of events waiting to be sent, events exceeding the limit are dropped and reported
+	// as EventNotifyError. Defaults to 1000.
+	Queue int
+}
+
+// Notification carries information about single notified event.
+type Notification struct {
+	// Event name.
+	Event string `json:"event"`
+
+	// Time when event happened.
+	Time time.Time `json:"time"`
+
+	// Pipeline associated with the event.
+	Pipeline string `json:"pipeline,omitempty"`
+
+	// ID of the job (if any).
+	ID string `json:"id,omitempty"`
+
+	// Job name (if any).
+	Job string `json:"job,omitempty"`
+
+	// Attempt number of the job.
+	Attempt int `json:"attempt"`
+
+	// Error message.
+	Error string `json:"error"`
+}
+
+// NotifyError is passed as context of EventNotifyError.
+type NotifyError struct {
+	// URL of the webhook endpoint.
+	URL string
+
+	// Events which were not delivered.
+	Events []*Notification
+
+	// Caused contains delivery error.
+	Caused error
+}
+
+// Error returns error message.
+func (e *NotifyError) Error() string {
+	return e.Caused.Error()
+}
+
+// errNotifyDropped reported for events which did not fit into the notification queue.
+var errNotifyDropped = errors.New("notification queue is full, events are dropped")
+
+// notifier aggregates failure events and sends them to the webhook using fixed number of workers.
+type notifier struct {
+	cfg      *NotifyConfig
+	lsn      func(event int, ctx interface{})
+	client   *http.Client
+	tpl      *template.Template
+	events   map[string]bool
+	patterns []string
+	limit    int
+	workers  int
+
+	// pending events and worker queue, notifier is closed until opened
+	mu      sync.Mutex
+	pending []*Notification
+	queued  int
+	timer   *time.Timer
+	closed  bool
+	queue   chan []*Notification
+	wg      sync.WaitGroup
+}
+
+// newNotifier creates new webhook notifier, events are collected only while open. Events which can not be
+// delivered are reported to the given listener as EventNotifyError.
+func newNotifier(cfg *NotifyConfig, lsn func(event int, ctx interface{})) (*notifier, error) {
+	if cfg.URL == "" {
+		return nil, fmt.Errorf("notification url is missing")
+	}
+
+	n := &notifier{
+		cfg:     cfg,
+		lsn:     lsn,
+		client:  &http.Client{Timeout: 10 * time.Second},
+		events:  make(map[string]bool),
+		limit:   1000,
+		workers: 1,
+		closed:  true,
+	}
+
+	if cfg.Timeout != 0 {
+		n.client.Timeout = time.Duration(cfg.Timeout) * time.Second
+	}
+
+	if cfg.Queue > 0 {
+		n.limit = cfg.Queue
+	}
+
+	if cfg.Workers > 0 {
+		n.workers = cfg.Workers
+	}
+
+	events := cfg.Events
+	if len(events) == 0 {
+		events = []string{eventNames[EventJobError], eventNames[EventPipeError]}
+	}
+
+	for _, e := range events {
+		n.events[e] = true
+	}
+
+	for _, p := range cfg.Jobs {
+		n.patterns = append(n.patterns, normalizePattern(p))
+	}
+
+	if cfg.Template != "" {
+		tpl, err := template.New("notification").Funcs(template.FuncMap{"json": toJSON}).Parse(cfg.Template)
+		if err != nil {
+			return nil, err
+		}
+
+		n.tpl = tpl
+	}
+
+	return n, nil
+}
+
+// Open starts the workers and begins to collect events.
+func (n *notifier) Open() {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if !n.closed {
+		return
+	}
+
+	// every batch holds at least one event, queue never blocks while number of events is within the limit
+	n.queue = make(chan []*Notification, n.limit)
+	for i := 0; i < n.workers; i++ {
+		n.wg.Add(1)
+		go n.serve(n.queue)
+	}
+
+	n.closed = false
+}
+
+// listen collects matching events.
+func (n *notifier) listen(event int, ctx interface{}) {
+	name, ok := eventNames[event]
+	if !ok || !n.events[name] {
+		return
+	}
+
+	e := &Notification{Event: name, Time: time.Now()}
+
+	var pipeEvent bool
+	switch ctx := ctx.(type) {
+	case *JobError:
+		e.Pipeline, e.ID, e.Attempt, e.Error = ctx.Pipeline, ctx.ID, ctx.Attempt, ctx.Error()
+		if ctx.Job != nil {
+			e.Job = ctx.Job.Job
+		}
+	case *PipelineError:
+		pipeEvent = true
+		e.Error = ctx.Error()
+		if ctx.Pipeline != nil {
+			e.Pipeline = ctx.Pipeline.Name()
+		}
+	default:
+		return
+	}
+
+	if !n.matches(e, pipeEvent) {
+		return
+	}
+
+	n.mu.Lock()
+	if n.closed {
+		n.mu.Unlock()
+		return
+	}
+
+	if n.queued+len(n.pending) >= n.limit {
+		n.mu.Unlock()
+		n.report([]*Notification{e}, errNotifyDropped)
+		return
+	}
+
+	n.pending = append(n.pending, e)
+	if n.cfg.Window == 0 {
+		n.flush()
+	} else if n.timer == nil {
+		n.timer = time.AfterFunc(time.Duration(n.cfg.Window)*time.Second, func() {
+			n.mu.Lock()
+			defer n.mu.Unlock()
+
+			n.timer = nil
+			if !n.closed {
+				n.flush()
+			}
+		})
+	}
+	n.mu.Unlock()
+}
+
+// Close sends all pending notifications and waits for delivery, events received till the notifier is opened
+// again are ignored.
+func (n *notifier) Close() {
+	n.mu.Lock()
+	if n.closed {
+		n.mu.Unlock()
+		return
+	}
+
+	n.closed = true
+	if n.timer != nil {
+		n.timer.Stop()
+		n.timer = nil
+	}
+	n.flush()
+	close(n.queue)
+	n.mu.Unlock()
+
+	n.wg.Wait()
+}
+
+// matches checks if event must be delivered. Job filter does not apply to pipeline events.
+func (n *notifier) matches(e *Notification, pipeEvent bool) bool {
+	if len(n.cfg.Pipelines) != 0 {
+		found := false
+		for _, p := range n.cfg.Pipelines {
+			if p == e.Pipeline {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			return false
+		}
+	}
+
+	if len(n.patterns) == 0 || pipeEvent {
+		return true
+	}
+
+	name := normalizeName(e.Job)
+	for _, p := range n.patterns {
+		if strings.HasPrefix(name, p) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// flush queues pending events to be sent by the workers. Must be called under lock.
+func (n *notifier) flush() {
+	if len(n.pending) == 0 {
+		return
+	}
+
+	n.queued += len(n.pending)
+	n.queue <- n.pending
+	n.pending = nil
+}
+
+// serve sends queued events till the queue is closed.
+func (n *notifier) serve(queue chan []*Notification) {
+	defer n.wg.Done()
+
+	for events := range queue {
+		n.mu.Lock()
+		n.queued -= len(events)
+		n.mu.Unlock()
+
+		if err := n.send(events); err != nil {
+			n.report(events, err)
+		}
+	}
+}
+
+// report events which were not delivered.
+func (n *notifier) report(events []*Notification, err error) {
+	if n.lsn != nil {
+		n.lsn(EventNotifyError, &NotifyError{URL: n.cfg.URL, Events: events, Caused: err})
+	}
+}
+
+// send delivers events to the webhook, retries with exponential backoff.
+func (n *notifier) send(events []*Notification) error {
+	body, err := n.render(events)
+	if err != nil {
+		return err
+	}
+
+	retries := n.cfg.Retries
+	if retries == 0 {
+		retries = 3
+	}
+
+	expb := backoff.NewExponentialBackOff()
+	expb.MaxElapsedTime = 0
+
+	return backoff.Retry(func() error {
+		return n.post(body)
+	}, backoff.WithMaxRetries(expb, uint64(retries)))
+}
+
+// render notification body.
+func (n *notifier) render(events []*Notification) ([]byte, error) {
+	if n.tpl == nil {
+		return json.Marshal(struct {
+			Events []*Notification `json:"events"`
+		}{Events: events})
+	}
+
+	buf := &bytes.Buffer{}
+	err := n.tpl.Execute(buf, struct{ Events []*Notification }{Events: events})
+
+	return buf.Bytes(), err
+}
+
+// post performs single webhook request.
+func (n *notifier) post(body []byte) error {
+	req, err := http.NewRequest(http.MethodPost, n.cfg.URL, bytes.NewReader(body))
+	if err != nil {
+		return backoff.Permanent(err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range n.cfg.Headers {
+		req.Header.Set(k, v)
+	}
+
+	if n.cfg.Secret != "" {
+		req.Header.Set("X-Signature", Sign(n.cfg.Secret, body))
+	}
+
+	r, err := n.client.Do(req)
+	if err != nil {
+		return err
+	}
+	r.Body.Close()
+
+	switch {
+	case r.StatusCode >= 200 && r.StatusCode < 300:
+		return nil
+	case r.StatusCode == http.StatusTooManyRequests || r.StatusCode >= 500:
+		return fmt.Errorf("notification failed with status %v", r.StatusCode)
+	default:
+		return backoff.Permanent(fmt.Errorf("notification rejected with status %v", r.StatusCode))
+	}
+}
+
+// Sign calculates HMAC-SHA256 signature of the body in form of `X-Signature` header value. Used to sign
+// notifications and requests of the http pipelines.
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// toJSON encodes template value as JSON.
+func toJSON(v interface{}) (string, error) {
+	data, err := json.Marshal(v)
+	return string(data), err
+}
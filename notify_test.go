@@ -0,0 +1,258 @@
+package jobs
+
+import (
+	"errors"
+	json "github.com/json-iterator/go"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+)
+
+type testHook struct {
+	calls  int32
+	fail   int32
+	bodies chan []byte
+	sigs   chan string
+}
+
+func newTestHook() *testHook {
+	return &testHook{bodies: make(chan []byte, 10), sigs: make(chan string, 10)}
+}
+
+func (h *testHook) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if atomic.AddInt32(&h.calls, 1) <= atomic.LoadInt32(&h.fail) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+
+	body, _ := ioutil.ReadAll(r.Body)
+	h.sigs <- r.Header.Get("X-Signature")
+	h.bodies <- body
+}
+
+func TestNotifier_Send(t *testing.T) {
+	hook := newTestHook()
+	srv := httptest.NewServer(hook)
+	defer srv.Close()
+
+	n, err := newNotifier(&NotifyConfig{URL: srv.URL, Secret: "secret"}, nil)
+	assert.NoError(t, err)
+	n.Open()
+
+	n.listen(EventJobError, &JobError{ID: "id", Job: &Job{Job: "job"}, Pipeline: "default", Caused: errors.New("failed")})
+	n.Close()
+
+	body := <-hook.bodies
+	assert.Equal(t, Sign("secret", body), <-hook.sigs)
+
+	var data struct{ Events []*Notification }
+	assert.NoError(t, json.Unmarshal(body, &data))
+	assert.Len(t, data.Events, 1)
+	assert.Equal(t, "job.error", data.Events[0].Event)
+	assert.Equal(t, "id", data.Events[0].ID)
+	assert.Equal(t, "job", data.Events[0].Job)
+	assert.Equal(t, "default", data.Events[0].Pipeline)
+	assert.Equal(t, "failed", data.Events[0].Error)
+}
+
+func TestNotifier_Aggregate(t *testing.T) {
+	hook := newTestHook()
+	srv := httptest.NewServer(hook)
+	defer srv.Close()
+
+	n, err := newNotifier(&NotifyConfig{URL: srv.URL, Window: 1}, nil)
+	assert.NoError(t, err)
+	n.Open()
+
+	n.listen(EventJobError, &JobError{ID: "1", Job: &Job{Job: "job"}, Caused: errors.New("failed")})
+	n.listen(EventJobError, &JobError{ID: "2", Job: &Job{Job: "job"}, Caused: errors.New("failed")})
+	n.listen(EventPipeError, &PipelineError{Pipeline: &Pipeline{"name": "default"}, Caused: errors.New("dead")})
+
+	var data struct{ Events []*Notification }
+	assert.NoError(t, json.Unmarshal(<-hook.bodies, &data))
+	assert.Len(t, data.Events, 3)
+	assert.Equal(t, "pipe.error", data.Events[2].Event)
+	assert.Equal(t, "default", data.Events[2].Pipeline)
+
+	n.Close()
+	assert.Equal(t, int32(1), atomic.LoadInt32(&hook.calls))
+}
+
+func TestNotifier_Filter(t *testing.T) {
+	hook := newTestHook()
+	srv := httptest.NewServer(hook)
+	defer srv.Close()
+
+	n, err := newNotifier(&NotifyConfig{
+		URL:       srv.URL,
+		Events:    []string{"job.dead"},
+		Pipelines: []string{"default"},
+		Jobs:      []string{"app-jobs-*"},
+	}, nil)
+	assert.NoError(t, err)
+	n.Open()
+
+	n.listen(EventJobError, &JobError{Job: &Job{Job: "app-jobs-email"}, Pipeline: "default", Caused: errors.New("1")})
+	n.listen(EventJobDead, &JobError{Job: &Job{Job: "app-jobs-email"}, Pipeline: "other", Caused: errors.New("2")})
+	n.listen(EventJobDead, &JobError{Job: &Job{Job: "app-other"}, Pipeline: "default", Caused: errors.New("3")})
+	n.listen(EventJobDead, &JobError{Job: &Job{Job: "App.Jobs.Email"}, Pipeline: "default", Caused: errors.New("4")})
+	n.Close()
+
+	assert.Equal(t, int32(1), atomic.LoadInt32(&hook.calls))
+
+	var data struct{ Events []*Notification }
+	assert.NoError(t, json.Unmarshal(<-hook.bodies, &data))
+	assert.Len(t, data.Events, 1)
+	assert.Equal(t, "4", data.Events[0].Error)
+}
+
+func TestNotifier_Filter_PipelineEvents(t *testing.T) {
+	hook := newTestHook()
+	srv := httptest.NewServer(hook)
+	defer srv.Close()
+
+	n, err := newNotifier(&NotifyConfig{
+		URL:    srv.URL,
+		Events: []string{"pipe.error"},
+		Jobs:   []string{"app-jobs-*"},
+	}, nil)
+	assert.NoError(t, err)
+	n.Open()
+
+	n.listen(EventPipeError, &PipelineError{Pipeline: &Pipeline{"name": "default"}, Caused: errors.New("failed")})
+	n.Close()
+
+	var data struct{ Events []*Notification }
+	assert.NoError(t, json.Unmarshal(<-hook.bodies, &data))
+	assert.Len(t, data.Events, 1)
+	assert.Equal(t, "default", data.Events[0].Pipeline)
+}
+
+func TestNotifier_Template(t *testing.T) {
+	hook := newTestHook()
+	srv := httptest.NewServer(hook)
+	defer srv.Close()
+
+	n, err := newNotifier(&NotifyConfig{
+		URL:      srv.URL,
+		Template: `{"text": "{{len .Events}} failure(s)", "first": {{json (index .Events 0).Job}}}`,
+	}, nil)
+	assert.NoError(t, err)
+	n.Open()
+
+	n.listen(EventJobError, &JobError{Job: &Job{Job: "job"}, Caused: errors.New("failed")})
+	n.Close()
+
+	assert.Equal(t, `{"text": "1 failure(s)", "first": "job"}`, string(<-hook.bodies))
+}
+
+func TestNotifier_Retry(t *testing.T) {
+	hook := newTestHook()
+	hook.fail = 1
+	srv := httptest.NewServer(hook)
+	defer srv.Close()
+
+	n, err := newNotifier(&NotifyConfig{URL: srv.URL}, nil)
+	assert.NoError(t, err)
+	n.Open()
+
+	n.listen(EventJobError, &JobError{Job: &Job{Job: "job"}, Caused: errors.New("failed")})
+	n.Close()
+
+	assert.NotEmpty(t, <-hook.bodies)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&hook.calls))
+}
+
+func TestNotifier_Undelivered(t *testing.T) {
+	hook := newTestHook()
+	hook.fail = 10
+	srv := httptest.NewServer(hook)
+	defer srv.Close()
+
+	failed := make(chan *NotifyError, 1)
+	n, err := newNotifier(&NotifyConfig{URL: srv.URL, Retries: 1}, func(event int, ctx interface{}) {
+		if event == EventNotifyError {
+			failed <- ctx.(*NotifyError)
+		}
+	})
+	assert.NoError(t, err)
+	n.Open()
+
+	n.listen(EventJobError, &JobError{ID: "id", Job: &Job{Job: "job"}, Caused: errors.New("failed")})
+	n.Close()
+
+	e := <-failed
+	assert.Equal(t, srv.URL, e.URL)
+	assert.Len(t, e.Events, 1)
+	assert.Equal(t, "id", e.Events[0].ID)
+	assert.Error(t, e.Caused)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&hook.calls))
+}
+
+func TestNotifier_Invalid(t *testing.T) {
+	_, err := newNotifier(&NotifyConfig{}, nil)
+	assert.Error(t, err)
+
+	_, err = newNotifier(&NotifyConfig{URL: "http://localhost", Template: "{{"}, nil)
+	assert.Error(t, err)
+}
+
+func TestNotifier_Dropped(t *testing.T) {
+	hook := newTestHook()
+	srv := httptest.NewServer(hook)
+	defer srv.Close()
+
+	dropped := make(chan *NotifyError, 1)
+	n, err := newNotifier(&NotifyConfig{URL: srv.URL, Window: 1, Queue: 2}, func(event int, ctx interface{}) {
+		if event == EventNotifyError {
+			dropped <- ctx.(*NotifyError)
+		}
+	})
+	assert.NoError(t, err)
+	n.Open()
+
+	n.listen(EventJobError, &JobError{ID: "1", Job: &Job{Job: "job"}, Caused: errors.New("failed")})
+	n.listen(EventJobError, &JobError{ID: "2", Job: &Job{Job: "job"}, Caused: errors.New("failed")})
+	n.listen(EventJobError, &JobError{ID: "3", Job: &Job{Job: "job"}, Caused: errors.New("failed")})
+
+	e := <-dropped
+	assert.Equal(t, errNotifyDropped, e.Caused)
+	assert.Len(t, e.Events, 1)
+	assert.Equal(t, "3", e.Events[0].ID)
+
+	var data struct{ Events []*Notification }
+	assert.NoError(t, json.Unmarshal(<-hook.bodies, &data))
+	assert.Len(t, data.Events, 2)
+
+	n.Close()
+	n.listen(EventJobError, &JobError{ID: "4", Job: &Job{Job: "job"}, Caused: errors.New("failed")})
+	assert.Equal(t, int32(1), atomic.LoadInt32(&hook.calls))
+}
+
+func TestNotifier_Reopen(t *testing.T) {
+	hook := newTestHook()
+	srv := httptest.NewServer(hook)
+	defer srv.Close()
+
+	n, err := newNotifier(&NotifyConfig{URL: srv.URL}, nil)
+	assert.NoError(t, err)
+
+	// ignored until opened
+	n.listen(EventJobError, &JobError{ID: "1", Job: &Job{Job: "job"}, Caused: errors.New("failed")})
+
+	n.Open()
+	n.Close()
+
+	n.Open()
+	n.listen(EventJobError, &JobError{ID: "2", Job: &Job{Job: "job"}, Caused: errors.New("failed")})
+	n.Close()
+
+	var data struct{ Events []*Notification }
+	assert.NoError(t, json.Unmarshal(<-hook.bodies, &data))
+	assert.Len(t, data.Events, 1)
+	assert.Equal(t, "2", data.Events[0].ID)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&hook.calls))
+}
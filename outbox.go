@@ -0,0 +1,481 @@
+package jobs
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"github.com/gofrs/uuid"
+	json "github.com/json-iterator/go"
+	"go.etcd.io/bbolt"
+	"net"
+	"sync"
+	"time"
+)
+
+// outboxBatch defines number of journal records loaded at once during the replay.
+const outboxBatch = 100
+
+var (
+	// outboxBucket is name of the journal bucket.
+	outboxBucket = []byte("outbox")
+
+	// discardedBucket is name of the bucket holding journaled jobs which can not be replayed.
+	discardedBucket = []byte("outbox.discarded")
+
+	// errOutboxBacklog is reported for jobs journaled to keep them after the earlier journaled jobs.
+	errOutboxBacklog = errors.New("earlier jobs are waiting in the outbox")
+
+	// errOutboxClosed is reported when journal is not open.
+	errOutboxClosed = errors.New("outbox is closed")
+)
+
+// OutboxConfig configures local disk outbox for jobs which can not be pushed due to broker outage.
+type OutboxConfig struct {
+	// Path to the journal file.
+	Path string
+
+	// MaxJobs defines maximum number of jobs in the journal, pushes fail once the limit is reached. Zero - no limit.
+	MaxJobs int
+
+	// Interval defines how often (in seconds) journaled jobs must be replayed. Defaults to 1 second.
+	Interval int
+
+	// MaxDiscarded defines maximum number of discarded jobs kept in the journal, oldest jobs are removed once
+	// the limit is reached. Defaults to 1000.
+	MaxDiscarded int
+}
+
+// InitDefaults sets missing values to their default values.
+func (c *OutboxConfig) InitDefaults() {
+	if c.Interval == 0 {
+		c.Interval = 1
+	}
+
+	if c.MaxDiscarded == 0 {
+		c.MaxDiscarded = 1000
+	}
+}
+
+// OutboxEvent is passed with outbox events.
+type OutboxEvent struct {
+	// ID is provisional job id returned to the client.
+	ID string
+
+	// Job journaled in the outbox, nil when journal itself is failing or journal record is broken.
+	Job *Job
+
+	// Pipeline job must be pushed into.
+	Pipeline string
+
+	// Pushed is job id assigned by the broker once job has been replayed.
+	Pushed string
+
+	// Caused contains error which made job journaled or failed the replay.
+	Caused error
+}
+
+// outboxRecord is single journaled job.
+type outboxRecord struct {
+	ID       string    `json:"id"`
+	Pipeline string    `json:"pipeline"`
+	Job      *Job      `json:"job"`
+	Time     time.Time `json:"time"`
+
+	key    []byte
+	data   []byte
+	broken error
+}
+
+// outbox journals jobs which can not be pushed due to broker connectivity issues and replays them in order of
+// the push, once broker accepts jobs again. Jobs of every pipeline are replayed strictly in order, replay of the
+// pipeline stops at the first failed job till the next attempt. Broken records and jobs rejected by the available
+// broker are moved out of the journal to not block the replay.
+type outbox struct {
+	svc *Service
+	cfg *OutboxConfig
+	db  *bbolt.DB
+
+	mu        sync.Mutex
+	backlog   map[string]int64
+	total     int
+	discarded int
+
+	stopped chan interface{}
+}
+
+// newOutbox opens the journal and counts journaled jobs.
+func newOutbox(svc *Service, cfg *OutboxConfig) (*outbox, error) {
+	o := &outbox{svc: svc, cfg: cfg}
+
+	if err := o.open(); err != nil {
+		return nil, err
+	}
+
+	return o, nil
+}
+
+// open the journal and count journaled jobs, journal which is already open is kept as is. Broken records are
+// counted as well, they are discarded during the replay. Replay can be stopped again once the journal is open.
+func (o *outbox) open() error {
+	o.mu.Lock()
+	o.stopped = make(chan interface{})
+	opened := o.db != nil
+	o.mu.Unlock()
+
+	if opened {
+		return nil
+	}
+
+	db, err := bbolt.Open(o.cfg.Path, 0600, &bbolt.Options{Timeout: time.Second})
+	if err != nil {
+		return err
+	}
+
+	backlog, total, discarded := make(map[string]int64), 0, 0
+	err = db.Update(func(tx *bbolt.Tx) error {
+		d, err := tx.CreateBucketIfNotExists(discardedBucket)
+		if err != nil {
+			return err
+		}
+		discarded = d.Stats().KeyN
+
+		b, err := tx.CreateBucketIfNotExists(outboxBucket)
+		if err != nil {
+			return err
+		}
+
+		return b.ForEach(func(k, v []byte) error {
+			if r := newOutboxRecord(k, v); r.broken == nil {
+				backlog[r.Pipeline]++
+			}
+			total++
+
+			return nil
+		})
+	})
+
+	if err != nil {
+		db.Close()
+		return err
+	}
+
+	o.mu.Lock()
+	o.db, o.backlog, o.total, o.discarded = db, backlog, total, discarded
+	o.mu.Unlock()
+
+	return nil
+}
+
+// push journals the job and returns it's provisional id.
+func (o *outbox) push(pipe *Pipeline, job *Job, caused error) (string, error) {
+	id, err := uuid.NewV4()
+	if err != nil {
+		return "", err
+	}
+
+	r := &outboxRecord{ID: id.String(), Pipeline: pipe.Name(), Job: job, Time: time.Now()}
+	data, err := json.Marshal(r)
+	if err != nil {
+		return "", err
+	}
+
+	if err := o.journal(r, data, caused); err != nil {
+		return "", err
+	}
+
+	// listeners are notified outside of the lock, they might request the outbox size
+	o.svc.throw(EventPushDeferred, &OutboxEvent{ID: r.ID, Job: job, Pipeline: r.Pipeline, Caused: caused})
+
+	return r.ID, nil
+}
+
+// journal writes the record into the journal.
+func (o *outbox) journal(r *outboxRecord, data []byte, caused error) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if o.cfg.MaxJobs > 0 && o.total >= o.cfg.MaxJobs {
+		return fmt.Errorf("outbox is full: %s", caused)
+	}
+
+	err := o.update(func(tx *bbolt.Tx) error {
+		b := tx.Bucket(outboxBucket)
+
+		seq, err := b.NextSequence()
+		if err != nil {
+			return err
+		}
+
+		key := make([]byte, 8)
+		binary.BigEndian.PutUint64(key, seq)
+
+		return b.Put(key, data)
+	})
+
+	if err != nil {
+		return err
+	}
+
+	o.backlog[r.Pipeline]++
+	o.total++
+
+	return nil
+}
+
+// serve replays journaled jobs until stopped.
+func (o *outbox) serve() {
+	ticker := time.NewTicker(time.Duration(o.cfg.Interval) * time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-o.stopped:
+			return
+		case <-ticker.C:
+			if err := o.replay(); err != nil {
+				o.svc.throw(EventOutboxError, &OutboxEvent{Caused: err})
+			}
+		}
+	}
+}
+
+// replay pushes journaled jobs in order of the push. Pipeline is skipped till the next replay after the first
+// failed job, broken records are discarded.
+func (o *outbox) replay() error {
+	blocked := make(map[string]bool)
+
+	var after []byte
+	for {
+		records, err := o.load(after)
+		if err != nil || len(records) == 0 {
+			return err
+		}
+
+		for _, r := range records {
+			after = r.key
+
+			select {
+			case <-o.stopped:
+				return nil
+			default:
+			}
+
+			if r.broken != nil {
+				o.discard(r, r.broken)
+				continue
+			}
+
+			if blocked[r.Pipeline] {
+				continue
+			}
+
+			if err := o.replayRecord(r); err != nil {
+				blocked[r.Pipeline] = true
+			}
+		}
+	}
+}
+
+// replayRecord pushes journaled job into it's pipeline and removes it from the journal. Jobs of undefined
+// pipelines and jobs rejected by the available broker are discarded.
+func (o *outbox) replayRecord(r *outboxRecord) error {
+	pipe := o.svc.cfg.pipelines.Get(r.Pipeline)
+	if pipe == nil {
+		return o.discard(r, fmt.Errorf("undefined pipeline `%s`", r.Pipeline))
+	}
+
+	id, err := o.svc.pushPipeline(pipe, r.Job)
+	if err != nil {
+		if o.svc.unavailable(pipe, err) {
+			return err
+		}
+
+		return o.discard(r, err)
+	}
+
+	o.mu.Lock()
+	err = o.update(func(tx *bbolt.Tx) error {
+		return tx.Bucket(outboxBucket).Delete(r.key)
+	})
+
+	if err == nil {
+		o.backlog[r.Pipeline]--
+		o.total--
+	}
+	o.mu.Unlock()
+
+	if err != nil {
+		// job would be pushed again on the next replay
+		o.svc.throw(EventOutboxError, &OutboxEvent{ID: r.ID, Job: r.Job, Pipeline: r.Pipeline, Pushed: id, Caused: err})
+		return err
+	}
+
+	o.svc.throw(EventPushReplayed, &OutboxEvent{ID: r.ID, Job: r.Job, Pipeline: r.Pipeline, Pushed: id})
+
+	return nil
+}
+
+// discard moves journaled job out of the replay, oldest discarded jobs exceeding the limit are removed. Returns
+// nil once job is discarded.
+func (o *outbox) discard(r *outboxRecord, caused error) error {
+	o.mu.Lock()
+	discarded := o.discarded + 1
+	err := o.update(func(tx *bbolt.Tx) error {
+		d := tx.Bucket(discardedBucket)
+		if err := d.Put(r.key, r.data); err != nil {
+			return err
+		}
+
+		pruned, err := o.prune(d, discarded)
+		if err != nil {
+			return err
+		}
+		discarded = pruned
+
+		return tx.Bucket(outboxBucket).Delete(r.key)
+	})
+
+	if err == nil {
+		o.discarded = discarded
+		if r.broken == nil {
+			o.backlog[r.Pipeline]--
+		}
+		o.total--
+	}
+	o.mu.Unlock()
+
+	if err != nil {
+		o.svc.throw(EventOutboxError, &OutboxEvent{ID: r.ID, Job: r.Job, Pipeline: r.Pipeline, Caused: err})
+		return err
+	}
+
+	o.svc.throw(EventPushDiscarded, &OutboxEvent{ID: r.ID, Job: r.Job, Pipeline: r.Pipeline, Caused: caused})
+
+	return nil
+}
+
+// prune removes oldest discarded jobs exceeding the limit and returns number of kept jobs, journal keys are
+// ordered by the push.
+func (o *outbox) prune(b *bbolt.Bucket, count int) (int, error) {
+	if o.cfg.MaxDiscarded <= 0 {
+		return count, nil
+	}
+
+	c := b.Cursor()
+	for ; count > o.cfg.MaxDiscarded; count-- {
+		if k, _ := c.First(); k == nil {
+			return 0, nil
+		}
+
+		if err := c.Delete(); err != nil {
+			return count, err
+		}
+	}
+
+	return count, nil
+}
+
+// load next batch of journaled jobs after given key.
+func (o *outbox) load(after []byte) ([]*outboxRecord, error) {
+	var records []*outboxRecord
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if o.db == nil {
+		return nil, errOutboxClosed
+	}
+
+	err := o.db.View(func(tx *bbolt.Tx) error {
+		c := tx.Bucket(outboxBucket).Cursor()
+
+		k, v := c.First()
+		if after != nil {
+			if k, v = c.Seek(after); bytes.Equal(k, after) {
+				k, v = c.Next()
+			}
+		}
+
+		for ; k != nil && len(records) < outboxBatch; k, v = c.Next() {
+			records = append(records, newOutboxRecord(k, v))
+		}
+
+		return nil
+	})
+
+	return records, err
+}
+
+// newOutboxRecord unpacks journal record, broken records are marked with the unpack error.
+func newOutboxRecord(k, v []byte) *outboxRecord {
+	r := &outboxRecord{key: append([]byte(nil), k...), data: append([]byte(nil), v...)}
+
+	if err := json.Unmarshal(v, r); err != nil {
+		r.broken = fmt.Errorf("broken outbox record: %s", err)
+	} else if r.Job == nil {
+		r.broken = errors.New("broken outbox record: missing job")
+	}
+
+	return r
+}
+
+// size returns number of journaled jobs of the pipeline.
+func (o *outbox) size(pipe *Pipeline) int64 {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	return o.backlog[pipe.Name()]
+}
+
+// stop the replay.
+func (o *outbox) stop() {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	select {
+	case <-o.stopped:
+	default:
+		close(o.stopped)
+	}
+}
+
+// update runs read-write transaction on the journal, must be called under the lock.
+func (o *outbox) update(fn func(tx *bbolt.Tx) error) error {
+	if o.db == nil {
+		return errOutboxClosed
+	}
+
+	return o.db.Update(fn)
+}
+
+// release closes the journal file, journaled jobs are kept and counted.
+func (o *outbox) release() error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if o.db == nil {
+		return nil
+	}
+
+	err := o.db.Close()
+	o.db = nil
+
+	return err
+}
+
+// close the journal and stop the replay.
+func (o *outbox) close() error {
+	o.stop()
+	return o.release()
+}
+
+// unavailable returns true if push error is caused by broker connectivity rather than by the job itself.
+func (svc *Service) unavailable(pipe *Pipeline, err error) bool {
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+
+	return svc.healthy(pipe) != nil
+}
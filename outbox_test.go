@@ -0,0 +1,398 @@
+package jobs
+
+import (
+	"errors"
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"github.com/spiral/roadrunner/service"
+	"github.com/stretchr/testify/assert"
+	"go.etcd.io/bbolt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func outboxService(t *testing.T, path string, b Broker) (service.Container, *Service) {
+	c := service.NewContainer(logrus.New())
+	c.Register("jobs", &Service{Brokers: map[string]Broker{"flaky": b}})
+
+	assert.NoError(t, c.Init(viperConfig(fmt.Sprintf(`{
+	"jobs":{
+		"pipelines":{
+			"default":{"broker":"flaky"}
+		},
+		"outbox":{"path":"%s", "maxJobs": 2}
+	}
+}`, path))))
+
+	return c, jobs(c)
+}
+
+func TestService_Outbox(t *testing.T) {
+	dir, err := ioutil.TempDir("", "outbox")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	primary := &flakyBroker{down: 1}
+	c, svc := outboxService(t, filepath.Join(dir, "outbox.db"), primary)
+
+	ready := make(chan interface{})
+	deferred := make(chan *OutboxEvent, 2)
+	replayed := make(chan *OutboxEvent, 2)
+	svc.AddListener(func(event int, ctx interface{}) {
+		switch event {
+		case EventBrokerReady:
+			close(ready)
+		case EventPushDeferred:
+			deferred <- ctx.(*OutboxEvent)
+		case EventPushReplayed:
+			replayed <- ctx.(*OutboxEvent)
+		}
+	})
+
+	go func() { c.Serve() }()
+	defer c.Stop()
+	<-ready
+
+	var ids []string
+	for i := 0; i < 2; i++ {
+		id, err := svc.Push(&Job{Job: "test", Payload: fmt.Sprintf("body-%v", i), Options: &Options{Pipeline: "default"}})
+		assert.NoError(t, err)
+		assert.NotEqual(t, "", id)
+
+		e := <-deferred
+		assert.Equal(t, id, e.ID)
+		assert.Equal(t, "default", e.Pipeline)
+		assert.Equal(t, "connection is dead", e.Caused.Error())
+
+		ids = append(ids, id)
+	}
+
+	// journal is full
+	_, err = svc.Push(&Job{Job: "test", Payload: "body", Options: &Options{Pipeline: "default"}})
+	assert.Error(t, err)
+
+	stat, err := svc.Stat(svc.cfg.pipelines.Get("default"))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), stat.Outbox)
+	assert.Equal(t, int64(0), stat.Queue)
+
+	atomic.StoreInt32(&primary.down, 0)
+
+	for i := 0; i < 2; i++ {
+		select {
+		case e := <-replayed:
+			assert.Equal(t, ids[i], e.ID)
+			assert.Equal(t, fmt.Sprintf("body-%v", i), e.Job.Payload)
+			assert.NotEqual(t, "", e.Pushed)
+			assert.NotEqual(t, e.ID, e.Pushed)
+		case <-time.After(5 * time.Second):
+			t.Fatal("jobs were not replayed")
+		}
+	}
+
+	stat, err = svc.Stat(svc.cfg.pipelines.Get("default"))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), stat.Outbox)
+	assert.Equal(t, int64(2), stat.Queue)
+}
+
+func TestService_Outbox_Restart(t *testing.T) {
+	dir, err := ioutil.TempDir("", "outbox")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "outbox.db")
+
+	o, err := newOutbox(&Service{}, &OutboxConfig{Path: path})
+	assert.NoError(t, err)
+
+	_, err = o.push(&Pipeline{"name": "default"}, &Job{Job: "test", Payload: "body", Options: &Options{}}, fmt.Errorf("failed"))
+	assert.NoError(t, err)
+	assert.NoError(t, o.close())
+
+	c, svc := outboxService(t, path, &flakyBroker{})
+	assert.Equal(t, int64(1), svc.outbox.size(svc.cfg.pipelines.Get("default")))
+
+	ready := make(chan interface{})
+	replayed := make(chan *OutboxEvent, 1)
+	svc.AddListener(func(event int, ctx interface{}) {
+		switch event {
+		case EventBrokerReady:
+			close(ready)
+		case EventPushReplayed:
+			replayed <- ctx.(*OutboxEvent)
+		}
+	})
+
+	go func() { c.Serve() }()
+	defer c.Stop()
+	<-ready
+
+	select {
+	case e := <-replayed:
+		assert.Equal(t, "body", e.Job.Payload)
+	case <-time.After(5 * time.Second):
+		t.Fatal("job was not replayed")
+	}
+}
+
+type rejectBroker struct{ testBroker }
+
+func (b *rejectBroker) Push(pipe *Pipeline, j *Job) (string, error) {
+	return "", errors.New("job rejected")
+}
+
+func TestService_Outbox_Order(t *testing.T) {
+	dir, err := ioutil.TempDir("", "outbox")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	primary := &flakyBroker{down: 1}
+
+	c := service.NewContainer(logrus.New())
+	c.Register("jobs", &Service{Brokers: map[string]Broker{"flaky": primary}})
+
+	assert.NoError(t, c.Init(viperConfig(fmt.Sprintf(`{
+	"jobs":{
+		"pipelines":{
+			"default":{"broker":"flaky"}
+		},
+		"outbox":{"path":"%s", "interval": 3600}
+	}
+}`, filepath.Join(dir, "outbox.db")))))
+
+	svc := jobs(c)
+
+	ready := make(chan interface{})
+	deferred := make(chan *OutboxEvent, 2)
+	replayed := make(chan *OutboxEvent, 2)
+	svc.AddListener(func(event int, ctx interface{}) {
+		switch event {
+		case EventBrokerReady:
+			close(ready)
+		case EventPushDeferred:
+			deferred <- ctx.(*OutboxEvent)
+		case EventPushReplayed:
+			replayed <- ctx.(*OutboxEvent)
+		}
+	})
+
+	go func() { c.Serve() }()
+	defer c.Stop()
+	<-ready
+
+	first, err := svc.Push(&Job{Job: "test", Payload: "first", Options: &Options{Pipeline: "default"}})
+	assert.NoError(t, err)
+	assert.Equal(t, "connection is dead", (<-deferred).Caused.Error())
+
+	atomic.StoreInt32(&primary.down, 0)
+
+	second, err := svc.Push(&Job{Job: "test", Payload: "second", Options: &Options{Pipeline: "default"}})
+	assert.NoError(t, err)
+	assert.Equal(t, errOutboxBacklog, (<-deferred).Caused)
+
+	assert.NoError(t, svc.outbox.replay())
+
+	assert.Equal(t, first, (<-replayed).ID)
+	assert.Equal(t, second, (<-replayed).ID)
+
+	id, err := svc.Push(&Job{Job: "test", Payload: "third", Options: &Options{Pipeline: "default"}})
+	assert.NoError(t, err)
+	assert.Len(t, deferred, 0)
+
+	stat, err := svc.Stat(svc.cfg.pipelines.Get("default"))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), stat.Outbox)
+	assert.Equal(t, int64(3), stat.Queue)
+	assert.NotEqual(t, "", id)
+}
+
+func TestService_Outbox_Discard(t *testing.T) {
+	dir, err := ioutil.TempDir("", "outbox")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "outbox.db")
+
+	o, err := newOutbox(&Service{}, &OutboxConfig{Path: path})
+	assert.NoError(t, err)
+
+	_, err = o.push(&Pipeline{"name": "default"}, &Job{Job: "test", Payload: "body", Options: &Options{}}, fmt.Errorf("failed"))
+	assert.NoError(t, err)
+
+	assert.NoError(t, o.db.Update(func(tx *bbolt.Tx) error {
+		return tx.Bucket(outboxBucket).Put([]byte{0, 0, 0, 0, 0, 0, 0, 0}, []byte("{broken"))
+	}))
+	assert.NoError(t, o.close())
+
+	c := service.NewContainer(logrus.New())
+	c.Register("jobs", &Service{Brokers: map[string]Broker{"reject": &rejectBroker{}}})
+
+	assert.NoError(t, c.Init(viperConfig(fmt.Sprintf(`{
+	"jobs":{
+		"pipelines":{
+			"default":{"broker":"reject"}
+		},
+		"outbox":{"path":"%s", "interval": 3600}
+	}
+}`, path))))
+
+	svc := jobs(c)
+	assert.Equal(t, int64(1), svc.outbox.size(svc.cfg.pipelines.Get("default")))
+
+	ready := make(chan interface{})
+	discarded := make(chan *OutboxEvent, 2)
+	svc.AddListener(func(event int, ctx interface{}) {
+		switch event {
+		case EventBrokerReady:
+			close(ready)
+		case EventPushDiscarded:
+			discarded <- ctx.(*OutboxEvent)
+		}
+	})
+
+	go func() { c.Serve() }()
+	defer c.Stop()
+	<-ready
+
+	assert.NoError(t, svc.outbox.replay())
+
+	e := <-discarded
+	assert.Nil(t, e.Job)
+	assert.Contains(t, e.Caused.Error(), "broken outbox record")
+
+	e = <-discarded
+	assert.Equal(t, "body", e.Job.Payload)
+	assert.Equal(t, "job rejected", e.Caused.Error())
+
+	assert.Equal(t, int64(0), svc.outbox.size(svc.cfg.pipelines.Get("default")))
+	assert.Equal(t, 0, svc.outbox.total)
+
+	assert.NoError(t, svc.outbox.db.View(func(tx *bbolt.Tx) error {
+		assert.Equal(t, 2, tx.Bucket(discardedBucket).Stats().KeyN)
+		return nil
+	}))
+}
+
+func TestOutbox_PruneDiscarded(t *testing.T) {
+	dir, err := ioutil.TempDir("", "outbox")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	svc := &Service{}
+	o, err := newOutbox(svc, &OutboxConfig{Path: filepath.Join(dir, "outbox.db"), MaxDiscarded: 2})
+	assert.NoError(t, err)
+	defer o.close()
+
+	for i := 1; i <= 3; i++ {
+		_, err = o.push(&Pipeline{"name": "default"}, &Job{Job: "test", Payload: fmt.Sprint(i), Options: &Options{}}, nil)
+		assert.NoError(t, err)
+	}
+
+	records, err := o.load(nil)
+	assert.NoError(t, err)
+	assert.Len(t, records, 3)
+
+	for _, r := range records {
+		assert.NoError(t, o.discard(r, errors.New("rejected")))
+	}
+
+	assert.Equal(t, 0, o.total)
+	assert.NoError(t, o.db.View(func(tx *bbolt.Tx) error {
+		var payloads []string
+		err := tx.Bucket(discardedBucket).ForEach(func(k, v []byte) error {
+			payloads = append(payloads, newOutboxRecord(k, v).Job.Payload)
+			return nil
+		})
+
+		assert.Equal(t, []string{"2", "3"}, payloads)
+		return err
+	}))
+}
+
+func TestOutbox_ListenerSize(t *testing.T) {
+	dir, err := ioutil.TempDir("", "outbox")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	svc := &Service{}
+	o, err := newOutbox(svc, &OutboxConfig{Path: filepath.Join(dir, "outbox.db")})
+	assert.NoError(t, err)
+	defer o.close()
+
+	pipe := &Pipeline{"name": "default"}
+
+	sizes := make(chan int64, 1)
+	svc.AddListener(func(event int, ctx interface{}) {
+		if event == EventPushDeferred {
+			sizes <- o.size(pipe)
+		}
+	})
+
+	_, err = o.push(pipe, &Job{Job: "test", Payload: "body", Options: &Options{}}, fmt.Errorf("failed"))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), <-sizes)
+}
+
+func TestOutbox_Released(t *testing.T) {
+	dir, err := ioutil.TempDir("", "outbox")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	o, err := newOutbox(&Service{}, &OutboxConfig{Path: filepath.Join(dir, "outbox.db")})
+	assert.NoError(t, err)
+	assert.NoError(t, o.release())
+
+	_, err = o.push(&Pipeline{"name": "default"}, &Job{Job: "test", Payload: "body", Options: &Options{}}, fmt.Errorf("failed"))
+	assert.Equal(t, errOutboxClosed, err)
+
+	// journal file is not locked anymore
+	db, err := bbolt.Open(filepath.Join(dir, "outbox.db"), 0600, &bbolt.Options{Timeout: 100 * time.Millisecond})
+	assert.NoError(t, err)
+	assert.NoError(t, db.Close())
+}
+
+func TestService_Outbox_BeforeServe(t *testing.T) {
+	dir, err := ioutil.TempDir("", "outbox")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	_, svc := outboxService(t, filepath.Join(dir, "outbox.db"), &flakyBroker{down: 1})
+	defer svc.outbox.close()
+
+	id, err := svc.Push(&Job{Job: "test", Payload: "body", Options: &Options{Pipeline: "default"}})
+	assert.NoError(t, err)
+	assert.NotEqual(t, "", id)
+	assert.Equal(t, int64(1), svc.outbox.size(svc.cfg.pipelines.Get("default")))
+}
+
+func TestOutbox_Reopen(t *testing.T) {
+	dir, err := ioutil.TempDir("", "outbox")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	o, err := newOutbox(&Service{}, &OutboxConfig{Path: filepath.Join(dir, "outbox.db"), Interval: 1})
+	assert.NoError(t, err)
+
+	for i := 0; i < 2; i++ {
+		assert.NoError(t, o.open())
+
+		done := make(chan interface{})
+		go func() {
+			o.serve()
+			close(done)
+		}()
+
+		assert.NoError(t, o.close())
+
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Fatal("replay was not stopped")
+		}
+	}
+}
@@ -167,3 +167,21 @@ func (p Pipeline) Duration(name string, d time.Duration) time.Duration {
 
 	return d
 }
+
+// Strings must return option value as list of strings or return empty list.
+func (p Pipeline) Strings(name string) []string {
+	var out []string
+
+	switch value := p[name].(type) {
+	case []string:
+		out = append(out, value...)
+	case []interface{}:
+		for _, v := range value {
+			if str, ok := v.(string); ok {
+				out = append(out, str)
+			}
+		}
+	}
+
+	return out
+}
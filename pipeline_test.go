@@ -48,6 +48,14 @@ func TestPipeline_Duration(t *testing.T) {
 	assert.Equal(t, time.Second, pipe.Duration("other", time.Second))
 }
 
+func TestPipeline_Strings(t *testing.T) {
+	pipe := Pipeline{"value": []interface{}{"a", 1, "b"}, "list": []string{"c"}}
+
+	assert.Equal(t, []string{"a", "b"}, pipe.Strings("value"))
+	assert.Equal(t, []string{"c"}, pipe.Strings("list"))
+	assert.Len(t, pipe.Strings("other"), 0)
+}
+
 func TestPipeline_Has(t *testing.T) {
 	pipe := Pipeline{"options": map[string]interface{}{"ttl": 10}}
 
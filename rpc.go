@@ -19,6 +19,78 @@ type PipelineList struct {
 	Pipelines []*Stat `json:"pipelines"`
 }
 
+// ListRequest defines pipeline and range of pending jobs to be listed.
+type ListRequest struct {
+	// Pipeline name.
+	Pipeline string `json:"pipeline"`
+
+	// Offset of the first job.
+	Offset int `json:"offset"`
+
+	// Limit defines maximum number of jobs to be listed.
+	Limit int `json:"limit"`
+}
+
+// PeekRequest defines pending job to be fetched.
+type PeekRequest struct {
+	// Pipeline name.
+	Pipeline string `json:"pipeline"`
+
+	// ID of the job.
+	ID string `json:"id"`
+}
+
+// PurgeRequest defines pipeline to be purged.
+type PurgeRequest struct {
+	// Pipeline name.
+	Pipeline string `json:"pipeline"`
+
+	// Options defines which jobs to purge.
+	Options PurgeOptions `json:"options"`
+}
+
+// RequeueRequest defines pipeline to requeue failed jobs of.
+type RequeueRequest struct {
+	// Pipeline name.
+	Pipeline string `json:"pipeline"`
+
+	// Options limits the set of requeued jobs.
+	Options RequeueOptions `json:"options"`
+}
+
+// MoveRequest defines pipelines to move jobs between.
+type MoveRequest struct {
+	// From is source pipeline name.
+	From string `json:"from"`
+
+	// To is target pipeline name.
+	To string `json:"to"`
+
+	// Options defines how jobs are moved.
+	Options MoveOptions `json:"options"`
+}
+
+// FailedRequest defines failed job to be retried or deleted.
+type FailedRequest struct {
+	// Pipeline name.
+	Pipeline string `json:"pipeline"`
+
+	// ID of the job.
+	ID string `json:"id"`
+}
+
+// FailedList contains list of failed jobs.
+type FailedList struct {
+	// Jobs is list of failed jobs.
+	Jobs []*FailedJob `json:"jobs"`
+}
+
+// JobList contains list of pending jobs.
+type JobList struct {
+	// Jobs is list of pending jobs.
+	Jobs []*JobInfo `json:"jobs"`
+}
+
 // Push job to the testQueue.
 func (rpc *rpcServer) Push(j *Job, id *string) (err error) {
 	if rpc.svc == nil {
@@ -62,7 +134,7 @@ func (rpc *rpcServer) Stop(pipeline string, w *string) (err error) {
 		return fmt.Errorf("undefined pipeline `%s`", pipeline)
 	}
 
-	if err := rpc.svc.Consume(pipe, nil, nil); err != nil {
+	if err := rpc.svc.pause(pipe); err != nil {
 		return err
 	}
 
@@ -81,7 +153,7 @@ func (rpc *rpcServer) Resume(pipeline string, w *string) (err error) {
 		return fmt.Errorf("undefined pipeline `%s`", pipeline)
 	}
 
-	if err := rpc.svc.Consume(pipe, rpc.svc.execPool, rpc.svc.error); err != nil {
+	if err := rpc.svc.resume(pipe); err != nil {
 		return err
 	}
 
@@ -96,7 +168,7 @@ func (rpc *rpcServer) StopAll(stop bool, w *string) (err error) {
 	}
 
 	for _, pipe := range rpc.svc.cfg.pipelines {
-		if err := rpc.svc.Consume(pipe, nil, nil); err != nil {
+		if err := rpc.svc.pause(pipe); err != nil {
 			return err
 		}
 	}
@@ -112,7 +184,7 @@ func (rpc *rpcServer) ResumeAll(resume bool, w *string) (err error) {
 	}
 
 	for _, pipe := range rpc.svc.cfg.pipelines {
-		if err := rpc.svc.Consume(pipe, rpc.svc.execPool, rpc.svc.error); err != nil {
+		if err := rpc.svc.resume(pipe); err != nil {
 			return err
 		}
 	}
@@ -149,3 +221,153 @@ func (rpc *rpcServer) Stat(list bool, l *PipelineList) (err error) {
 
 	return err
 }
+
+// Health returns state of the brokers, pipelines and worker pool.
+func (rpc *rpcServer) Health(check bool, h *Health) (err error) {
+	if rpc.svc == nil {
+		return fmt.Errorf("jobs server is not running")
+	}
+
+	*h = *rpc.svc.Health()
+	return nil
+}
+
+// List returns pending jobs of the pipeline.
+func (rpc *rpcServer) List(r ListRequest, l *JobList) (err error) {
+	if rpc.svc == nil {
+		return fmt.Errorf("jobs server is not running")
+	}
+
+	pipe := rpc.svc.cfg.pipelines.Get(r.Pipeline)
+	if pipe == nil {
+		return fmt.Errorf("undefined pipeline `%s`", r.Pipeline)
+	}
+
+	*l = JobList{}
+	l.Jobs, err = rpc.svc.List(pipe, r.Offset, r.Limit)
+	return err
+}
+
+// Peek returns pending job of the pipeline by it's id.
+func (rpc *rpcServer) Peek(r PeekRequest, j *JobInfo) error {
+	if rpc.svc == nil {
+		return fmt.Errorf("jobs server is not running")
+	}
+
+	pipe := rpc.svc.cfg.pipelines.Get(r.Pipeline)
+	if pipe == nil {
+		return fmt.Errorf("undefined pipeline `%s`", r.Pipeline)
+	}
+
+	info, err := rpc.svc.Peek(pipe, r.ID)
+	if err != nil {
+		return err
+	}
+
+	*j = *info
+	return nil
+}
+
+// Purge removes pending jobs from the pipeline and returns number of removed jobs.
+func (rpc *rpcServer) Purge(r PurgeRequest, n *int) (err error) {
+	if rpc.svc == nil {
+		return fmt.Errorf("jobs server is not running")
+	}
+
+	pipe := rpc.svc.cfg.pipelines.Get(r.Pipeline)
+	if pipe == nil {
+		return fmt.Errorf("undefined pipeline `%s`", r.Pipeline)
+	}
+
+	*n, err = rpc.svc.Purge(pipe, r.Options)
+	return err
+}
+
+// Requeue moves failed jobs back to the pipeline and returns number of requeued jobs.
+func (rpc *rpcServer) Requeue(r RequeueRequest, n *int) (err error) {
+	if rpc.svc == nil {
+		return fmt.Errorf("jobs server is not running")
+	}
+
+	pipe := rpc.svc.cfg.pipelines.Get(r.Pipeline)
+	if pipe == nil {
+		return fmt.Errorf("undefined pipeline `%s`", r.Pipeline)
+	}
+
+	*n, err = rpc.svc.Requeue(pipe, r.Options)
+	return err
+}
+
+// ListFailed returns failed jobs retained by the pipeline broker.
+func (rpc *rpcServer) ListFailed(r ListRequest, l *FailedList) (err error) {
+	if rpc.svc == nil {
+		return fmt.Errorf("jobs server is not running")
+	}
+
+	pipe := rpc.svc.cfg.pipelines.Get(r.Pipeline)
+	if pipe == nil {
+		return fmt.Errorf("undefined pipeline `%s`", r.Pipeline)
+	}
+
+	*l = FailedList{}
+	l.Jobs, err = rpc.svc.ListFailed(pipe, r.Offset, r.Limit)
+	return err
+}
+
+// RetryFailed pushes failed job back to the pipeline.
+func (rpc *rpcServer) RetryFailed(r FailedRequest, w *string) (err error) {
+	if rpc.svc == nil {
+		return fmt.Errorf("jobs server is not running")
+	}
+
+	pipe := rpc.svc.cfg.pipelines.Get(r.Pipeline)
+	if pipe == nil {
+		return fmt.Errorf("undefined pipeline `%s`", r.Pipeline)
+	}
+
+	if err := rpc.svc.RetryFailed(pipe, r.ID); err != nil {
+		return err
+	}
+
+	*w = "OK"
+	return nil
+}
+
+// DeleteFailed removes failed job from the pipeline broker.
+func (rpc *rpcServer) DeleteFailed(r FailedRequest, w *string) (err error) {
+	if rpc.svc == nil {
+		return fmt.Errorf("jobs server is not running")
+	}
+
+	pipe := rpc.svc.cfg.pipelines.Get(r.Pipeline)
+	if pipe == nil {
+		return fmt.Errorf("undefined pipeline `%s`", r.Pipeline)
+	}
+
+	if err := rpc.svc.DeleteFailed(pipe, r.ID); err != nil {
+		return err
+	}
+
+	*w = "OK"
+	return nil
+}
+
+// Move consumes jobs from one pipeline and pushes them into another, returns number of moved jobs.
+func (rpc *rpcServer) Move(r MoveRequest, n *int) (err error) {
+	if rpc.svc == nil {
+		return fmt.Errorf("jobs server is not running")
+	}
+
+	from := rpc.svc.cfg.pipelines.Get(r.From)
+	if from == nil {
+		return fmt.Errorf("undefined pipeline `%s`", r.From)
+	}
+
+	to := rpc.svc.cfg.pipelines.Get(r.To)
+	if to == nil {
+		return fmt.Errorf("undefined pipeline `%s`", r.To)
+	}
+
+	*n, err = rpc.svc.Move(from, to, r.Options)
+	return err
+}
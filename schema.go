@@ -0,0 +1,113 @@
+package jobs
+
+import (
+	"fmt"
+	"github.com/xeipuuv/gojsonschema"
+	"io/ioutil"
+	"strings"
+)
+
+// Schema defines JSON schema to validate job payloads with.
+type Schema struct {
+	// Schema contains inline JSON schema definition (as JSON string).
+	Schema string
+
+	// File points to the JSON schema file, used when no inline schema is given.
+	File string
+
+	// Quarantine defines pipeline to move jobs with invalid payload into. When set the
+	// payload is validated again on consume, except jobs consumed from the quarantine pipeline.
+	Quarantine string
+
+	// compiled schema
+	schema *gojsonschema.Schema
+}
+
+// SchemaError is returned when job payload does not match associated schema.
+type SchemaError struct {
+	// Job is name of the job.
+	Job string
+
+	// Errors contains list of validation errors.
+	Errors []string
+}
+
+// Error returns error message.
+func (e *SchemaError) Error() string {
+	return fmt.Sprintf("invalid payload for `%s`: %s", e.Job, strings.Join(e.Errors, "; "))
+}
+
+// Schemas provides ability to locate the payload schema for the specific job.
+type Schemas map[string]*Schema
+
+// compile all schemas and pre-compile patterns
+func initSchemas(schemas map[string]*Schema) (Schemas, error) {
+	out := make(Schemas)
+	for pattern, s := range schemas {
+		if err := s.compile(); err != nil {
+			return nil, fmt.Errorf("invalid schema `%s`: %s", pattern, err)
+		}
+
+		out[normalizePattern(pattern)] = s
+	}
+
+	return out, nil
+}
+
+// match locates the schema associated with the job. Can return nil.
+func (schemas Schemas) match(job *Job) (found *Schema) {
+	var best = 0
+
+	jobName := normalizeName(job.Job)
+	for pattern, s := range schemas {
+		if strings.HasPrefix(jobName, pattern) && len(pattern) > best {
+			found = s
+			best = len(pattern)
+		}
+	}
+
+	if best == 0 {
+		return nil
+	}
+
+	return found
+}
+
+// compile loads schema from inline definition or file.
+func (s *Schema) compile() (err error) {
+	var src = s.Schema
+	if src == "" {
+		if s.File == "" {
+			return fmt.Errorf("either `schema` or `file` must be set")
+		}
+
+		data, err := ioutil.ReadFile(s.File)
+		if err != nil {
+			return err
+		}
+
+		src = string(data)
+	}
+
+	s.schema, err = gojsonschema.NewSchema(gojsonschema.NewStringLoader(src))
+	return err
+}
+
+// validate job payload against the schema.
+func (s *Schema) validate(job *Job) error {
+	r, err := s.schema.Validate(gojsonschema.NewStringLoader(job.Payload))
+	if err != nil {
+		return &SchemaError{Job: job.Job, Errors: []string{err.Error()}}
+	}
+
+	if r.Valid() {
+		return nil
+	}
+
+	e := &SchemaError{Job: job.Job}
+	for _, re := range r.Errors() {
+		e.Errors = append(e.Errors, re.String())
+	}
+
+	return e
+}
@@ -0,0 +1,203 @@
+package jobs
+
+import (
+	"github.com/sirupsen/logrus"
+	"github.com/spiral/roadrunner"
+	"github.com/spiral/roadrunner/service"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const testSchema = `{
+	"type": "object",
+	"required": ["id"],
+	"properties": {"id": {"type": "integer"}}
+}`
+
+func Test_Schema_Validate(t *testing.T) {
+	s, err := initSchemas(map[string]*Schema{"some.*": {Schema: testSchema}})
+	assert.NoError(t, err)
+
+	assert.NoError(t, s.match(&Job{Job: "some.job"}).validate(&Job{Job: "some.job", Payload: `{"id":1}`}))
+
+	err = s.match(&Job{Job: "some.job"}).validate(&Job{Job: "some.job", Payload: `{"id":"abc"}`})
+	assert.Error(t, err)
+	assert.IsType(t, &SchemaError{}, err)
+	assert.Contains(t, err.Error(), "some.job")
+}
+
+func Test_Schema_ValidateMalformed(t *testing.T) {
+	s, err := initSchemas(map[string]*Schema{"some.*": {Schema: testSchema}})
+	assert.NoError(t, err)
+
+	assert.Error(t, s.match(&Job{Job: "some.job"}).validate(&Job{Job: "some.job", Payload: `{"id`}))
+}
+
+func Test_Schema_Miss(t *testing.T) {
+	s, err := initSchemas(map[string]*Schema{"some.*": {Schema: testSchema}})
+	assert.NoError(t, err)
+
+	assert.Nil(t, s.match(&Job{Job: "other.job"}))
+}
+
+func Test_Schema_Best(t *testing.T) {
+	s, err := initSchemas(map[string]*Schema{
+		"some.*":       {Schema: `{"type":"object"}`},
+		"some.other.*": {Schema: testSchema},
+	})
+	assert.NoError(t, err)
+
+	assert.NoError(t, s.match(&Job{Job: "some.any"}).validate(&Job{Job: "some.any", Payload: `{}`}))
+	assert.Error(t, s.match(&Job{Job: "some.other.job"}).validate(&Job{Job: "some.other.job", Payload: `{}`}))
+}
+
+func Test_Schema_File(t *testing.T) {
+	dir, err := ioutil.TempDir("", "schema")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "schema.json")
+	assert.NoError(t, ioutil.WriteFile(file, []byte(testSchema), 0644))
+
+	s, err := initSchemas(map[string]*Schema{"some.*": {File: file}})
+	assert.NoError(t, err)
+	assert.Error(t, s.match(&Job{Job: "some.job"}).validate(&Job{Job: "some.job", Payload: `{}`}))
+}
+
+func Test_Schema_Invalid(t *testing.T) {
+	_, err := initSchemas(map[string]*Schema{"some.*": {}})
+	assert.Error(t, err)
+
+	_, err = initSchemas(map[string]*Schema{"some.*": {Schema: `{"type": 1`}})
+	assert.Error(t, err)
+
+	_, err = initSchemas(map[string]*Schema{"some.*": {File: "missing.json"}})
+	assert.Error(t, err)
+}
+
+func Test_Config_Schemas_UndefinedQuarantine(t *testing.T) {
+	cfg := &mockCfg{cfg: `{
+	"pipelines":{
+		"pipe": {"broker":"broker"}
+	},
+	"schemas":{
+		"job.*": {"schema":"{}", "quarantine":"missing"}
+	}
+	}`}
+	c := &Config{}
+
+	assert.Error(t, c.Hydrate(cfg))
+}
+
+func TestService_PushInvalidPayload(t *testing.T) {
+	c := service.NewContainer(logrus.New())
+	c.Register("jobs", &Service{Brokers: map[string]Broker{"ephemeral": &testBroker{}}})
+
+	assert.NoError(t, c.Init(viperConfig(`{
+	"jobs":{
+		"pipelines":{"default":{"broker":"ephemeral"}},
+		"dispatch": {
+			"spiral-jobs-tests-local-*.pipeline": "default"
+		},
+		"schemas": {
+			"spiral-jobs-tests-local-*": {"schema": "{\"type\":\"object\",\"required\":[\"id\"]}"}
+		}
+	}
+}`)))
+
+	ready := make(chan interface{})
+	pushErr := make(chan *JobError, 1)
+	jobs(c).AddListener(func(event int, ctx interface{}) {
+		switch event {
+		case EventBrokerReady:
+			close(ready)
+		case EventPushError:
+			pushErr <- ctx.(*JobError)
+		}
+	})
+
+	go func() { c.Serve() }()
+	defer c.Stop()
+	<-ready
+
+	_, err := jobs(c).Push(&Job{
+		Job:     "spiral-jobs-tests-local-job",
+		Payload: `{"data":100}`,
+		Options: &Options{Pipeline: "default"},
+	})
+
+	assert.Error(t, err)
+
+	e := <-pushErr
+	assert.IsType(t, &SchemaError{}, e.Caused)
+	assert.Equal(t, "default", e.Pipeline)
+
+	id, err := jobs(c).Push(&Job{
+		Job:     "spiral-jobs-tests-local-job",
+		Payload: `{"id":100}`,
+		Options: &Options{Pipeline: "default"},
+	})
+
+	assert.NoError(t, err)
+	assert.NotEqual(t, "", id)
+}
+
+func TestService_Quarantine(t *testing.T) {
+	c := service.NewContainer(logrus.New())
+	c.Register("jobs", &Service{Brokers: map[string]Broker{"ephemeral": &testBroker{}}})
+
+	assert.NoError(t, c.Init(viperConfig(`{
+	"jobs":{
+		"pipelines":{
+			"default":{"broker":"ephemeral"},
+			"invalid":{"broker":"ephemeral"}
+		},
+		"schemas": {
+			"spiral-jobs-tests-local-*": {
+				"schema": "{\"type\":\"object\",\"required\":[\"id\"]}",
+				"quarantine": "invalid"
+			}
+		}
+	}
+}`)))
+
+	svc := jobs(c)
+
+	ready := make(chan interface{})
+	svc.AddListener(func(event int, ctx interface{}) {
+		if event == EventBrokerReady {
+			close(ready)
+		}
+	})
+
+	go func() { c.Serve() }()
+	defer c.Stop()
+	<-ready
+
+	// no worker pool, jobs which pass the validation fail to execute
+	svc.rr = roadrunner.NewServer(&roadrunner.ServerConfig{})
+
+	j := &Job{
+		Job:      "spiral-jobs-tests-local-job",
+		Payload:  `{"data":100}`,
+		Options:  &Options{Pipeline: "default"},
+		Delivery: &Delivery{Pipeline: "default"},
+	}
+
+	assert.NoError(t, svc.exec("id", j))
+
+	quarantine := svc.cfg.pipelines.Get("invalid")
+	stat, err := svc.Stat(quarantine)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), stat.Queue)
+
+	j.Delivery = &Delivery{Pipeline: "invalid"}
+	assert.Error(t, svc.exec("id", j))
+
+	stat, err = svc.Stat(quarantine)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), stat.Queue)
+}
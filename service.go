@@ -28,20 +28,44 @@ type Service struct {
 	log *logrus.Logger
 	lsn []func(event int, ctx interface{})
 
+	// job lifecycle audit log and failure notifications
+	audit     *auditor
+	notifiers []*notifier
+
+	// circuit breakers of pipelines
+	breakers map[*Pipeline]*breaker
+
+	// fallback pipelines of pipelines
+	failovers map[*Pipeline]*failover
+
+	// worker pool autoscaler
+	scaler *autoscaler
+
+	// journal of jobs failed to push due to broker outage
+	outbox *outbox
+
+	// health and readiness endpoints
+	health *healthServer
+
+	// pipeline counters
+	stats *statsCollector
+
 	// server and server controller
 	rr *roadrunner.Server
 	cr roadrunner.Controller
 
-	// task balancer
+	// task balancer and pool of the pipelines executed by their brokers when workers are not configured
 	execPool chan Handler
+	idlePool chan Handler
 
 	// registered brokers
 	serving int32
 	brokers service.Container
 
-	// pipelines pipelines
+	// pipelines pipelines and pipelines paused by the operator
 	mup       sync.Mutex
 	pipelines map[*Pipeline]bool
+	held      map[*Pipeline]bool
 }
 
 // Attach attaches cr. Currently only one cr is supported.
@@ -69,25 +93,83 @@ func (svc *Service) Init(
 	svc.env = env
 	svc.log = log
 
+	svc.stats = newStatsCollector()
+	svc.AddListener(svc.stats.listen)
+
 	if rpc != nil {
 		if err := rpc.Register(ID, &rpcServer{svc}); err != nil {
 			return false, err
 		}
 	}
 
+	svc.idlePool = make(chan Handler)
+
 	// limit the number of parallel threads
 	if svc.cfg.Workers.Command != "" {
-		svc.execPool = make(chan Handler, svc.cfg.Workers.Pool.NumWorkers)
+		size := svc.cfg.Workers.Pool.NumWorkers
+		if svc.cfg.Autoscale != nil {
+			// exec pool must be able to fit the highest number of workers
+			svc.cfg.Workers.Pool.NumWorkers = svc.cfg.Autoscale.clamp(size)
+			size = svc.cfg.Autoscale.MaxWorkers
+		}
+
+		svc.execPool = make(chan Handler, size)
 		for i := int64(0); i < svc.cfg.Workers.Pool.NumWorkers; i++ {
 			svc.execPool <- svc.exec
 		}
 
 		svc.rr = roadrunner.NewServer(svc.cfg.Workers)
+
+		if svc.cfg.Autoscale != nil {
+			svc.scaler = newAutoscaler(svc, svc.cfg.Autoscale, svc.cfg.Workers.Pool.NumWorkers)
+			svc.AddListener(svc.scaler.listen)
+		}
 	}
 
 	svc.pipelines = make(map[*Pipeline]bool)
+	svc.held = make(map[*Pipeline]bool)
+	svc.breakers = make(map[*Pipeline]*breaker)
+	svc.failovers = make(map[*Pipeline]*failover)
 	for _, p := range svc.cfg.pipelines {
 		svc.pipelines[p] = false
+
+		if p.Has("breaker") {
+			b := newBreaker(svc, p)
+			svc.breakers[p] = b
+			svc.AddListener(b.listen)
+		}
+
+		if p.Has("failover") {
+			if svc.failovers[p], err = newFailover(svc, p); err != nil {
+				return false, err
+			}
+		}
+	}
+
+	if svc.cfg.Audit != nil && svc.cfg.Audit.Path != "" {
+		svc.audit = newAuditor(svc.cfg.Audit, svc.throw)
+		svc.AddListener(svc.audit.listen)
+	}
+
+	if svc.cfg.Outbox != nil && svc.cfg.Outbox.Path != "" {
+		// journal is kept open till the end of serving, so jobs pushed before serving are journaled as well
+		if svc.outbox, err = newOutbox(svc, svc.cfg.Outbox); err != nil {
+			return false, err
+		}
+	}
+
+	if svc.cfg.Health != nil && svc.cfg.Health.Address != "" {
+		svc.health = newHealthServer(svc, svc.cfg.Health)
+	}
+
+	for _, cfg := range svc.cfg.Notify {
+		n, err := newNotifier(cfg, svc.throw)
+		if err != nil {
+			return false, err
+		}
+
+		svc.notifiers = append(svc.notifiers, n)
+		svc.AddListener(n.listen)
 	}
 
 	// run all brokers in nested container
@@ -97,6 +179,10 @@ func (svc *Service) Init(
 		if ep, ok := b.(EventProvider); ok {
 			ep.Listen(svc.throw)
 		}
+
+		if f, ok := b.(Forwarder); ok {
+			f.UseRouter(svc)
+		}
 	}
 
 	// init all broker configs
@@ -118,6 +204,24 @@ func (svc *Service) Init(
 
 // Serve serves local rr server and creates broker association.
 func (svc *Service) Serve() error {
+	var healthErr chan error
+	if svc.audit != nil {
+		if err := svc.audit.Open(); err != nil {
+			return err
+		}
+		defer svc.audit.Close()
+	}
+
+	if svc.health != nil {
+		if err := svc.health.listen(); err != nil {
+			return err
+		}
+
+		healthErr = make(chan error, 1)
+		go func() { healthErr <- svc.health.serve() }()
+		defer svc.health.stop()
+	}
+
 	if svc.rr != nil {
 		if svc.env != nil {
 			if err := svc.env.Copy(svc.cfg.Workers); err != nil {
@@ -138,21 +242,71 @@ func (svc *Service) Serve() error {
 		}
 		defer svc.rr.Stop()
 
-		// start pipelines of all the pipelines
-		for _, p := range svc.cfg.pipelines.Names(svc.cfg.Consume...) {
-			// start pipeline consuming
-			if err := svc.Consume(p, svc.execPool, svc.error); err != nil {
-				svc.Stop()
+		if svc.scaler != nil {
+			go svc.scaler.serve()
+			defer svc.scaler.Stop()
+		}
+	}
+
+	// start pipelines of all the pipelines, pipelines executed by their brokers do not require workers
+	for _, p := range svc.cfg.pipelines.Names(svc.cfg.Consume...) {
+		execPool := svc.pool(p)
+		if execPool == nil {
+			continue
+		}
 
-				return err
-			}
+		// start pipeline consuming
+		if err := svc.Consume(p, execPool, svc.error); err != nil {
+			svc.Stop()
+
+			return err
 		}
 	}
 
+	for _, f := range svc.failovers {
+		go f.serve()
+		defer f.stop()
+	}
+
 	atomic.StoreInt32(&svc.serving, 1)
 	defer atomic.StoreInt32(&svc.serving, 0)
 
-	return svc.brokers.Serve()
+	if svc.outbox != nil {
+		if err := svc.outbox.open(); err != nil {
+			return err
+		}
+
+		go svc.outbox.serve()
+		defer svc.outbox.close()
+	}
+
+	for _, n := range svc.notifiers {
+		n.Open()
+		defer n.Close()
+	}
+
+	if healthErr == nil {
+		return svc.brokers.Serve()
+	}
+
+	served := make(chan error, 1)
+	go func() { served <- svc.brokers.Serve() }()
+
+	select {
+	case err := <-served:
+		return err
+	case err := <-healthErr:
+		if err == nil {
+			// endpoints have been shut down
+			return <-served
+		}
+
+		// failed health endpoints stop the service
+		svc.Stop()
+		<-served
+
+		return err
+	}
 }
 
 // Stop all pipelines and rr server.
@@ -161,6 +315,18 @@ func (svc *Service) Stop() {
 		return
 	}
 
+	for _, b := range svc.breakers {
+		b.stop()
+	}
+
+	for _, f := range svc.failovers {
+		f.stop()
+	}
+
+	if svc.outbox != nil {
+		svc.outbox.stop()
+	}
+
 	wg := sync.WaitGroup{}
 	for _, p := range svc.cfg.pipelines.Names(svc.cfg.Consume...).Reverse() {
 		wg.Add(1)
@@ -182,6 +348,50 @@ func (svc *Service) Server() *roadrunner.Server {
 	return svc.rr
 }
 
+// scale resizes rr worker pool and exec pool. Pool shrinking waits for the active jobs to complete or for the stop
+// signal.
+func (svc *Service) scale(from, to int64, stop chan interface{}) error {
+	if to > from {
+		if err := svc.resize(to); err != nil {
+			return err
+		}
+
+		for i := from; i < to; i++ {
+			svc.execPool <- svc.exec
+		}
+
+		return nil
+	}
+
+	for i := to; i < from; i++ {
+		select {
+		case <-svc.execPool:
+		case <-stop:
+			// return taken handlers back
+			for ; i > to; i-- {
+				svc.execPool <- svc.exec
+			}
+
+			return fmt.Errorf("scaling has been interrupted")
+		}
+	}
+
+	return svc.resize(to)
+}
+
+// resize rr worker pool.
+func (svc *Service) resize(workers int64) error {
+	if svc.rr == nil {
+		return nil
+	}
+
+	pool := *svc.cfg.Workers.Pool
+	pool.NumWorkers = workers
+	svc.cfg.Workers.Pool = &pool
+
+	return svc.rr.Reconfigure(svc.cfg.Workers)
+}
+
 // Stat returns list of pipelines workers and their stats.
 func (svc *Service) Stat(pipe *Pipeline) (stat *Stat, err error) {
 	b, ok := svc.Brokers[pipe.Broker()]
@@ -201,6 +411,16 @@ func (svc *Service) Stat(pipe *Pipeline) (stat *Stat, err error) {
 	stat.Consuming = svc.pipelines[pipe]
 	svc.mup.Unlock()
 
+	if b, ok := svc.breakers[pipe]; ok {
+		stat.Breaker = b.State()
+	}
+
+	if svc.outbox != nil {
+		stat.Outbox = svc.outbox.size(pipe)
+	}
+
+	svc.stats.fill(stat)
+
 	return stat, err
 }
 
@@ -252,6 +472,46 @@ func (svc *Service) Consume(pipe *Pipeline, execPool chan Handler, errHandler Er
 	return nil
 }
 
+// pause stops pipeline consuming on operator request. Circuit breaker does not resume paused pipelines.
+func (svc *Service) pause(pipe *Pipeline) error {
+	svc.mup.Lock()
+	svc.held[pipe] = true
+	svc.mup.Unlock()
+
+	if b, ok := svc.breakers[pipe]; ok {
+		b.hold(true)
+	}
+
+	return svc.Consume(pipe, nil, nil)
+}
+
+// resume starts pipeline consuming on operator request.
+func (svc *Service) resume(pipe *Pipeline) error {
+	svc.mup.Lock()
+	delete(svc.held, pipe)
+	svc.mup.Unlock()
+
+	if b, ok := svc.breakers[pipe]; ok {
+		b.hold(false)
+	}
+
+	return svc.Consume(pipe, svc.pool(pipe), svc.error)
+}
+
+// pool returns exec pool to consume given pipeline with, nil when pipeline can not be consumed. Brokers executing
+// jobs on their own do not use the pool handlers and are consumed without workers.
+func (svc *Service) pool(pipe *Pipeline) chan Handler {
+	if svc.execPool != nil {
+		return svc.execPool
+	}
+
+	if e, ok := svc.Brokers[pipe.Broker()].(Executor); ok && e.Executes(pipe) {
+		return svc.idlePool
+	}
+
+	return nil
+}
+
 // Push job to associated broker and return job id.
 func (svc *Service) Push(job *Job) (string, error) {
 	pipe, pOpts, err := svc.cfg.MatchPipeline(job)
@@ -263,6 +523,48 @@ func (svc *Service) Push(job *Job) (string, error) {
 		job.Options.Merge(pOpts)
 	}
 
+	if s := svc.cfg.schemas.match(job); s != nil {
+		if err := s.validate(job); err != nil {
+			svc.throw(EventPushError, &JobError{Job: job, Pipeline: pipe.Name(), Caused: err})
+			return "", err
+		}
+	}
+
+	return svc.deliver(pipe, job)
+}
+
+// deliver pushes job into the pipeline, it's fallback pipelines or journals it in the outbox when the pipeline
+// is unavailable.
+func (svc *Service) deliver(pipe *Pipeline, job *Job) (string, error) {
+	if svc.outbox != nil && svc.outbox.size(pipe) != 0 {
+		// jobs pushed after the broker recovery must wait for the earlier journaled ones
+		caused := svc.healthy(pipe)
+		if caused == nil {
+			caused = errOutboxBacklog
+		}
+
+		return svc.outbox.push(pipe, job, caused)
+	}
+
+	id, err := svc.pushPipeline(pipe, job)
+	if err != nil && svc.outbox != nil && svc.unavailable(pipe, err) {
+		return svc.outbox.push(pipe, job, err)
+	}
+
+	return id, err
+}
+
+// pushPipeline pushes job into given pipeline or into it's fallback pipelines.
+func (svc *Service) pushPipeline(pipe *Pipeline, job *Job) (string, error) {
+	if f, ok := svc.failovers[pipe]; ok {
+		return f.push(job)
+	}
+
+	return svc.push(pipe, job)
+}
+
+// push job into given pipeline.
+func (svc *Service) push(pipe *Pipeline, job *Job) (string, error) {
 	broker, ok := svc.Brokers[pipe.Broker()]
 	if !ok {
 		return "", fmt.Errorf("undefined broker `%s`", pipe.Broker())
@@ -271,47 +573,191 @@ func (svc *Service) Push(job *Job) (string, error) {
 	id, err := broker.Push(pipe, job)
 
 	if err != nil {
-		svc.throw(EventPushError, &JobError{Job: job, Caused: err})
+		svc.throw(EventPushError, &JobError{Job: job, Caused: err, Pipeline: pipe.Name()})
 	} else {
-		svc.throw(EventPushOK, &JobEvent{ID: id, Job: job})
+		svc.throw(EventPushOK, &JobEvent{ID: id, Job: job, Pipeline: pipe.Name()})
 	}
 
 	return id, err
 }
 
-// exec executed job using local RR server. Make sure that service is started.
+// Route pushes job into the pipeline with given name, bypassing failover and outbox of the pipeline. Pipelines of
+// forwarding brokers can not be used as targets to avoid forwarding loops.
+func (svc *Service) Route(pipeline string, job *Job) (string, error) {
+	pipe, err := svc.route(pipeline)
+	if err != nil {
+		return "", err
+	}
+
+	return svc.push(pipe, job)
+}
+
+// Healthy returns error if broker of the pipeline with given name is not able to accept jobs.
+func (svc *Service) Healthy(pipeline string) error {
+	pipe, err := svc.route(pipeline)
+	if err != nil {
+		return err
+	}
+
+	return svc.healthy(pipe)
+}
+
+// Withdraw removes pending job routed into the pipeline with given name.
+func (svc *Service) Withdraw(pipeline, id string) error {
+	pipe, err := svc.route(pipeline)
+	if err != nil {
+		return err
+	}
+
+	r, ok := svc.Brokers[pipe.Broker()].(Remover)
+	if !ok {
+		return fmt.Errorf("broker `%s` does not support job removal", pipe.Broker())
+	}
+
+	return r.Remove(pipe, id)
+}
+
+// route returns pipeline which can be used as routing target.
+func (svc *Service) route(pipeline string) (*Pipeline, error) {
+	pipe := svc.cfg.pipelines.Get(pipeline)
+	if pipe == nil {
+		return nil, fmt.Errorf("undefined pipeline `%s`", pipeline)
+	}
+
+	if _, ok := svc.Brokers[pipe.Broker()].(Forwarder); ok {
+		return nil, fmt.Errorf("unable to route into forwarding pipeline `%s`", pipeline)
+	}
+
+	return pipe, nil
+}
+
+// exec executed job using local RR server. Make sure that service is started. Jobs consumed from the quarantine
+// pipeline are not validated again to avoid moving them in circle.
 func (svc *Service) exec(id string, j *Job) error {
-	start := time.Now()
-	svc.throw(EventJobStart, &JobEvent{ID: id, Job: j, start: start})
+	if s := svc.cfg.schemas.match(j); s != nil && s.Quarantine != "" && delivery(j).Pipeline != s.Quarantine {
+		if err := s.validate(j); err != nil {
+			return svc.quarantine(s, j)
+		}
+	}
+
+	return Execute(svc.throw, id, j, func() error {
+		// ignore response for now, possibly add more routing options
+		_, err := svc.rr.Exec(&roadrunner.Payload{
+			Body:    j.Body(),
+			Context: j.Context(id),
+		})
+
+		return err
+	})
+}
 
-	// ignore response for now, possibly add more routing options
-	_, err := svc.rr.Exec(&roadrunner.Payload{
-		Body:    j.Body(),
-		Context: j.Context(id),
+// Execute runs the job using given function and notifies listener about job start, completion, failure and
+// timeout. Used by the service and by brokers executing jobs on their own.
+func Execute(lsn func(event int, ctx interface{}), id string, j *Job, exec func() error) error {
+	start := time.Now()
+	d := delivery(j)
+
+	lsn(EventJobStart, &JobEvent{
+		ID:        id,
+		Job:       j,
+		Pipeline:  d.Pipeline,
+		Attempt:   d.Attempt,
+		QueueWait: d.QueueWait(start),
+		start:     start,
 	})
 
+	err := exec()
+
+	elapsed := time.Since(start)
+
 	if err == nil {
-		svc.throw(EventJobOK, &JobEvent{
-			ID:      id,
-			Job:     j,
-			start:   start,
-			elapsed: time.Since(start),
+		lsn(EventJobOK, &JobEvent{
+			ID:        id,
+			Job:       j,
+			Pipeline:  d.Pipeline,
+			Attempt:   d.Attempt,
+			QueueWait: d.QueueWait(start),
+			start:     start,
+			elapsed:   elapsed,
 		})
 	} else {
-		svc.throw(EventJobError, &JobError{
-			ID:     id,
-			Job:    j,
-			Caused: err, start: start,
-			elapsed: time.Since(start),
+		lsn(EventJobError, &JobError{
+			ID:        id,
+			Job:       j,
+			Caused:    err,
+			Pipeline:  d.Pipeline,
+			Attempt:   d.Attempt,
+			QueueWait: d.QueueWait(start),
+			start:     start,
+			elapsed:   elapsed,
 		})
 	}
 
+	if j.Options != nil && elapsed > j.Options.TimeoutDuration() {
+		lsn(EventJobTimeout, &JobEvent{
+			ID:        id,
+			Job:       j,
+			Pipeline:  d.Pipeline,
+			Attempt:   d.Attempt,
+			QueueWait: d.QueueWait(start),
+			start:     start,
+			elapsed:   elapsed,
+		})
+	}
+
+	return err
+}
+
+// quarantine moves job with invalid payload into the quarantine pipeline.
+func (svc *Service) quarantine(s *Schema, j *Job) error {
+	pipe := svc.cfg.pipelines.Get(s.Quarantine)
+	if pipe == nil {
+		return fmt.Errorf("undefined quarantine pipeline `%s`", s.Quarantine)
+	}
+
+	opts := &Options{}
+	if j.Options != nil {
+		*opts = *j.Options
+	}
+	opts.Pipeline = s.Quarantine
+	opts.Delay = 0
+
+	_, err := svc.push(pipe, &Job{Job: j.Job, Payload: j.Payload, Options: opts})
 	return err
 }
 
-// register died job, can be used to move to fallback testQueue or log
+// error registers failed job and notifies listeners if job is going to be retried or is dead. Nothing is reported
+// when job attempt is unknown.
 func (svc *Service) error(id string, j *Job, err error) {
-	// nothing for now, possibly route to another pipeline
+	d := delivery(j)
+	if d.AttemptUnknown {
+		return
+	}
+
+	e := &JobError{
+		ID:       id,
+		Job:      j,
+		Caused:   err,
+		Pipeline: d.Pipeline,
+		Attempt:  d.Attempt,
+	}
+
+	if retry, delay := NextRetry(j, d.Attempt, err); retry {
+		e.NextRetry = time.Now().Add(delay)
+		svc.throw(EventJobRetry, e)
+		return
+	}
+
+	svc.throw(EventJobDead, e)
+}
+
+// delivery returns job delivery information or empty delivery when unknown.
+func delivery(j *Job) *Delivery {
+	if j.Delivery == nil {
+		return &Delivery{}
+	}
+
+	return j.Delivery
 }
 
 // throw handles service, server and pool events.
@@ -456,3 +456,105 @@ func TestService_DoErrorJob(t *testing.T) {
 	assert.Error(t, jobErr)
 	assert.Contains(t, jobErr.Error(), "something is wrong")
 }
+
+type forwardBroker struct {
+	testBroker
+	router Router
+}
+
+func (b *forwardBroker) UseRouter(r Router) {
+	b.router = r
+}
+
+func TestService_Route(t *testing.T) {
+	fb := &forwardBroker{}
+
+	c := service.NewContainer(logrus.New())
+	c.Register("jobs", &Service{Brokers: map[string]Broker{"ephemeral": &testBroker{}, "fanout": fb}})
+
+	assert.NoError(t, c.Init(viperConfig(`{
+	"jobs":{
+		"pipelines":{
+			"default":{"broker":"ephemeral"},
+			"mirror":{"broker":"fanout"}
+		}
+	}
+}`)))
+
+	svc := jobs(c)
+	assert.Equal(t, svc, fb.router)
+
+	ready := make(chan interface{}, 2)
+	svc.AddListener(func(event int, ctx interface{}) {
+		if event == EventBrokerReady {
+			ready <- nil
+		}
+	})
+
+	go func() { c.Serve() }()
+	defer c.Stop()
+	<-ready
+	<-ready
+
+	id, err := svc.Route("default", &Job{Job: "test", Payload: "body", Options: &Options{}})
+	assert.NoError(t, err)
+	assert.NotEqual(t, "", id)
+
+	_, err = svc.Route("missing", &Job{Job: "test", Payload: "body", Options: &Options{}})
+	assert.Error(t, err)
+
+	_, err = svc.Route("mirror", &Job{Job: "test", Payload: "body", Options: &Options{}})
+	assert.Error(t, err)
+
+	assert.NoError(t, svc.Healthy("default"))
+	assert.Error(t, svc.Healthy("missing"))
+	assert.Error(t, svc.Healthy("mirror"))
+
+	// test broker is not able to remove jobs
+	assert.Error(t, svc.Withdraw("default", id))
+}
+
+// executorBroker executes jobs of all pipelines on it's own.
+type executorBroker struct {
+	testBroker
+	consumed chan *Pipeline
+}
+
+// Executes returns true for all pipelines.
+func (b *executorBroker) Executes(pipe *Pipeline) bool {
+	return true
+}
+
+// Consume notifies about pipelines being consumed.
+func (b *executorBroker) Consume(pipe *Pipeline, execPool chan Handler, errHandler ErrorHandler) error {
+	if execPool != nil {
+		b.consumed <- pipe
+	}
+
+	return b.testBroker.Consume(pipe, execPool, errHandler)
+}
+
+func TestService_ServeWithoutWorkers(t *testing.T) {
+	b := &executorBroker{consumed: make(chan *Pipeline, 1)}
+
+	c := service.NewContainer(logrus.New())
+	c.Register("jobs", &Service{Brokers: map[string]Broker{"ephemeral": &testBroker{}, "executor": b}})
+
+	assert.NoError(t, c.Init(viperConfig(`{
+	"jobs":{
+		"pipelines":{
+			"default":{"broker":"ephemeral"},
+			"webhook":{"broker":"executor"}
+		},
+    	"dispatch": {
+	    	"spiral-jobs-tests-local-*.pipeline": "default"
+    	},
+    	"consume": ["default", "webhook"]
+	}
+}`)))
+
+	go func() { c.Serve() }()
+	defer c.Stop()
+
+	assert.Equal(t, "webhook", (<-b.consumed).Name())
+}
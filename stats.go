@@ -0,0 +1,170 @@
+package jobs
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	// rateWindow defines number of seconds to calculate pipeline rates within.
+	rateWindow = 60
+
+	// durationSamples defines number of recent job durations to calculate average and percentiles from.
+	durationSamples = 1024
+)
+
+// statsCollector counts job events per pipeline.
+type statsCollector struct {
+	mu    sync.Mutex
+	pipes map[string]*pipeStats
+}
+
+// pipeStats contains counters of single pipeline.
+type pipeStats struct {
+	pushed, processed, errors, retried int64
+
+	pushRate, processRate rateCounter
+
+	// recent execution durations (ring buffer)
+	durations []time.Duration
+	next      int
+}
+
+// rateCounter counts events within one second buckets.
+type rateCounter [rateWindow]struct {
+	second int64
+	count  int64
+}
+
+// newStatsCollector creates new stats collector.
+func newStatsCollector() *statsCollector {
+	return &statsCollector{pipes: make(map[string]*pipeStats)}
+}
+
+// listen counts job events.
+func (c *statsCollector) listen(event int, ctx interface{}) {
+	now := time.Now()
+
+	switch event {
+	case EventPushOK:
+		e := ctx.(*JobEvent)
+		c.update(e.Pipeline, func(s *pipeStats) {
+			s.pushed++
+			s.pushRate.add(now)
+		})
+
+	case EventJobOK:
+		e := ctx.(*JobEvent)
+		c.update(e.Pipeline, func(s *pipeStats) {
+			s.processed++
+			s.processRate.add(now)
+			s.observe(e.Elapsed())
+		})
+
+	case EventJobError:
+		e := ctx.(*JobError)
+		c.update(e.Pipeline, func(s *pipeStats) {
+			s.errors++
+			s.processRate.add(now)
+			s.observe(e.Elapsed())
+		})
+
+	case EventJobRetry:
+		e := ctx.(*JobError)
+		c.update(e.Pipeline, func(s *pipeStats) {
+			s.retried++
+		})
+	}
+}
+
+// update pipeline stats under lock.
+func (c *statsCollector) update(pipeline string, f func(s *pipeStats)) {
+	if pipeline == "" {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	s, ok := c.pipes[pipeline]
+	if !ok {
+		s = &pipeStats{}
+		c.pipes[pipeline] = s
+	}
+
+	f(s)
+}
+
+// fill the stat with collected pipeline counters.
+func (c *statsCollector) fill(stat *Stat) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	s, ok := c.pipes[stat.Pipeline]
+	if !ok {
+		return
+	}
+
+	now := time.Now()
+
+	stat.Pushed = s.pushed
+	stat.Processed = s.processed
+	stat.Errors = s.errors
+	stat.Retried = s.retried
+	stat.PushRate = s.pushRate.rate(now)
+	stat.ProcessRate = s.processRate.rate(now)
+	stat.AvgDuration, stat.P95Duration = s.timings()
+}
+
+// observe job execution duration.
+func (s *pipeStats) observe(d time.Duration) {
+	if len(s.durations) < durationSamples {
+		s.durations = append(s.durations, d)
+		return
+	}
+
+	s.durations[s.next] = d
+	s.next = (s.next + 1) % durationSamples
+}
+
+// timings returns average and 95th percentile of recent job durations.
+func (s *pipeStats) timings() (avg, p95 time.Duration) {
+	if len(s.durations) == 0 {
+		return 0, 0
+	}
+
+	sorted := make([]time.Duration, len(s.durations))
+	copy(sorted, s.durations)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	var sum time.Duration
+	for _, d := range sorted {
+		sum += d
+	}
+
+	return sum / time.Duration(len(sorted)), sorted[(len(sorted)*95-1)/100]
+}
+
+// add event to the counter.
+func (r *rateCounter) add(now time.Time) {
+	sec := now.Unix()
+	b := &r[sec%rateWindow]
+	if b.second != sec {
+		b.second, b.count = sec, 0
+	}
+
+	b.count++
+}
+
+// rate returns average number of events per second within the window.
+func (r *rateCounter) rate(now time.Time) float64 {
+	var count int64
+	for _, b := range r {
+		if now.Unix()-b.second < rateWindow {
+			count += b.count
+		}
+	}
+
+	return float64(count) / rateWindow
+}
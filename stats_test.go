@@ -0,0 +1,61 @@
+package jobs
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestStatsCollector_Counters(t *testing.T) {
+	c := newStatsCollector()
+
+	c.listen(EventPushOK, &JobEvent{Pipeline: "default"})
+	c.listen(EventPushOK, &JobEvent{Pipeline: "default"})
+	c.listen(EventPushOK, &JobEvent{Pipeline: "other"})
+	c.listen(EventPushOK, &JobEvent{})
+	c.listen(EventJobOK, &JobEvent{Pipeline: "default", elapsed: time.Second})
+	c.listen(EventJobError, &JobError{Pipeline: "default", Caused: errors.New("failed"), elapsed: 3 * time.Second})
+	c.listen(EventJobRetry, &JobError{Pipeline: "default", Caused: errors.New("failed")})
+
+	stat := &Stat{Pipeline: "default"}
+	c.fill(stat)
+
+	assert.Equal(t, int64(2), stat.Pushed)
+	assert.Equal(t, int64(1), stat.Processed)
+	assert.Equal(t, int64(1), stat.Errors)
+	assert.Equal(t, int64(1), stat.Retried)
+	assert.Equal(t, 2.0/rateWindow, stat.PushRate)
+	assert.Equal(t, 2.0/rateWindow, stat.ProcessRate)
+	assert.Equal(t, 2*time.Second, stat.AvgDuration)
+	assert.Equal(t, 3*time.Second, stat.P95Duration)
+
+	stat = &Stat{Pipeline: "missing"}
+	c.fill(stat)
+	assert.Equal(t, int64(0), stat.Pushed)
+}
+
+func TestStatsCollector_Timings(t *testing.T) {
+	s := &pipeStats{}
+	for i := 1; i <= durationSamples+100; i++ {
+		s.observe(time.Duration(i) * time.Millisecond)
+	}
+
+	assert.Len(t, s.durations, durationSamples)
+
+	avg, p95 := s.timings()
+	assert.Equal(t, time.Duration(100+durationSamples/2)*time.Millisecond+500*time.Microsecond, avg)
+	assert.Equal(t, time.Duration(101+972)*time.Millisecond, p95)
+}
+
+func TestRateCounter(t *testing.T) {
+	r := &rateCounter{}
+	now := time.Now()
+
+	r.add(now.Add(-2 * rateWindow * time.Second))
+	r.add(now.Add(-time.Second))
+	r.add(now)
+	r.add(now)
+
+	assert.Equal(t, 3.0/rateWindow, r.rate(now))
+}